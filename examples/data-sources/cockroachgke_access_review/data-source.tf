@@ -0,0 +1,13 @@
+variable "pii_tables" {
+  type    = set(string)
+  default = ["app.public.customers", "app.billing.payment_methods"]
+}
+
+data "cockroachgke_access_review" "pii" {
+  tables = var.pii_tables
+}
+
+# The quarterly access review artifact
+output "pii_access" {
+  value = [for a in data.cockroachgke_access_review.pii.access : "${a.table}: ${a.role} (via ${a.via}, ${a.source})"]
+}
@@ -0,0 +1,3 @@
+data "cockroachgke_advisory_lock" "schema" {
+  name = "app-schema"
+}
@@ -0,0 +1,13 @@
+variable "expected_changefeed_jobs" {
+  type    = list(number)
+  default = []
+}
+
+data "cockroachgke_changefeeds" "running" {
+  status = "running"
+}
+
+# Feeds on the cluster which aren't in the list of those this workspace expects
+output "orphan_changefeeds" {
+  value = [for feed in data.cockroachgke_changefeeds.running.changefeeds : feed.job_id if !contains(var.expected_changefeed_jobs, feed.job_id)]
+}
@@ -0,0 +1,6 @@
+# List temporary databases and users left behind by cancelled PR pipelines, cockroachgke_cleanup_run drops them
+data "cockroachgke_cleanup" "expired" {}
+
+output "expired" {
+  value = data.cockroachgke_cleanup.expired.expired[*].object_name
+}
@@ -0,0 +1,10 @@
+data "cockroachgke_cluster_settings" "rangefeed" {
+  prefix = "kv.rangefeed."
+
+  lifecycle {
+    postcondition {
+      condition     = self.values["kv.rangefeed.enabled"] == "true"
+      error_message = "Rangefeeds must be enabled on the cluster"
+    }
+  }
+}
@@ -0,0 +1,5 @@
+data "cockroachgke_connection_info" "current" {}
+
+output "ca_fingerprint" {
+  value = data.cockroachgke_connection_info.current.ca_fingerprint
+}
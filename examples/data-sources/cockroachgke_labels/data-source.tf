@@ -0,0 +1,4 @@
+data "cockroachgke_labels" "team_payments" {
+  key   = "team"
+  value = "payments"
+}
@@ -0,0 +1,16 @@
+variable "changefeed_job_ids" {
+  type    = set(number)
+  default = []
+}
+
+data "cockroachgke_protected_timestamps" "managed" {
+  job_ids = var.changefeed_job_ids
+}
+
+# Fail the run before garbage collection is held back for more than a day
+check "protected_timestamps" {
+  assert {
+    condition     = data.cockroachgke_protected_timestamps.managed.oldest_age_seconds < 86400
+    error_message = "A changefeed has held a protected timestamp for more than a day."
+  }
+}
@@ -0,0 +1,5 @@
+data "cockroachgke_regions" "cluster" {}
+
+output "regions" {
+  value = data.cockroachgke_regions.cluster.names
+}
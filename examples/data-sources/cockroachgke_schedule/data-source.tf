@@ -0,0 +1,3 @@
+data "cockroachgke_schedule" "nightly_backup" {
+  label = "nightly_backup"
+}
@@ -0,0 +1,10 @@
+# Table created by hand, read back to write a matching resource
+data "cockroachgke_show_create" "orders" {
+  object_type = "table"
+  database    = "app"
+  name        = "public.orders"
+}
+
+output "orders_ddl" {
+  value = data.cockroachgke_show_create.orders.create_statement
+}
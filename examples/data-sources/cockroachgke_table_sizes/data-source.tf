@@ -0,0 +1,3 @@
+data "cockroachgke_table_sizes" "app" {
+  database = "app"
+}
@@ -0,0 +1,12 @@
+data "cockroachgke_unmanaged_objects" "cluster" {
+  managed_users     = [for user in cockroachgke_user.all : coalesce(user.full_username, user.username)]
+  managed_databases = [for database in cockroachgke_database.all : database.name]
+}
+
+# Nothing may exist outside of terraform
+check "unmanaged_objects" {
+  assert {
+    condition     = data.cockroachgke_unmanaged_objects.cluster.in_sync
+    error_message = "Unmanaged users ${join(", ", data.cockroachgke_unmanaged_objects.cluster.unmanaged_users)} and databases ${join(", ", data.cockroachgke_unmanaged_objects.cluster.unmanaged_databases)} exist outside of terraform."
+  }
+}
@@ -0,0 +1,5 @@
+ephemeral "cockroachgke_credentials" "migrations" {
+  prefix = "migrate_"
+  ttl    = "30m"
+  roles  = ["admin"]
+}
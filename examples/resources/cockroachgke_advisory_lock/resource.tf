@@ -0,0 +1,7 @@
+# Held for as long as the resource exists, migration tools skip their run while it's taken. The ttl frees the lock
+# for others should a pipeline never get to destroy it.
+resource "cockroachgke_advisory_lock" "schema" {
+  name         = "app-schema"
+  wait_timeout = "5m"
+  ttl          = "2h"
+}
@@ -0,0 +1,10 @@
+# Run from a scheduled pipeline to drop temporary databases and users left behind by cancelled PR pipelines
+variable "run_at" {
+  type = string
+}
+
+resource "cockroachgke_cleanup_run" "expired" {
+  triggers = {
+    run_at = var.run_at
+  }
+}
@@ -0,0 +1,8 @@
+# Terraform 1.12 and later can import by identity, cluster_id is optional and checked against the connected cluster
+import {
+  to = cockroachgke_database.example
+  identity = {
+    name       = "my_database"
+    cluster_id = "a9c4b1e2-0f3d-4c8e-9b71-5d2e6f8a1c30"
+  }
+}
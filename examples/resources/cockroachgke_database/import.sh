@@ -0,0 +1,2 @@
+# Databases are imported by name
+terraform import cockroachgke_database.example my_database
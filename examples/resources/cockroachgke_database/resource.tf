@@ -0,0 +1,7 @@
+# Creates the database with its schemas, all owned by the migration role
+resource "cockroachgke_database" "app" {
+  name                 = "app"
+  default_schema_owner = "migrator"
+  additional_schemas   = ["audit", "reporting"]
+  public_schema_create = false
+}
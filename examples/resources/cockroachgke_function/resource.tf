@@ -0,0 +1,9 @@
+resource "cockroachgke_function" "add" {
+  database         = cockroachgke_database.app.name
+  name             = "add"
+  arguments        = "a INT, b INT"
+  returns          = "INT"
+  body             = "SELECT a + b"
+  volatility       = "immutable"
+  execute_grantees = [cockroachgke_user.app.username]
+}
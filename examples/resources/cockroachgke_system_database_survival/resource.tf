@@ -0,0 +1,6 @@
+resource "cockroachgke_system_database_survival" "this" {
+  primary_region    = "europe-west1"
+  regions           = ["europe-west1", "europe-west3", "europe-west4"]
+  survival_goal     = "region"
+  confirm_dangerous = true
+}
@@ -0,0 +1,2 @@
+# Table statistics are imported as database.schema.table
+terraform import cockroachgke_table_statistics.events app.public.events
@@ -0,0 +1,11 @@
+resource "cockroachgke_table_statistics" "events" {
+  database                     = cockroachgke_database.app.name
+  table                        = "events"
+  automatic_collection_enabled = true
+  fraction_stale_rows          = 0.05
+
+  # Applies wait for the resulting schema change job
+  timeouts {
+    update = "45m"
+  }
+}
@@ -0,0 +1,2 @@
+# Storage parameters are imported as database.schema.table
+terraform import cockroachgke_table_storage_params.events app.public.events
@@ -0,0 +1,10 @@
+resource "cockroachgke_table_storage_params" "events" {
+  database = cockroachgke_database.app.name
+  table    = "events"
+
+  params = {
+    ttl_expire_after         = "30 days"
+    ttl_job_cron             = "@daily"
+    exclude_data_from_backup = "true"
+  }
+}
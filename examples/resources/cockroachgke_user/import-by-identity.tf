@@ -0,0 +1,8 @@
+# Terraform 1.12 and later can import by identity, the password has to be added to the config afterwards
+import {
+  to = cockroachgke_user.example
+  identity = {
+    database = "my_database"
+    username = "my_user"
+  }
+}
@@ -0,0 +1,3 @@
+# Users are imported as database:username, the password has to be added to the config afterwards.
+# Set ignore_password_changes = true to keep the first apply from resetting the credentials of the imported user.
+terraform import cockroachgke_user.example my_database:my_user
@@ -0,0 +1,40 @@
+variable "reporting_password" {
+  type      = string
+  sensitive = true
+}
+
+variable "environments" {
+  type    = set(string)
+  default = ["staging", "production"]
+}
+
+# One account reading the orders of every environment
+resource "cockroachgke_user" "reporting" {
+  database    = "reporting"
+  username    = "reporting"
+  password    = var.reporting_password
+  privileges  = ["select"]
+  search_path = "reports,public"
+
+  dynamic "grant" {
+    for_each = var.environments
+    content {
+      database   = "orders_${grant.value}"
+      privileges = ["select"]
+    }
+  }
+}
+
+variable "cdc_password" {
+  type      = string
+  sensitive = true
+}
+
+# Service account running changefeeds into a shared kafka sink
+resource "cockroachgke_user" "cdc" {
+  database             = "orders_production"
+  username             = "cdc"
+  password             = var.cdc_password
+  privileges           = ["select", "changefeed"]
+  external_connections = ["kafka"]
+}
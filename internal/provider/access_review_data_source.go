@@ -0,0 +1,283 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/setvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/lib/pq"
+	"golang.org/x/exp/slices"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &AccessReviewDataSource{}
+
+// How a role came to read a table
+const (
+	accessSourceGrant             = "grant"
+	accessSourceDefaultPrivileges = "default_privileges"
+	accessSourceAdmin             = "admin"
+)
+
+func NewAccessReviewDataSource() datasource.DataSource {
+	return &AccessReviewDataSource{}
+}
+
+// AccessReviewDataSource lists who can read a set of sensitive tables.
+type AccessReviewDataSource struct {
+	db Database
+}
+
+// AccessReviewDataSourceModel describes the data source data model.
+type AccessReviewDataSourceModel struct {
+	Tables  types.Set          `tfsdk:"tables"`
+	Access  []tableAccessModel `tfsdk:"access"`
+	Readers types.List         `tfsdk:"readers"`
+}
+
+// tableAccessModel is one role able to read one table
+type tableAccessModel struct {
+	Table  types.String `tfsdk:"table"`
+	Role   types.String `tfsdk:"role"`
+	Via    types.String `tfsdk:"via"`
+	Source types.String `tfsdk:"source"`
+}
+
+// Metadata appends the data source name to the provider name
+func (d *AccessReviewDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_access_review"
+}
+
+// Schema is the shape of the data source - what you need to supply and what you get back
+func (d *AccessReviewDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Every role able to select from the given tables, e.g. those holding PII, for access reviews. Covers grants on the tables, default privileges which grant future tables of their schemas, and the members inheriting either through roles. Grants to `public` extend to every user, and the `admin` role and its members read every table",
+		Attributes: map[string]schema.Attribute{
+			"tables": schema.SetAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: "Tables to review as `database.schema.table`",
+				Required:            true,
+				Validators: []validator.Set{
+					setvalidator.SizeAtLeast(1),
+					setvalidator.ValueStringsAre(stringvalidator.RegexMatches(regexp.MustCompile(`^[^.]+\.[^.]+\.[^.]+$`), "must have the form database.schema.table")),
+				},
+			},
+			"access": schema.ListNestedAttribute{
+				MarkdownDescription: "Roles able to read each table, ordered by table, role and the role they read through",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"table": schema.StringAttribute{
+							MarkdownDescription: "Table as given in `tables`",
+							Computed:            true,
+						},
+						"role": schema.StringAttribute{
+							MarkdownDescription: "User or role able to read the table",
+							Computed:            true,
+						},
+						"via": schema.StringAttribute{
+							MarkdownDescription: "Role holding the privilege, `role` itself unless inherited",
+							Computed:            true,
+						},
+						"source": schema.StringAttribute{
+							MarkdownDescription: "`grant` for privileges on the table, `default_privileges` for default privileges of its schema, `admin` for members of the `admin` role",
+							Computed:            true,
+						},
+					},
+				},
+			},
+			"readers": schema.ListAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: "Distinct roles of `access`, sorted",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+// Configure adds the provider configured client to the data source
+func (d *AccessReviewDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(Database)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *CockroachClient, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.db = client
+}
+
+func (d *AccessReviewDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data AccessReviewDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var tables []string
+	resp.Diagnostics.Append(data.Tables.ElementsAs(ctx, &tables, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	slices.Sort(tables)
+
+	client, err := d.db.ConnectForRead(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to connect to cockroach", err.Error())
+		return
+	}
+	defer client.Close()
+
+	dialect, err := client.Dialect(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Read access review error", fmt.Sprintf("Unable to determine server version, got error: %s", err))
+		return
+	}
+
+	members, err := roleMembers(ctx, client)
+	if err != nil {
+		resp.Diagnostics.AddError("Read access review error", fmt.Sprintf("Unable to list role memberships, got error: %s", err))
+		return
+	}
+
+	// Every user is implicitly a member of public
+	usersQuery, diags := dialect.Users()
+	resp.Diagnostics.Append(diags...)
+	users, err := unmanagedNames(ctx, client, usersQuery, 0, nil, nil)
+	if err != nil {
+		resp.Diagnostics.AddError("Read access review error", fmt.Sprintf("Unable to list users, got error: %s", err))
+		return
+	}
+	members["public"] = users
+
+	data.Access = []tableAccessModel{}
+	readers := []string{}
+	for _, table := range tables {
+		parts := strings.Split(table, ".")
+		database, schemaName := parts[0], parts[1]
+
+		granted, err := queryNames(ctx, client, fmt.Sprintf("SELECT DISTINCT grantee FROM [SHOW GRANTS ON TABLE %s.%s.%s] WHERE privilege_type IN ('SELECT', 'ALL')", pq.QuoteIdentifier(database), pq.QuoteIdentifier(schemaName), pq.QuoteIdentifier(parts[2])))
+		if err != nil {
+			resp.Diagnostics.AddError("Read access review error", fmt.Sprintf("Unable to read the grants on %s, got error: %s", table, err))
+			return
+		}
+
+		query, diags := dialect.DefaultTableReaders(database)
+		resp.Diagnostics.Append(diags...)
+		defaults, err := queryNames(ctx, client, query, schemaName)
+		if err != nil {
+			resp.Diagnostics.AddError("Read access review error", fmt.Sprintf("Unable to read the default privileges of %s, got error: %s", table, err))
+			return
+		}
+
+		access := tableAccess(table, granted, accessSourceGrant, members)
+		access = append(access, tableAccess(table, defaults, accessSourceDefaultPrivileges, members)...)
+		// Admins read every table, listed through the role unless it is granted on the table anyway
+		if !slices.Contains(granted, "admin") {
+			access = append(access, tableAccess(table, []string{"admin"}, accessSourceAdmin, members)...)
+		}
+		slices.SortFunc(access, func(a, b tableAccessModel) bool { return a.sortKey() < b.sortKey() })
+		for _, a := range access {
+			if !slices.Contains(readers, a.Role.ValueString()) {
+				readers = append(readers, a.Role.ValueString())
+			}
+		}
+		data.Access = append(data.Access, access...)
+	}
+	slices.Sort(readers)
+
+	list, diags := types.ListValueFrom(ctx, types.StringType, readers)
+	resp.Diagnostics.Append(diags...)
+	data.Readers = list
+
+	tflog.Trace(ctx, "read access review")
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// sortKey orders entries of a table by role, the role they read through and the source
+func (a tableAccessModel) sortKey() string {
+	return a.Role.ValueString() + "\x00" + a.Via.ValueString() + "\x00" + a.Source.ValueString()
+}
+
+// tableAccess expands the grantees of a privilege on a table to everyone inheriting it
+func tableAccess(table string, grantees []string, source string, members map[string][]string) []tableAccessModel {
+	access := []tableAccessModel{}
+	for _, grantee := range grantees {
+		for _, role := range inheritors(grantee, members) {
+			access = append(access, tableAccessModel{
+				Table:  types.StringValue(table),
+				Role:   types.StringValue(role),
+				Via:    types.StringValue(grantee),
+				Source: types.StringValue(source),
+			})
+		}
+	}
+	return access
+}
+
+// inheritors lists a role and every role which is a member of it, directly or through other roles
+func inheritors(role string, members map[string][]string) []string {
+	found := []string{role}
+	for i := 0; i < len(found); i++ {
+		for _, member := range members[found[i]] {
+			if !slices.Contains(found, member) {
+				found = append(found, member)
+			}
+		}
+	}
+	return found
+}
+
+// roleMembers maps each role to its direct members
+func roleMembers(ctx context.Context, client Executor) (map[string][]string, error) {
+	rows, err := client.QueryContext(ctx, "SELECT role_name, member FROM [SHOW GRANTS ON ROLE]")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := map[string][]string{}
+	for rows.Next() {
+		var role, member string
+		if err := rows.Scan(&role, &member); err != nil {
+			return nil, err
+		}
+		members[role] = append(members[role], member)
+	}
+	return members, rows.Err()
+}
+
+// queryNames runs a query selecting a single column of names
+func queryNames(ctx context.Context, client Executor, query string, args ...any) ([]string, error) {
+	rows, err := client.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
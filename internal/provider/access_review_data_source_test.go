@@ -0,0 +1,70 @@
+package provider
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+func TestInheritors(t *testing.T) {
+	members := map[string][]string{
+		"pii_readers": {"analysts", "auditor"},
+		"analysts":    {"alice", "pii_readers"},
+	}
+	got := inheritors("pii_readers", members)
+	if want := []string{"pii_readers", "analysts", "auditor", "alice"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("inheritors() = %v, want %v", got, want)
+	}
+}
+
+func TestAccessReviewDataSourceRead(t *testing.T) {
+	version := mockQuery{contains: "SELECT version()", columns: []string{"version"}, rows: [][]driver.Value{{"CockroachDB CCL v23.2.1 (x86_64-pc-linux-gnu)"}}}
+	users := mockQuery{contains: "FROM system.users", columns: []string{"username", "user_id"}, rows: [][]driver.Value{{"root", int64(1)}, {"admin", int64(2)}, {"alice", int64(100)}, {"bob", int64(101)}}}
+
+	for name, tc := range map[string]struct {
+		members  [][]driver.Value
+		granted  [][]driver.Value
+		defaults [][]driver.Value
+		want     []string
+		readers  int
+	}{
+		"roles and default privileges": {
+			members:  [][]driver.Value{{"pii_readers", "alice"}},
+			granted:  [][]driver.Value{{"pii_readers"}},
+			defaults: [][]driver.Value{{"etl"}},
+			want:     []string{"admin via admin by admin", "alice via pii_readers by grant", "etl via etl by default_privileges", "pii_readers via pii_readers by grant"},
+			readers:  4,
+		},
+		"public and admin members": {
+			members: [][]driver.Value{{"admin", "root"}},
+			granted: [][]driver.Value{{"admin"}, {"public"}},
+			want: []string{
+				"admin via admin by grant", "admin via public by grant", "alice via public by grant", "bob via public by grant",
+				"public via public by grant", "root via admin by grant", "root via public by grant",
+			},
+			readers: 5,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			data := readDataSource[AccessReviewDataSourceModel](t, &AccessReviewDataSource{},
+				map[string]any{"tables": []string{"app.public.customers"}},
+				version,
+				mockQuery{contains: "SHOW GRANTS ON ROLE", columns: []string{"role_name", "member"}, rows: tc.members},
+				users,
+				mockQuery{contains: `SHOW GRANTS ON TABLE "app"."public"."customers"`, columns: []string{"grantee"}, rows: tc.granted},
+				mockQuery{contains: `"app".crdb_internal.default_privileges`, columns: []string{"grantee"}, rows: tc.defaults},
+			)
+
+			got := []string{}
+			for _, a := range data.Access {
+				got = append(got, a.Role.ValueString()+" via "+a.Via.ValueString()+" by "+a.Source.ValueString())
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("access = %v, want %v", got, tc.want)
+			}
+			if len(data.Readers.Elements()) != tc.readers {
+				t.Errorf("unexpected readers %s", data.Readers)
+			}
+		})
+	}
+}
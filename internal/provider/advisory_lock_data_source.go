@@ -0,0 +1,133 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &AdvisoryLockDataSource{}
+
+func NewAdvisoryLockDataSource() datasource.DataSource {
+	return &AdvisoryLockDataSource{}
+}
+
+// AdvisoryLockDataSource reports who holds a named lock
+type AdvisoryLockDataSource struct {
+	db Database
+}
+
+// AdvisoryLockDataSourceModel describes the data source data model.
+type AdvisoryLockDataSourceModel struct {
+	Name       types.String `tfsdk:"name"`
+	Held       types.Bool   `tfsdk:"held"`
+	Holder     types.String `tfsdk:"holder"`
+	AcquiredAt types.String `tfsdk:"acquired_at"`
+	ExpiresAt  types.String `tfsdk:"expires_at"`
+}
+
+// Metadata appends the data source name to the provider name
+func (d *AdvisoryLockDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_advisory_lock"
+}
+
+// Schema is the shape of the data source - what you can filter on and what you get back
+func (d *AdvisoryLockDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "State of a lock taken with the `cockroachgke_advisory_lock` resource or by a migration tool",
+		Attributes: map[string]schema.Attribute{
+			"name": schema.StringAttribute{
+				MarkdownDescription: "Name of the lock",
+				Required:            true,
+			},
+			"held": schema.BoolAttribute{
+				MarkdownDescription: "Whether anyone holds the lock, expired locks are free to take over",
+				Computed:            true,
+			},
+			"holder": schema.StringAttribute{
+				MarkdownDescription: "Who holds the lock",
+				Computed:            true,
+			},
+			"acquired_at": schema.StringAttribute{
+				MarkdownDescription: "When the lock was acquired",
+				Computed:            true,
+			},
+			"expires_at": schema.StringAttribute{
+				MarkdownDescription: "When the lock expires, null for locks held until released",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+// Configure adds the provider configured client to the data source
+func (d *AdvisoryLockDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(Database)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *CockroachClient, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.db = client
+}
+
+// Read looks up the lock row, ignoring it once expired
+func (d *AdvisoryLockDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data AdvisoryLockDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, err := d.db.Connect(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to connect to cockroach",
+			err.Error(),
+		)
+		return
+	}
+	defer client.Close()
+
+	var holder string
+	var acquiredAt time.Time
+	var expiresAt sql.NullTime
+	err = client.QueryRowContext(ctx, "SELECT holder, acquired_at, expires_at FROM "+locksTable+" WHERE name = $1 AND (expires_at IS NULL OR expires_at >= now())", data.Name.ValueString()).Scan(&holder, &acquiredAt, &expiresAt)
+	switch {
+	case err == sql.ErrNoRows || isMissingMetadata(err):
+		data.Held = types.BoolValue(false)
+		data.Holder = types.StringNull()
+		data.AcquiredAt = types.StringNull()
+		data.ExpiresAt = types.StringNull()
+	case err != nil:
+		resp.Diagnostics.AddError("Read lock error", fmt.Sprintf("Unable to read lock, got error: %s", err))
+		return
+	default:
+		data.Held = types.BoolValue(true)
+		data.Holder = types.StringValue(holder)
+		data.AcquiredAt = types.StringValue(acquiredAt.UTC().Format(time.RFC3339))
+		data.ExpiresAt = types.StringNull()
+		if expiresAt.Valid {
+			data.ExpiresAt = types.StringValue(expiresAt.Time.UTC().Format(time.RFC3339))
+		}
+	}
+
+	tflog.Trace(ctx, "read a lock")
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,38 @@
+package provider
+
+import (
+	"database/sql/driver"
+	"testing"
+	"time"
+)
+
+func TestAdvisoryLockDataSourceRead(t *testing.T) {
+	acquiredAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	for name, tc := range map[string]struct {
+		rows      [][]driver.Value
+		held      bool
+		holder    string
+		expiresAt string
+	}{
+		"held":             {[][]driver.Value{{"ci-1234", acquiredAt, nil}}, true, "ci-1234", ""},
+		"held for an hour": {[][]driver.Value{{"ci-1234", acquiredAt, acquiredAt.Add(time.Hour)}}, true, "ci-1234", "2024-05-01T13:00:00Z"},
+		"not held":         {nil, false, "", ""},
+	} {
+		t.Run(name, func(t *testing.T) {
+			data := readDataSource[AdvisoryLockDataSourceModel](t, &AdvisoryLockDataSource{},
+				map[string]any{"name": "migrations"},
+				mockQuery{contains: "FROM " + locksTable, columns: []string{"holder", "acquired_at", "expires_at"}, rows: tc.rows},
+			)
+			if data.Held.ValueBool() != tc.held || data.Holder.ValueString() != tc.holder {
+				t.Errorf("expected held %t by %q, got %t by %q", tc.held, tc.holder, data.Held.ValueBool(), data.Holder.ValueString())
+			}
+			if tc.held && data.AcquiredAt.ValueString() != "2024-05-01T12:00:00Z" {
+				t.Errorf("unexpected acquired_at %s", data.AcquiredAt.ValueString())
+			}
+			if data.ExpiresAt.ValueString() != tc.expiresAt {
+				t.Errorf("expected expires_at %q, got %q", tc.expiresAt, data.ExpiresAt.ValueString())
+			}
+		})
+	}
+}
@@ -0,0 +1,283 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Cockroach has no session advisory locks, and every resource operation uses its own connection anyway,
+// so locks are rows in a provider managed table. External tools take the same lock by inserting a row. Nothing
+// heartbeats a row between terraform runs, so a lock either has a ttl after which anyone may take it over, or
+// persists until released.
+const (
+	locksTable = metadataDatabase + ".public.locks"
+
+	lockPollInterval = 2 * time.Second
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &AdvisoryLockResource{}
+
+func NewAdvisoryLockResource() resource.Resource {
+	return &AdvisoryLockResource{}
+}
+
+// AdvisoryLockResource holds a named lock from create until destroy
+type AdvisoryLockResource struct {
+	db Database
+}
+
+// AdvisoryLockResourceModel describes the resource data model.
+type AdvisoryLockResourceModel struct {
+	Name        types.String `tfsdk:"name"`
+	Holder      types.String `tfsdk:"holder"`
+	WaitTimeout types.String `tfsdk:"wait_timeout"`
+	TTL         types.String `tfsdk:"ttl"`
+	AcquiredAt  types.String `tfsdk:"acquired_at"`
+	ExpiresAt   types.String `tfsdk:"expires_at"`
+}
+
+// Metadata appends the resource name to the provider name
+func (r *AdvisoryLockResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_advisory_lock"
+}
+
+// Schema is the shape of the resource - what you need to supply
+func (r *AdvisoryLockResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Named lock, acquired on create and released on destroy. Migration tools coordinate by inserting into and deleting from the `cockroachgke_metadata.locks` table. The lock is a row rather than a session lock, so a run which never destroys it, e.g. a crashed pipeline, keeps it held. Set `ttl` to let anyone take it over once expired, without it the lock persists until destroyed or deleted from the table by hand",
+		Attributes: map[string]schema.Attribute{
+			"name": schema.StringAttribute{
+				MarkdownDescription: "Name of the lock",
+				Required:            true,
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"holder": schema.StringAttribute{
+				MarkdownDescription: "Who holds the lock, shown to anyone waiting for it. Defaults to `terraform`",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString("terraform"),
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"wait_timeout": schema.StringAttribute{
+				MarkdownDescription: "How long to wait for a lock held by someone else, e.g. `5m`. Fails immediately when unset",
+				Optional:            true,
+			},
+			"ttl": schema.StringAttribute{
+				MarkdownDescription: "How long the lock is held as a Go duration, e.g. `1h`. Once expired others may take it over and the next apply acquires it again. Held until destroyed when unset",
+				Optional:            true,
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"acquired_at": schema.StringAttribute{
+				MarkdownDescription: "When the lock was acquired",
+				Computed:            true,
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"expires_at": schema.StringAttribute{
+				MarkdownDescription: "When the lock expires, null without a `ttl`",
+				Computed:            true,
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+		},
+	}
+}
+
+// Configure adds the provider configured client to the resource
+func (r *AdvisoryLockResource) Configure(_ context.Context, req resource.ConfigureRequest, _ *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	r.db = req.ProviderData.(Database)
+}
+
+// Create acquires the lock, waiting up to wait_timeout while someone else holds it
+func (r *AdvisoryLockResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data *AdvisoryLockResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	wait := time.Duration(0)
+	if !data.WaitTimeout.IsNull() {
+		var err error
+		wait, err = time.ParseDuration(data.WaitTimeout.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("wait_timeout"), "Invalid wait_timeout", err.Error())
+			return
+		}
+	}
+
+	// Seconds until the lock expires, NULL keeps it until released
+	var ttlSeconds any
+	if !data.TTL.IsNull() {
+		ttl, err := time.ParseDuration(data.TTL.ValueString())
+		if err != nil || ttl < time.Second {
+			resp.Diagnostics.AddAttributeError(path.Root("ttl"), "Invalid ttl", fmt.Sprintf("Unable to use %q as ttl, expected a duration of at least a second such as 1h", data.TTL.ValueString()))
+			return
+		}
+		ttlSeconds = int64(ttl / time.Second)
+	}
+
+	client, err := r.db.Connect(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to connect to cockroach",
+			err.Error(),
+		)
+		return
+	}
+	defer client.Close()
+
+	if err := ensureLocksTable(ctx, client); err != nil {
+		resp.Diagnostics.AddError("Create lock error", fmt.Sprintf("Unable to create the locks table, got error: %s", err))
+		return
+	}
+
+	deadline := time.Now().Add(wait)
+	var acquiredAt time.Time
+	var expiresAt sql.NullTime
+	for {
+		// Takes over an expired lock, returns no row while someone else holds it
+		err = client.QueryRowContext(ctx,
+			"INSERT INTO "+locksTable+" (name, holder, acquired_at, expires_at) VALUES ($1, $2, now(), now() + $3::INT * INTERVAL '1 second') "+
+				"ON CONFLICT (name) DO UPDATE SET holder = excluded.holder, acquired_at = excluded.acquired_at, expires_at = excluded.expires_at "+
+				"WHERE "+locksTable+".expires_at < now() RETURNING acquired_at, expires_at",
+			data.Name.ValueString(), data.Holder.ValueString(), ttlSeconds,
+		).Scan(&acquiredAt, &expiresAt)
+
+		if err != sql.ErrNoRows || time.Now().After(deadline) {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			resp.Diagnostics.AddError("Create lock error", fmt.Sprintf("Cancelled while waiting for lock %s", data.Name.ValueString()))
+			return
+		case <-time.After(lockPollInterval):
+		}
+	}
+	if err != nil {
+		var holder string
+		var since time.Time
+		if err == sql.ErrNoRows && client.QueryRowContext(ctx, "SELECT holder, acquired_at FROM "+locksTable+" WHERE name = $1", data.Name.ValueString()).Scan(&holder, &since) == nil {
+			resp.Diagnostics.AddError("Create lock error", fmt.Sprintf("Lock %s is held by %s since %s", data.Name.ValueString(), holder, since.Format(time.RFC3339)))
+			return
+		}
+		resp.Diagnostics.AddError("Create lock error", fmt.Sprintf("Unable to acquire lock, got error: %s", err))
+		return
+	}
+
+	tflog.Trace(ctx, "acquired a lock")
+
+	data.AcquiredAt = types.StringValue(acquiredAt.UTC().Format(time.RFC3339))
+	data.ExpiresAt = types.StringNull()
+	if expiresAt.Valid {
+		data.ExpiresAt = types.StringValue(expiresAt.Time.UTC().Format(time.RFC3339))
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Read drops the lock from state if it expired, or was released or taken over outside of terraform
+func (r *AdvisoryLockResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data *AdvisoryLockResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Not ConnectForRead, a stale follower read could miss a lock just acquired or taken over
+	client, err := r.db.Connect(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to connect to cockroach",
+			err.Error(),
+		)
+		return
+	}
+	defer client.Close()
+
+	var holder string
+	var expired bool
+	err = client.QueryRowContext(ctx, "SELECT holder, coalesce(expires_at < now(), false) FROM "+locksTable+" WHERE name = $1", data.Name.ValueString()).Scan(&holder, &expired)
+	if err == sql.ErrNoRows || isMissingMetadata(err) || (err == nil && (expired || holder != data.Holder.ValueString())) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Read lock error", fmt.Sprintf("Unable to read lock, got error: %s", err))
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Update only records a new wait_timeout, everything else replaces the lock
+func (r *AdvisoryLockResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data *AdvisoryLockResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Delete releases the lock, unless someone else took it over
+func (r *AdvisoryLockResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data *AdvisoryLockResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, err := r.db.Connect(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to connect to cockroach",
+			err.Error(),
+		)
+		return
+	}
+	defer client.Close()
+
+	_, err = client.ExecContext(ctx, "DELETE FROM "+locksTable+" WHERE name = $1 AND holder = $2", data.Name.ValueString(), data.Holder.ValueString())
+	if err != nil && !isMissingMetadata(err) {
+		resp.Diagnostics.AddError("Delete lock error", fmt.Sprintf("Unable to release lock, got error: %s", err))
+		return
+	}
+
+	tflog.Trace(ctx, "released a lock")
+}
+
+// ensureLocksTable lazily creates the metadata database and locks table
+func ensureLocksTable(ctx context.Context, client Executor) error {
+	_, err := client.ExecContext(ctx, "CREATE DATABASE IF NOT EXISTS "+metadataDatabase)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+locksTable+` (
+		name STRING PRIMARY KEY,
+		holder STRING NOT NULL,
+		acquired_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		expires_at TIMESTAMPTZ
+	)`)
+	return err
+}
@@ -0,0 +1,95 @@
+package provider
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// advisoryLockValue is the lock as a plan or state value, unknown values for computed attributes left nil
+func advisoryLockValue(objectType tftypes.Type, ttl any, acquiredAt any, expiresAt any) tftypes.Value {
+	return tftypes.NewValue(objectType, map[string]tftypes.Value{
+		"name":         tftypes.NewValue(tftypes.String, "migrations"),
+		"holder":       tftypes.NewValue(tftypes.String, "terraform"),
+		"wait_timeout": tftypes.NewValue(tftypes.String, nil),
+		"ttl":          tftypes.NewValue(tftypes.String, ttl),
+		"acquired_at":  tftypes.NewValue(tftypes.String, acquiredAt),
+		"expires_at":   tftypes.NewValue(tftypes.String, expiresAt),
+	})
+}
+
+func TestAdvisoryLockCreateWithTTL(t *testing.T) {
+	ctx := context.Background()
+	acquiredAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	r := &AdvisoryLockResource{db: newMockClient(t,
+		mockQuery{contains: "CREATE DATABASE IF NOT EXISTS"},
+		mockQuery{contains: "CREATE TABLE IF NOT EXISTS " + locksTable},
+		mockQuery{
+			contains: "WHERE " + locksTable + ".expires_at < now()",
+			args:     []driver.Value{"migrations", "terraform", int64(3600)},
+			columns:  []string{"acquired_at", "expires_at"},
+			rows:     [][]driver.Value{{acquiredAt, acquiredAt.Add(time.Hour)}},
+		},
+	)}
+
+	var schemaResp resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+	objectType := schemaResp.Schema.Type().TerraformType(ctx)
+
+	req := resource.CreateRequest{Plan: tfsdk.Plan{Schema: schemaResp.Schema, Raw: advisoryLockValue(objectType, "1h", tftypes.UnknownValue, tftypes.UnknownValue)}}
+	resp := resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: tftypes.NewValue(objectType, nil)}}
+
+	r.Create(ctx, req, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatal(resp.Diagnostics)
+	}
+
+	var data AdvisoryLockResourceModel
+	resp.Diagnostics.Append(resp.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		t.Fatal(resp.Diagnostics)
+	}
+	if data.ExpiresAt.ValueString() != "2024-05-01T13:00:00Z" {
+		t.Errorf("unexpected expires_at %s", data.ExpiresAt.ValueString())
+	}
+}
+
+func TestAdvisoryLockReadRemovesExpired(t *testing.T) {
+	ctx := context.Background()
+
+	for name, tc := range map[string]struct {
+		row     []driver.Value
+		removed bool
+	}{
+		"held":       {[]driver.Value{"terraform", false}, false},
+		"expired":    {[]driver.Value{"terraform", true}, true},
+		"taken over": {[]driver.Value{"ci-1234", false}, true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := &AdvisoryLockResource{db: newMockClient(t, mockQuery{
+				contains: "FROM " + locksTable,
+				columns:  []string{"holder", "expired"},
+				rows:     [][]driver.Value{tc.row},
+			})}
+
+			var schemaResp resource.SchemaResponse
+			r.Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+			objectType := schemaResp.Schema.Type().TerraformType(ctx)
+			state := tfsdk.State{Schema: schemaResp.Schema, Raw: advisoryLockValue(objectType, "1h", "2024-05-01T12:00:00Z", "2024-05-01T13:00:00Z")}
+
+			resp := resource.ReadResponse{State: state}
+			r.Read(ctx, resource.ReadRequest{State: state}, &resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatal(resp.Diagnostics)
+			}
+			if removed := resp.State.Raw.IsNull(); removed != tc.removed {
+				t.Errorf("expected removed %t, got %t", tc.removed, removed)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/lib/pq"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &ChangefeedsDataSource{}
+
+func NewChangefeedsDataSource() datasource.DataSource {
+	return &ChangefeedsDataSource{}
+}
+
+// ChangefeedsDataSource lists the changefeed jobs of the cluster.
+type ChangefeedsDataSource struct {
+	db Database
+}
+
+// ChangefeedsDataSourceModel describes the data source data model.
+type ChangefeedsDataSourceModel struct {
+	Status      types.String      `tfsdk:"status"`
+	Changefeeds []changefeedModel `tfsdk:"changefeeds"`
+}
+
+// changefeedModel is a single changefeed job
+type changefeedModel struct {
+	JobID              types.Int64  `tfsdk:"job_id"`
+	Status             types.String `tfsdk:"status"`
+	Description        types.String `tfsdk:"description"`
+	SinkURI            types.String `tfsdk:"sink_uri"`
+	Targets            types.List   `tfsdk:"targets"`
+	HighWaterTimestamp types.String `tfsdk:"high_water_timestamp"`
+	Error              types.String `tfsdk:"error"`
+}
+
+// Metadata appends the data source name to the provider name
+func (d *ChangefeedsDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_changefeeds"
+}
+
+// Schema is the shape of the data source - what you need to supply and what you get back
+func (d *ChangefeedsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Changefeed jobs of the cluster with their targets and sinks, e.g. to find feeds nobody manages",
+		Attributes: map[string]schema.Attribute{
+			"status": schema.StringAttribute{
+				MarkdownDescription: "Only list changefeeds with this status, e.g. `running`",
+				Optional:            true,
+			},
+			"changefeeds": schema.ListNestedAttribute{
+				MarkdownDescription: "Changefeeds ordered by job id",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"job_id": schema.Int64Attribute{
+							MarkdownDescription: "Id of the changefeed job",
+							Computed:            true,
+						},
+						"status": schema.StringAttribute{
+							MarkdownDescription: "Status of the job, e.g. `running`, `paused` or `failed`",
+							Computed:            true,
+						},
+						"description": schema.StringAttribute{
+							MarkdownDescription: "Statement which created the changefeed",
+							Computed:            true,
+						},
+						"sink_uri": schema.StringAttribute{
+							MarkdownDescription: "Sink of the changefeed with passwords and tokens redacted, empty for sinkless changefeeds",
+							Computed:            true,
+						},
+						"targets": schema.ListAttribute{
+							ElementType:         types.StringType,
+							MarkdownDescription: "Fully qualified names of the watched tables",
+							Computed:            true,
+						},
+						"high_water_timestamp": schema.StringAttribute{
+							MarkdownDescription: "Timestamp up to which all changes have been emitted, null before the first checkpoint",
+							Computed:            true,
+						},
+						"error": schema.StringAttribute{
+							MarkdownDescription: "Error of a failed changefeed",
+							Computed:            true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Configure adds the provider configured client to the data source
+func (d *ChangefeedsDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(Database)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *CockroachClient, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.db = client
+}
+
+// Read lists the changefeeds from SHOW CHANGEFEED JOBS
+func (d *ChangefeedsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data ChangefeedsDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, err := d.db.Connect(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to connect to cockroach",
+			err.Error(),
+		)
+		return
+	}
+	defer client.Close()
+
+	// An empty status matches every changefeed
+	rows, err := client.QueryContext(ctx, `SELECT job_id, status, description, sink_uri, full_table_names, high_water_timestamp::STRING, error
+		FROM [SHOW CHANGEFEED JOBS] WHERE $1 = '' OR status = $1 ORDER BY job_id`, data.Status.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Read changefeeds error", fmt.Sprintf("Unable to list changefeeds, got error: %s", err))
+		return
+	}
+	defer rows.Close()
+
+	data.Changefeeds = []changefeedModel{}
+	for rows.Next() {
+		var jobID int64
+		var status, description string
+		var sinkURI, highWater, jobError sql.NullString
+		var targets []string
+		if err := rows.Scan(&jobID, &status, &description, &sinkURI, pq.Array(&targets), &highWater, &jobError); err != nil {
+			resp.Diagnostics.AddError("Read changefeeds error", fmt.Sprintf("Unable to scan changefeed, got error: %s", err))
+			return
+		}
+
+		list, diags := types.ListValueFrom(ctx, types.StringType, targets)
+		resp.Diagnostics.Append(diags...)
+
+		changefeed := changefeedModel{
+			JobID:              types.Int64Value(jobID),
+			Status:             types.StringValue(status),
+			Description:        types.StringValue(scrubSecrets(description)),
+			SinkURI:            types.StringValue(scrubSecrets(sinkURI.String)),
+			Targets:            list,
+			HighWaterTimestamp: types.StringNull(),
+			Error:              types.StringValue(jobError.String),
+		}
+		if highWater.Valid {
+			changefeed.HighWaterTimestamp = types.StringValue(highWater.String)
+		}
+		data.Changefeeds = append(data.Changefeeds, changefeed)
+	}
+	if err := rows.Err(); err != nil {
+		resp.Diagnostics.AddError("Read changefeeds error", fmt.Sprintf("Unable to list changefeeds, got error: %s", err))
+		return
+	}
+
+	tflog.Trace(ctx, "read changefeeds")
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
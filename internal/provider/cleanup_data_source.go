@@ -0,0 +1,148 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &CleanupDataSource{}
+
+func NewCleanupDataSource() datasource.DataSource {
+	return &CleanupDataSource{}
+}
+
+// CleanupDataSource finds temporary databases and users past their expiry. Data sources are read during plan, so
+// dropping them is left to the cleanup run resource.
+type CleanupDataSource struct {
+	db Database
+}
+
+// CleanupDataSourceModel describes the data source data model.
+type CleanupDataSourceModel struct {
+	Expired []expiredObjectModel `tfsdk:"expired"`
+}
+
+// expiredObjectModel is a temporary object past its expiry
+type expiredObjectModel struct {
+	ObjectType types.String `tfsdk:"object_type"`
+	ObjectName types.String `tfsdk:"object_name"`
+	ExpiresAt  types.String `tfsdk:"expires_at"`
+}
+
+// Metadata appends the data source name to the provider name
+func (d *CleanupDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_cleanup"
+}
+
+// Schema is the shape of the data source - what you can filter on and what you get back
+func (d *CleanupDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Temporary databases and users past their expiry, e.g. left behind by cancelled PR pipelines. Only lists them, `cockroachgke_cleanup_run` drops them",
+		Attributes: map[string]schema.Attribute{
+			"expired": schema.ListNestedAttribute{
+				MarkdownDescription: "Expired temporary objects",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"object_type": schema.StringAttribute{
+							MarkdownDescription: "`database` or `user`",
+							Computed:            true,
+						},
+						"object_name": schema.StringAttribute{
+							MarkdownDescription: "Name of the object including its temporary suffix",
+							Computed:            true,
+						},
+						"expires_at": schema.StringAttribute{
+							MarkdownDescription: "When the object expired",
+							Computed:            true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Configure adds the provider configured client to the data source
+func (d *CleanupDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(Database)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *CockroachClient, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.db = client
+}
+
+// Read lists the expired objects
+func (d *CleanupDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data CleanupDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, err := d.db.ConnectForRead(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to connect to cockroach",
+			err.Error(),
+		)
+		return
+	}
+	defer client.Close()
+
+	expired, err := expiredObjects(ctx, client)
+	if err != nil {
+		resp.Diagnostics.AddError("Read cleanup error", fmt.Sprintf("Unable to query expired objects, got error: %s", err))
+		return
+	}
+	data.Expired = expired
+
+	tflog.Trace(ctx, "read expired objects")
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// expiredObjects lists the temporary objects past their expiry
+func expiredObjects(ctx context.Context, client Executor) ([]expiredObjectModel, error) {
+	expired := []expiredObjectModel{}
+
+	q := "SELECT object_type, object_name, value FROM " + labelsTable + " WHERE key = $1 AND value::TIMESTAMPTZ < now() ORDER BY object_type, object_name"
+	rows, err := client.QueryContext(ctx, q, labelKeyExpiresAt)
+	if isMissingMetadata(err) {
+		// Nothing temporary was ever created
+		return expired, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var objectType, objectName, expiresAt string
+		if err := rows.Scan(&objectType, &objectName, &expiresAt); err != nil {
+			return nil, err
+		}
+		expired = append(expired, expiredObjectModel{
+			ObjectType: types.StringValue(objectType),
+			ObjectName: types.StringValue(objectName),
+			ExpiresAt:  types.StringValue(expiresAt),
+		})
+	}
+	return expired, rows.Err()
+}
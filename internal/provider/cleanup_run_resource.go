@@ -0,0 +1,187 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/lib/pq"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &CleanupRunResource{}
+
+func NewCleanupRunResource() resource.Resource {
+	return &CleanupRunResource{}
+}
+
+// CleanupRunResource drops expired temporary databases and users when it is created, so the drops happen on apply
+// rather than while planning
+type CleanupRunResource struct {
+	db Database
+}
+
+// CleanupRunResourceModel describes the resource data model.
+type CleanupRunResourceModel struct {
+	Triggers types.Map    `tfsdk:"triggers"`
+	Id       types.String `tfsdk:"id"`
+	Dropped  types.List   `tfsdk:"dropped"`
+}
+
+// droppedObjectType is an element of dropped, the same shape as expiredObjectModel
+var droppedObjectType = types.ObjectType{AttrTypes: map[string]attr.Type{
+	"object_type": types.StringType,
+	"object_name": types.StringType,
+	"expires_at":  types.StringType,
+}}
+
+// Metadata appends the resource name to the provider name
+func (r *CleanupRunResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_cleanup_run"
+}
+
+// Schema is the shape of the resource - what you need to supply
+func (r *CleanupRunResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Drops the temporary databases and users past their expiry when created. Change `triggers` to run it again, e.g. with a timestamp from a scheduled pipeline. Databases are dropped with CASCADE, destroying the resource drops nothing",
+		Attributes: map[string]schema.Attribute{
+			"triggers": schema.MapAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: "Arbitrary values, any change replaces the resource and runs the cleanup again",
+				Optional:            true,
+				PlanModifiers:       []planmodifier.Map{mapplanmodifier.RequiresReplace()},
+			},
+			"id": schema.StringAttribute{
+				MarkdownDescription: "When the cleanup ran",
+				Computed:            true,
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"dropped": schema.ListNestedAttribute{
+				MarkdownDescription: "Expired objects dropped by the run. Objects which failed to drop are reported as warnings and left for the next run",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"object_type": schema.StringAttribute{
+							MarkdownDescription: "`database` or `user`",
+							Computed:            true,
+						},
+						"object_name": schema.StringAttribute{
+							MarkdownDescription: "Name of the object including its temporary suffix",
+							Computed:            true,
+						},
+						"expires_at": schema.StringAttribute{
+							MarkdownDescription: "When the object expired",
+							Computed:            true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Configure adds the provider configured client to the resource
+func (r *CleanupRunResource) Configure(_ context.Context, req resource.ConfigureRequest, _ *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	r.db = req.ProviderData.(Database)
+}
+
+// Create drops the expired objects
+func (r *CleanupRunResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data *CleanupRunResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, err := r.db.Connect(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to connect to cockroach",
+			err.Error(),
+		)
+		return
+	}
+	defer client.Close()
+
+	expired, err := expiredObjects(ctx, client)
+	if err != nil {
+		resp.Diagnostics.AddError("Create cleanup run error", fmt.Sprintf("Unable to query expired objects, got error: %s", err))
+		return
+	}
+
+	dropped := []expiredObjectModel{}
+	for _, object := range expired {
+		objectType, name := object.ObjectType.ValueString(), object.ObjectName.ValueString()
+		if err := dropTemporary(ctx, client, objectType, name); err != nil {
+			// Keep going, one stuck object shouldn't block cleaning up the rest
+			resp.Diagnostics.AddWarning("Cleanup error", fmt.Sprintf("Unable to drop %s %s, got error: %s", objectType, name, err))
+			continue
+		}
+		resp.Diagnostics.Append(deleteLabels(ctx, client, objectType, name)...)
+		dropped = append(dropped, object)
+		tflog.Trace(ctx, "dropped an expired "+objectType)
+	}
+
+	list, diags := types.ListValueFrom(ctx, droppedObjectType, dropped)
+	resp.Diagnostics.Append(diags...)
+	data.Dropped = list
+
+	data.Id = types.StringValue(time.Now().UTC().Format(time.RFC3339))
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Read keeps the run as it is, there is nothing left in the cluster to compare against
+func (r *CleanupRunResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data *CleanupRunResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Update is never called with a change, every attribute replaces the run
+func (r *CleanupRunResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data *CleanupRunResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Delete only forgets the run, dropped objects stay dropped
+func (r *CleanupRunResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	tflog.Trace(ctx, "forgot a cleanup run")
+}
+
+// dropTemporary drops an expired database or user, objects already gone count as dropped
+func dropTemporary(ctx context.Context, client Executor, objectType string, name string) error {
+	var err error
+	switch objectType {
+	case labelObjectDatabase:
+		_, err = client.ExecContext(ctx, fmt.Sprintf("DROP DATABASE IF EXISTS %s CASCADE", pq.QuoteIdentifier(name)))
+	case labelObjectUser:
+		_, err = client.ExecContext(ctx, fmt.Sprintf("DROP USER IF EXISTS %s", pq.QuoteIdentifier(name)))
+	default:
+		err = fmt.Errorf("unknown object type %s", objectType)
+	}
+	return err
+}
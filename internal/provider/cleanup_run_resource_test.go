@@ -0,0 +1,67 @@
+package provider
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestCleanupRunCreate(t *testing.T) {
+	ctx := context.Background()
+
+	r := &CleanupRunResource{db: newMockClient(t,
+		mockQuery{
+			contains: "FROM " + labelsTable,
+			columns:  []string{"object_type", "object_name", "value"},
+			rows: [][]driver.Value{
+				{labelObjectDatabase, "app_run_1234_abcdef", "2024-05-01T12:00:00Z"},
+				{labelObjectUser, "app_run_1234_abcdef", "2024-05-01T12:00:00Z"},
+			},
+		},
+		mockQuery{contains: `DROP DATABASE IF EXISTS "app_run_1234_abcdef" CASCADE`},
+		mockQuery{contains: "DELETE FROM " + labelsTable},
+		mockQuery{contains: `DROP USER IF EXISTS "app_run_1234_abcdef"`, err: errors.New("role owns objects")},
+	)}
+
+	var schemaResp resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+	objectType := schemaResp.Schema.Type().TerraformType(ctx)
+	droppedType := objectType.(tftypes.Object).AttributeTypes["dropped"]
+
+	req := resource.CreateRequest{Plan: tfsdk.Plan{
+		Schema: schemaResp.Schema,
+		Raw: tftypes.NewValue(objectType, map[string]tftypes.Value{
+			"triggers": tftypes.NewValue(tftypes.Map{ElementType: tftypes.String}, nil),
+			"id":       tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+			"dropped":  tftypes.NewValue(droppedType, tftypes.UnknownValue),
+		}),
+	}}
+	resp := resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: tftypes.NewValue(objectType, nil)}}
+
+	r.Create(ctx, req, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatal(resp.Diagnostics)
+	}
+	if resp.Diagnostics.WarningsCount() != 1 {
+		t.Errorf("expected a warning for the user which failed to drop, got %v", resp.Diagnostics)
+	}
+
+	var data CleanupRunResourceModel
+	resp.Diagnostics.Append(resp.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		t.Fatal(resp.Diagnostics)
+	}
+	var dropped []expiredObjectModel
+	resp.Diagnostics.Append(data.Dropped.ElementsAs(ctx, &dropped, false)...)
+	if len(dropped) != 1 || dropped[0].ObjectType.ValueString() != labelObjectDatabase {
+		t.Errorf("expected only the database to be dropped, got %v", dropped)
+	}
+	if data.Id.ValueString() == "" {
+		t.Error("expected the run time as id")
+	}
+}
@@ -0,0 +1,160 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &ClusterSettingsDataSource{}
+
+func NewClusterSettingsDataSource() datasource.DataSource {
+	return &ClusterSettingsDataSource{}
+}
+
+// ClusterSettingsDataSource reads the current cluster settings.
+type ClusterSettingsDataSource struct {
+	db Database
+}
+
+// ClusterSettingsDataSourceModel describes the data source data model.
+type ClusterSettingsDataSourceModel struct {
+	Prefix   types.String          `tfsdk:"prefix"`
+	Values   map[string]string     `tfsdk:"values"`
+	Settings []clusterSettingModel `tfsdk:"settings"`
+}
+
+// clusterSettingModel is a single row of SHOW ALL CLUSTER SETTINGS
+type clusterSettingModel struct {
+	Name        types.String `tfsdk:"name"`
+	Value       types.String `tfsdk:"value"`
+	Type        types.String `tfsdk:"type"`
+	Description types.String `tfsdk:"description"`
+}
+
+// Metadata appends the data source name to the provider name
+func (d *ClusterSettingsDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_cluster_settings"
+}
+
+// Schema is the shape of the data source - what you can filter on and what you get back
+func (d *ClusterSettingsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Current cluster settings, e.g. to assert prerequisites with preconditions or feed compliance dashboards",
+		Attributes: map[string]schema.Attribute{
+			"prefix": schema.StringAttribute{
+				MarkdownDescription: "Only return settings whose name starts with this prefix, e.g. `kv.rangefeed.`",
+				Optional:            true,
+			},
+			"values": schema.MapAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: "Setting name to current value",
+				Computed:            true,
+			},
+			"settings": schema.ListNestedAttribute{
+				MarkdownDescription: "Matching settings with their type and description",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
+							MarkdownDescription: "Name of the setting",
+							Computed:            true,
+						},
+						"value": schema.StringAttribute{
+							MarkdownDescription: "Current value",
+							Computed:            true,
+						},
+						"type": schema.StringAttribute{
+							MarkdownDescription: "Type code of the setting, e.g. `b` for bool or `d` for duration",
+							Computed:            true,
+						},
+						"description": schema.StringAttribute{
+							MarkdownDescription: "What the setting does",
+							Computed:            true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Configure adds the provider configured client to the data source
+func (d *ClusterSettingsDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(Database)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *CockroachClient, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.db = client
+}
+
+// Read runs SHOW ALL CLUSTER SETTINGS and filters by prefix
+func (d *ClusterSettingsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data ClusterSettingsDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, err := d.db.Connect(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to connect to cockroach",
+			err.Error(),
+		)
+		return
+	}
+	defer client.Close()
+
+	rows, err := client.QueryContext(ctx, "SELECT variable, value, setting_type, description FROM [SHOW ALL CLUSTER SETTINGS] ORDER BY variable")
+	if err != nil {
+		resp.Diagnostics.AddError("Read cluster settings error", fmt.Sprintf("Unable to query cluster settings, got error: %s", err))
+		return
+	}
+	defer rows.Close()
+
+	data.Values = map[string]string{}
+	data.Settings = []clusterSettingModel{}
+	for rows.Next() {
+		var name, value, settingType, description string
+		if err := rows.Scan(&name, &value, &settingType, &description); err != nil {
+			resp.Diagnostics.AddError("Read cluster settings error", fmt.Sprintf("Unable to scan cluster setting, got error: %s", err))
+			return
+		}
+		if !strings.HasPrefix(name, data.Prefix.ValueString()) {
+			continue
+		}
+
+		data.Values[name] = value
+		data.Settings = append(data.Settings, clusterSettingModel{
+			Name:        types.StringValue(name),
+			Value:       types.StringValue(value),
+			Type:        types.StringValue(settingType),
+			Description: types.StringValue(description),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		resp.Diagnostics.AddError("Read cluster settings error", fmt.Sprintf("Unable to query cluster settings, got error: %s", err))
+		return
+	}
+
+	tflog.Trace(ctx, "read cluster settings")
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
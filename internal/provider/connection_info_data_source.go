@@ -0,0 +1,168 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/pem"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &ConnectionInfoDataSource{}
+
+func NewConnectionInfoDataSource() datasource.DataSource {
+	return &ConnectionInfoDataSource{}
+}
+
+// ConnectionInfoDataSource echoes what the provider connects to, without any secrets.
+type ConnectionInfoDataSource struct {
+	db *CockroachClient
+}
+
+// ConnectionInfoDataSourceModel describes the data source data model.
+type ConnectionInfoDataSourceModel struct {
+	Host          types.String `tfsdk:"host"`
+	Port          types.Int64  `tfsdk:"port"`
+	SSLMode       types.String `tfsdk:"sslmode"`
+	CAFingerprint types.String `tfsdk:"ca_fingerprint"`
+	TLSChecksum   types.String `tfsdk:"tls_checksum"`
+	DSN           types.String `tfsdk:"dsn"`
+}
+
+// redactedParams are connection string parameters that hold secrets
+var redactedParams = []string{"password", "sslpassword"}
+
+// Metadata appends the data source name to the provider name
+func (d *ConnectionInfoDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_connection_info"
+}
+
+// Schema is the shape of the data source - everything is computed from the provider configuration
+func (d *ConnectionInfoDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Resolved connection settings of the provider, without secrets",
+		Attributes: map[string]schema.Attribute{
+			"host": schema.StringAttribute{
+				MarkdownDescription: "Host the provider connects to",
+				Computed:            true,
+			},
+			"port": schema.Int64Attribute{
+				MarkdownDescription: "Port the provider connects to",
+				Computed:            true,
+			},
+			"sslmode": schema.StringAttribute{
+				MarkdownDescription: "SSL mode of the connection",
+				Computed:            true,
+			},
+			"ca_fingerprint": schema.StringAttribute{
+				MarkdownDescription: "SHA-256 fingerprint of the certificate authority, formatted like `openssl x509 -fingerprint -sha256`",
+				Computed:            true,
+			},
+			"tls_checksum": schema.StringAttribute{
+				MarkdownDescription: "SHA-256 of the certificate files as currently on disk. They're read again for every connection, so a change here shows a rotation was picked up without reloading the provider",
+				Computed:            true,
+			},
+			"dsn": schema.StringAttribute{
+				MarkdownDescription: "Connection string with the password redacted and parameters sorted, safe to log and compare between workspaces",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+// Configure adds the provider configured client to the data source
+func (d *ConnectionInfoDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*CockroachClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *CockroachClient, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.db = client
+}
+
+// Read fills the data source from the provider configuration, no query is sent to cockroach
+func (d *ConnectionInfoDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data ConnectionInfoDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	fingerprint, err := certificateFingerprint(d.db.CertPath)
+	if err != nil {
+		resp.Diagnostics.AddError("Read certificate error", fmt.Sprintf("Unable to fingerprint certificate authority %s, got error: %s", d.db.CertPath, err))
+		return
+	}
+
+	checksum, err := d.db.TLS.current()
+	if err != nil {
+		resp.Diagnostics.AddError("Read certificate error", fmt.Sprintf("Unable to checksum the certificate files, got error: %s", err))
+		return
+	}
+
+	data.Host = types.StringValue(d.db.Host)
+	data.Port = types.Int64Value(d.db.Port)
+	data.SSLMode = types.StringValue(d.db.SSLMode)
+	data.CAFingerprint = types.StringValue(fingerprint)
+	data.TLSChecksum = types.StringValue(checksum)
+	data.DSN = types.StringValue(redactDSN(*d.db.ConnectionString))
+
+	tflog.Trace(ctx, "read connection info")
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// redactDSN masks the password and secret parameters of a connection string and sorts its parameters
+func redactDSN(dsn string) string {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		// Never echo something we couldn't parse, it may contain the password in plain text
+		return "unparseable connection string"
+	}
+
+	params := u.Query()
+	for _, param := range redactedParams {
+		if params.Has(param) {
+			params.Set(param, "xxxxx")
+		}
+	}
+	u.RawQuery = params.Encode()
+	return u.Redacted()
+}
+
+// certificateFingerprint returns the SHA-256 fingerprint of the first certificate in a PEM file
+func certificateFingerprint(certPath string) (string, error) {
+	contents, err := os.ReadFile(certPath)
+	if err != nil {
+		return "", err
+	}
+
+	block, _ := pem.Decode(contents)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return "", fmt.Errorf("no PEM encoded certificate found")
+	}
+
+	sum := sha256.Sum256(block.Bytes)
+	hex := make([]string, len(sum))
+	for i, b := range sum {
+		hex[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(hex, ":"), nil
+}
@@ -0,0 +1,66 @@
+package provider
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/lib/pq"
+)
+
+// checkConnectivity runs a trivial query at configure time, so a misconfigured provider fails once with a clear
+// diagnostic instead of on every resource
+func checkConnectivity(ctx context.Context, client *CockroachClient) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	conn, err := client.Connect(ctx)
+	if err == nil {
+		defer conn.Close()
+		var version string
+		err = conn.QueryRowContext(ctx, "SELECT version()").Scan(&version)
+	}
+	if err != nil {
+		attribute, summary, detail := classifyConnectError(err)
+		diags.AddAttributeError(
+			path.Root(attribute),
+			summary,
+			fmt.Sprintf("%s Set skip_connectivity_check if the cluster is created in the same apply. Got error: %s", detail, err),
+		)
+	}
+	return diags
+}
+
+// classifyConnectError names the likely misconfiguration behind a failed connection and the attribute to fix
+func classifyConnectError(err error) (attribute string, summary string, detail string) {
+	var unknownAuthority x509.UnknownAuthorityError
+	var invalidCert x509.CertificateInvalidError
+	var hostname x509.HostnameError
+	var pathErr *fs.PathError
+	var pqErr *pq.Error
+	var dnsErr *net.DNSError
+	var netErr net.Error
+
+	switch {
+	case errors.As(err, &unknownAuthority):
+		return "certpath", "Untrusted Cockroach server certificate", "The server certificate is not signed by the CA at certpath, check that it is the CA of this cluster."
+	case errors.As(err, &invalidCert) && invalidCert.Reason == x509.Expired:
+		return "certpath", "Expired Cockroach certificate", "A certificate in the chain has expired or is not valid yet, rotate it or check the clock of this machine."
+	case errors.As(err, &hostname):
+		return "host", "Cockroach certificate does not match the host", "The server certificate is not valid for host, connect with a name listed in the certificate."
+	case errors.As(err, &pathErr):
+		return "certpath", "Unreadable Cockroach certificate", "A certificate file could not be read, check certpath."
+	// invalid_password or invalid_authorization_specification
+	case errors.As(err, &pqErr) && (pqErr.Code == "28P01" || pqErr.Code == "28000"):
+		return "password", "Cockroach authentication failed", "The server rejected the username and password."
+	case errors.As(err, &dnsErr):
+		return "host", "Unknown Cockroach host", "The host name does not resolve."
+	case errors.As(err, &netErr):
+		return "host", "Unreachable Cockroach host", "The host did not answer, check the address, firewall rules and proxy settings."
+	}
+	return "host", "Unable to connect to Cockroach", "The provider could not run a query against the cluster."
+}
@@ -0,0 +1,34 @@
+package provider
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestClassifyConnectError(t *testing.T) {
+	for _, tc := range []struct {
+		err       error
+		attribute string
+		summary   string
+	}{
+		{x509.UnknownAuthorityError{}, "certpath", "Untrusted Cockroach server certificate"},
+		{fmt.Errorf("tls: %w", x509.CertificateInvalidError{Reason: x509.Expired}), "certpath", "Expired Cockroach certificate"},
+		{x509.HostnameError{Certificate: &x509.Certificate{}, Host: "db"}, "host", "Cockroach certificate does not match the host"},
+		{&fs.PathError{Op: "open", Path: "/missing/ca.crt", Err: fs.ErrNotExist}, "certpath", "Unreadable Cockroach certificate"},
+		{&pq.Error{Code: "28P01"}, "password", "Cockroach authentication failed"},
+		{&net.DNSError{Name: "db.invalid", IsNotFound: true}, "host", "Unknown Cockroach host"},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, "host", "Unreachable Cockroach host"},
+		{errors.New("something else"), "host", "Unable to connect to Cockroach"},
+	} {
+		attribute, summary, _ := classifyConnectError(tc.err)
+		if attribute != tc.attribute || summary != tc.summary {
+			t.Errorf("%v: expected %s %q, got %s %q", tc.err, tc.attribute, tc.summary, attribute, summary)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package provider
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/lib/pq"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ ephemeral.EphemeralResource = &CredentialsEphemeralResource{}
+var _ ephemeral.EphemeralResourceWithConfigure = &CredentialsEphemeralResource{}
+var _ ephemeral.EphemeralResourceWithClose = &CredentialsEphemeralResource{}
+
+const (
+	defaultCredentialsPrefix = "tf_ephemeral_"
+	defaultCredentialsTTL    = "1h"
+
+	// Private data key holding the minted username so Close knows what to drop
+	privateKeyUsername = "username"
+)
+
+func NewCredentialsEphemeralResource() ephemeral.EphemeralResource {
+	return &CredentialsEphemeralResource{}
+}
+
+// CredentialsEphemeralResource mints a short lived user which is dropped again once terraform is done with it.
+type CredentialsEphemeralResource struct {
+	db Database
+}
+
+// CredentialsEphemeralResourceModel describes the ephemeral resource data model.
+type CredentialsEphemeralResourceModel struct {
+	Prefix     types.String `tfsdk:"prefix"`
+	TTL        types.String `tfsdk:"ttl"`
+	Roles      types.List   `tfsdk:"roles"`
+	Username   types.String `tfsdk:"username"`
+	Password   types.String `tfsdk:"password"`
+	ValidUntil types.String `tfsdk:"valid_until"`
+}
+
+// Metadata appends the ephemeral resource name to the provider name
+func (e *CredentialsEphemeralResource) Metadata(ctx context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_credentials"
+}
+
+// Schema is the shape of the ephemeral resource - what you need to supply
+func (e *CredentialsEphemeralResource) Schema(ctx context.Context, req ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Short lived credentials for a randomly named user, dropped again when terraform finishes. Never stored in state.",
+		Attributes: map[string]schema.Attribute{
+			"prefix": schema.StringAttribute{
+				MarkdownDescription: "Prefix of the generated username, defaults to `" + defaultCredentialsPrefix + "`",
+				Optional:            true,
+			},
+			"ttl": schema.StringAttribute{
+				MarkdownDescription: "How long the credentials stay valid as a Go duration, defaults to `" + defaultCredentialsTTL + "`",
+				Optional:            true,
+			},
+			"roles": schema.ListAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: "Roles granted to the generated user, e.g. `admin` for migrations. Defaults to none, the user can then log in but only use what is granted to `public`",
+				Optional:            true,
+			},
+			"username": schema.StringAttribute{
+				MarkdownDescription: "Generated username",
+				Computed:            true,
+			},
+			"password": schema.StringAttribute{
+				MarkdownDescription: "Generated password",
+				Computed:            true,
+				Sensitive:           true,
+			},
+			"valid_until": schema.StringAttribute{
+				MarkdownDescription: "RFC3339 timestamp after which cockroach refuses the credentials",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+// Configure adds the provider configured client to the ephemeral resource
+func (e *CredentialsEphemeralResource) Configure(_ context.Context, req ephemeral.ConfigureRequest, _ *ephemeral.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	e.db = req.ProviderData.(Database)
+}
+
+// Open creates the user with a password that expires after the ttl
+func (e *CredentialsEphemeralResource) Open(ctx context.Context, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	var data CredentialsEphemeralResourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	prefix := defaultCredentialsPrefix
+	if !data.Prefix.IsNull() {
+		prefix = data.Prefix.ValueString()
+	}
+
+	ttlString := defaultCredentialsTTL
+	if !data.TTL.IsNull() {
+		ttlString = data.TTL.ValueString()
+	}
+	ttl, err := time.ParseDuration(ttlString)
+	if err != nil || ttl <= 0 {
+		resp.Diagnostics.AddError("Invalid ttl", fmt.Sprintf("Unable to use %q as ttl, expected a positive duration such as 30m", ttlString))
+		return
+	}
+
+	var roles []string
+	resp.Diagnostics.Append(data.Roles.ElementsAs(ctx, &roles, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	suffix, err := randomHex(4)
+	if err != nil {
+		resp.Diagnostics.AddError("Generate credentials error", fmt.Sprintf("Unable to generate username, got error: %s", err))
+		return
+	}
+	password, err := randomHex(16)
+	if err != nil {
+		resp.Diagnostics.AddError("Generate credentials error", fmt.Sprintf("Unable to generate password, got error: %s", err))
+		return
+	}
+	username := prefix + suffix
+	ctx = maskSecrets(ctx, password)
+	validUntil := time.Now().Add(ttl).UTC().Format(time.RFC3339)
+
+	client, err := e.db.Connect(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to connect to cockroach",
+			err.Error(),
+		)
+		return
+	}
+	defer client.Close()
+
+	query := fmt.Sprintf("CREATE USER %s WITH LOGIN PASSWORD %s VALID UNTIL %s", pq.QuoteIdentifier(username), pq.QuoteLiteral(password), pq.QuoteLiteral(validUntil))
+	_, err = client.ExecContext(ctx, query)
+	if err != nil {
+		resp.Diagnostics.AddError("Create credentials error", fmt.Sprintf("Unable to create user %s, got error: %s", username, scrubError(err, password)))
+		return
+	}
+
+	if len(roles) > 0 {
+		quoted := make([]string, len(roles))
+		for i, role := range roles {
+			quoted[i] = pq.QuoteIdentifier(role)
+		}
+		_, err = client.ExecContext(ctx, fmt.Sprintf("GRANT %s TO %s", strings.Join(quoted, ", "), pq.QuoteIdentifier(username)))
+		if err != nil {
+			resp.Diagnostics.AddError("Create credentials error", fmt.Sprintf("Unable to grant roles to %s, got error: %s", username, err))
+			// Close isn't called when Open fails, drop the user here so it doesn't outlive terraform
+			if _, err := client.ExecContext(ctx, fmt.Sprintf("DROP USER IF EXISTS %s", pq.QuoteIdentifier(username))); err != nil {
+				resp.Diagnostics.AddError("Delete credentials error", fmt.Sprintf("Unable to drop user %s, got error: %s", username, err))
+			}
+			return
+		}
+	}
+
+	// Hand the username to Close
+	value, _ := json.Marshal(username)
+	resp.Diagnostics.Append(resp.Private.SetKey(ctx, privateKeyUsername, value)...)
+
+	tflog.Trace(ctx, "created ephemeral credentials")
+
+	data.Username = types.StringValue(username)
+	data.Password = types.StringValue(password)
+	data.ValidUntil = types.StringValue(validUntil)
+
+	resp.Diagnostics.Append(resp.Result.Set(ctx, &data)...)
+}
+
+// Close drops the user created by Open
+func (e *CredentialsEphemeralResource) Close(ctx context.Context, req ephemeral.CloseRequest, resp *ephemeral.CloseResponse) {
+	value, diags := req.Private.GetKey(ctx, privateKeyUsername)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || len(value) == 0 {
+		return
+	}
+
+	var username string
+	if err := json.Unmarshal(value, &username); err != nil {
+		resp.Diagnostics.AddError("Invalid private state", fmt.Sprintf("Unable to decode username, got error: %s", err))
+		return
+	}
+
+	client, err := e.db.Connect(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to connect to cockroach",
+			err.Error(),
+		)
+		return
+	}
+	defer client.Close()
+
+	_, err = client.ExecContext(ctx, fmt.Sprintf("DROP USER IF EXISTS %s", pq.QuoteIdentifier(username)))
+	if err != nil {
+		resp.Diagnostics.AddError("Delete credentials error", fmt.Sprintf("Unable to drop user %s, got error: %s", username, err))
+		return
+	}
+
+	tflog.Trace(ctx, "dropped ephemeral credentials")
+}
+
+// randomHex returns n random bytes hex encoded
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,42 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestCredentialsOpenDropsUserWhenGrantFails(t *testing.T) {
+	ctx := context.Background()
+	e := &CredentialsEphemeralResource{db: newMockClient(t,
+		mockQuery{contains: `CREATE USER "migrate_`},
+		mockQuery{contains: `GRANT "admin" TO "migrate_`, err: errors.New("permission denied")},
+		mockQuery{contains: `DROP USER IF EXISTS "migrate_`},
+	)}
+
+	var schemaResp ephemeral.SchemaResponse
+	e.Schema(ctx, ephemeral.SchemaRequest{}, &schemaResp)
+	objectType := schemaResp.Schema.Type().TerraformType(ctx)
+
+	req := ephemeral.OpenRequest{Config: tfsdk.Config{
+		Schema: schemaResp.Schema,
+		Raw: tftypes.NewValue(objectType, map[string]tftypes.Value{
+			"prefix":      tftypes.NewValue(tftypes.String, "migrate_"),
+			"ttl":         tftypes.NewValue(tftypes.String, nil),
+			"roles":       tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{tftypes.NewValue(tftypes.String, "admin")}),
+			"username":    tftypes.NewValue(tftypes.String, nil),
+			"password":    tftypes.NewValue(tftypes.String, nil),
+			"valid_until": tftypes.NewValue(tftypes.String, nil),
+		}),
+	}}
+	resp := ephemeral.OpenResponse{Result: tfsdk.EphemeralResultData{Schema: schemaResp.Schema, Raw: tftypes.NewValue(objectType, nil)}}
+
+	e.Open(ctx, req, &resp)
+	if resp.Diagnostics.ErrorsCount() != 1 {
+		t.Fatalf("expected only the grant error, got %v", resp.Diagnostics)
+	}
+}
@@ -4,21 +4,33 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 
-	// "github.com/hashicorp/terraform-plugin-log/tflog"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+	"golang.org/x/exp/slices"
 )
 
 // Ensure provider defined types fully satisfy framework interfaces.
 var _ resource.Resource = &DatabaseResource{}
 var _ resource.ResourceWithImportState = &DatabaseResource{}
+var _ resource.ResourceWithIdentity = &DatabaseResource{}
 
 func NewDatabaseResource() resource.Resource {
 	return &DatabaseResource{}
@@ -26,18 +38,56 @@ func NewDatabaseResource() resource.Resource {
 
 // DatabaseResource defines the resource implementation. Contains the cockroach client connection string.
 type DatabaseResource struct {
-	db *CockroachClient
+	db Database
 }
 
 // DatabaseResourceModel describes the resource data model.
 type DatabaseResourceModel struct {
-	Name              types.String `tfsdk:"name"`
-	DisableProtection types.Bool   `tfsdk:"disable_protection"`
+	Name                  identifierValue `tfsdk:"name"`
+	DisableProtection     types.Bool      `tfsdk:"disable_protection"`
+	Labels                types.Map       `tfsdk:"labels"`
+	GCTTLSeconds          types.Int64     `tfsdk:"gc_ttl_seconds"`
+	PublicSchemaCreate    types.Bool      `tfsdk:"public_schema_create"`
+	PublicSchemaUsage     types.Set       `tfsdk:"public_schema_usage"`
+	DefaultSchemaOwner    types.String    `tfsdk:"default_schema_owner"`
+	AdditionalSchemas     types.Set       `tfsdk:"additional_schemas"`
+	AllowDestroy          types.Bool      `tfsdk:"allow_destroy"`
+	ForceDestroy          types.Bool      `tfsdk:"force_destroy"`
+	InitSQL               types.List      `tfsdk:"init_sql"`
+	Temporary             types.Bool      `tfsdk:"temporary"`
+	TemporaryTTL          types.String    `tfsdk:"temporary_ttl"`
+	FullName              types.String    `tfsdk:"full_name"`
+	ExpiresAt             types.String    `tfsdk:"expires_at"`
+	Schemas               types.List      `tfsdk:"schemas"`
+	TableCount            types.Int64     `tfsdk:"table_count"`
+	NumReplicas           types.Int64     `tfsdk:"num_replicas"`
+	NumVoters             types.Int64     `tfsdk:"num_voters"`
+	Constraints           types.String    `tfsdk:"constraints"`
+	VoterConstraints      types.String    `tfsdk:"voter_constraints"`
+	LeasePreferences      types.String    `tfsdk:"lease_preferences"`
+	Fingerprint           types.String    `tfsdk:"fingerprint"`
+	LastAppliedStatements types.List      `tfsdk:"last_applied_statements"`
+	Timeouts              timeouts.Value  `tfsdk:"timeouts"`
+}
+
+// sqlName is the name of the database in cockroach, which has a suffix for temporary databases
+func (m *DatabaseResourceModel) sqlName() identifierValue {
+	if m.FullName.IsNull() || m.FullName.IsUnknown() {
+		return m.Name
+	}
+	return exactIdentifierValue(m.FullName.ValueString())
 }
 
 // Metadata appends the resource name to the provider name
 func (r *DatabaseResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_database"
+	// Renames keep the database but change its identity
+	resp.ResourceBehavior.MutableIdentity = true
+}
+
+// IdentitySchema is the shape of the identity - what identifies the database across workspaces
+func (r *DatabaseResource) IdentitySchema(ctx context.Context, req resource.IdentitySchemaRequest, resp *resource.IdentitySchemaResponse) {
+	resp.IdentitySchema = databaseIdentitySchema
 }
 
 // Schema is the shape of the resource - what you need to supply
@@ -46,13 +96,120 @@ func (r *DatabaseResource) Schema(ctx context.Context, req resource.SchemaReques
 		MarkdownDescription: "Database resource",
 		Attributes: map[string]schema.Attribute{
 			"name": schema.StringAttribute{
-				MarkdownDescription: "Name of the database",
+				CustomType:          identifierType{},
+				MarkdownDescription: "Name of the database. Folded to lowercase like an unquoted SQL identifier, quote it to keep its case, e.g. `\"\\\"MyDB\\\"\"`",
 				Required:            true,
+				PlanModifiers:       []planmodifier.String{requiresReplaceIfTemporary()},
 			},
 			"disable_protection": schema.BoolAttribute{
 				MarkdownDescription: "Optional disable delete protection for tables",
 				Optional:            true,
 			},
+			"labels": schema.MapAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: "Key/value labels stored in the provider managed `cockroachgke_metadata.labels` table, e.g. for chargeback reports",
+				Optional:            true,
+			},
+			"gc_ttl_seconds": schema.Int64Attribute{
+				MarkdownDescription: "Zone config gc.ttlseconds for the whole database, i.e. how long old row versions are kept. Inherits the cluster default when unset",
+				Optional:            true,
+				Validators:          []validator.Int64{int64validator.AtLeast(0)},
+			},
+			"public_schema_create": schema.BoolAttribute{
+				MarkdownDescription: "Whether every role may create objects in the `public` schema, as cockroach allows by default. Set to false to revoke CREATE from the `public` role. Left as is when unset",
+				Optional:            true,
+			},
+			"public_schema_usage": schema.SetAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: "Exactly the roles granted USAGE on the `public` schema, USAGE is revoked from any other role, including the `public` role unless it's listed. Left as is when unset",
+				Optional:            true,
+			},
+			"default_schema_owner": schema.StringAttribute{
+				MarkdownDescription: "Role owning the `public` schema and the `additional_schemas`, ownership is transferred when it changes. Left as is when unset",
+				Optional:            true,
+			},
+			"additional_schemas": schema.SetAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: "Schemas created in the database besides `public`, owned by `default_schema_owner` when set. Removing a name leaves the schema and its tables in place",
+				Optional:            true,
+			},
+			"allow_destroy": schema.BoolAttribute{
+				MarkdownDescription: "Allow destroying this database while the provider has `deletion_protection` enabled",
+				Optional:            true,
+			},
+			"force_destroy": schema.BoolAttribute{
+				MarkdownDescription: "Drop the database even while changefeeds, backups or schema changes targeting it are still running or paused. By default the destroy fails with a list of those jobs",
+				Optional:            true,
+			},
+			"init_sql": schema.ListAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: "Statements run once, in a single transaction, right after the database is created. Later changes are not applied",
+				Optional:            true,
+			},
+			"temporary": schema.BoolAttribute{
+				MarkdownDescription: "Suffix the name with the CI run id and a random part and record an expiry, so `cockroachgke_cleanup_run` can drop the database if the pipeline never destroys it",
+				Optional:            true,
+				PlanModifiers:       []planmodifier.Bool{boolplanmodifier.RequiresReplace()},
+			},
+			"temporary_ttl": schema.StringAttribute{
+				MarkdownDescription: "How long a temporary database lives, e.g. `8h`. Defaults to `24h`, only evaluated on creation",
+				Optional:            true,
+			},
+			"full_name": schema.StringAttribute{
+				MarkdownDescription: "Name of a temporary database including its suffix, null otherwise",
+				Computed:            true,
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"expires_at": schema.StringAttribute{
+				MarkdownDescription: "RFC 3339 time after which a temporary database may be cleaned up, null otherwise",
+				Computed:            true,
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"schemas": schema.ListAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: "User defined schemas in the database, including `public`, as found on the last refresh",
+				Computed:            true,
+				PlanModifiers:       []planmodifier.List{listplanmodifier.UseStateForUnknown()},
+			},
+			"table_count": schema.Int64Attribute{
+				MarkdownDescription: "Number of tables in the database, as found on the last refresh",
+				Computed:            true,
+				PlanModifiers:       []planmodifier.Int64{int64planmodifier.UseStateForUnknown()},
+			},
+			"num_replicas": schema.Int64Attribute{
+				MarkdownDescription: "Zone config num_replicas of the database, including non-voting replicas which can serve follower reads",
+				Computed:            true,
+				PlanModifiers:       []planmodifier.Int64{int64planmodifier.UseStateForUnknown()},
+			},
+			"num_voters": schema.Int64Attribute{
+				MarkdownDescription: "Zone config num_voters of the database, null when every replica votes",
+				Computed:            true,
+				PlanModifiers:       []planmodifier.Int64{int64planmodifier.UseStateForUnknown()},
+			},
+			"constraints": schema.StringAttribute{
+				MarkdownDescription: "Zone config constraints placing the replicas, e.g. `{+region=us-east1: 1}`, null when unconstrained",
+				Computed:            true,
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"voter_constraints": schema.StringAttribute{
+				MarkdownDescription: "Zone config voter_constraints placing the voting replicas, null when unconstrained",
+				Computed:            true,
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"lease_preferences": schema.StringAttribute{
+				MarkdownDescription: "Zone config lease_preferences, e.g. `[[+region=us-east1]]`. Reads outside the preferred regions are fastest as follower reads. Null without preferences",
+				Computed:            true,
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"fingerprint": schema.StringAttribute{
+				MarkdownDescription: "Cluster id and descriptor id of the database, e.g. `9b1c2d3e-.../104`. Compare it before applying a promoted state to make sure the state belongs to the intended cluster",
+				Computed:            true,
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"last_applied_statements": lastAppliedStatementsAttribute(),
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{Create: true, Update: true}),
 		},
 	}
 }
@@ -63,7 +220,7 @@ func (r *DatabaseResource) Configure(_ context.Context, req resource.ConfigureRe
 		return
 	}
 
-	r.db = req.ProviderData.(*CockroachClient)
+	r.db = req.ProviderData.(Database)
 }
 
 // Create is for creating the database resource
@@ -75,7 +232,7 @@ func (r *DatabaseResource) Create(ctx context.Context, req resource.CreateReques
 		return
 	}
 
-	client, err := r.db.Connect()
+	client, err := r.db.Connect(ctx)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Failed to connect to cockroach",
@@ -85,8 +242,38 @@ func (r *DatabaseResource) Create(ctx context.Context, req resource.CreateReques
 	}
 	defer client.Close()
 
-	sql := fmt.Sprintf("CREATE DATABASE %s", data.Name.String())
-	_, err = client.Exec(sql)
+	timeout, diags := data.Timeouts.Create(ctx, defaultSchemaChangeTimeout)
+	resp.Diagnostics.Append(diags...)
+	mark, err := schemaChangeMark(ctx, client)
+	if err != nil {
+		resp.Diagnostics.AddError("Create db error", fmt.Sprintf("Unable to read the cluster time, got error: %s", err))
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.FullName = types.StringNull()
+	data.ExpiresAt = types.StringNull()
+	data.Schemas = types.ListNull(types.StringType)
+	data.TableCount = types.Int64Null()
+	data.LastAppliedStatements = types.ListNull(appliedStatementType)
+	if data.Temporary.ValueBool() {
+		expiresAt, err := temporaryExpiry(data.TemporaryTTL)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("temporary_ttl"), "Create db error", err.Error())
+			return
+		}
+		suffix, err := temporarySuffix()
+		if err != nil {
+			resp.Diagnostics.AddError("Create db error", fmt.Sprintf("Unable to generate a temporary suffix, got error: %s", err))
+			return
+		}
+		data.FullName = types.StringValue(data.Name.ValueString() + "_" + suffix)
+		data.ExpiresAt = types.StringValue(expiresAt.Format(time.RFC3339))
+	}
+
+	sql := fmt.Sprintf("CREATE DATABASE %s", data.sqlName().String())
+	_, err = client.ExecContext(ctx, sql)
 	if err != nil {
 		resp.Diagnostics.AddError("Create db error", fmt.Sprintf("Unable to create database, got error: %s", err))
 		return
@@ -94,6 +281,90 @@ func (r *DatabaseResource) Create(ctx context.Context, req resource.CreateReques
 
 	tflog.Trace(ctx, "created a database")
 
+	// From here on the database exists. Should a later step fail it stays in state, which Terraform marks tainted, so
+	// the retry replaces it, running init_sql again, instead of failing with "already exists"
+	defer func() {
+		if resp.Diagnostics.HasError() {
+			data.LastAppliedStatements, _ = client.appliedStatements(ctx)
+			data.nullUnknowns()
+			resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+		}
+	}()
+
+	if data.Temporary.ValueBool() {
+		resp.Diagnostics.Append(recordExpiry(ctx, client, labelObjectDatabase, data.FullName.ValueString(), data.ExpiresAt.ValueString())...)
+	}
+
+	if !data.InitSQL.IsNull() {
+		resp.Diagnostics.Append(runInitSQL(ctx, client, data.sqlName().ValueString(), data.InitSQL)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	dialect, err := client.Dialect(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Read db error", fmt.Sprintf("Unable to determine server version, got error: %s", err))
+		return
+	}
+	query, diags := dialect.DatabaseByName()
+	resp.Diagnostics.Append(diags...)
+
+	var id int64
+	var name string
+	err = client.QueryRowContext(ctx, query, data.sqlName().ValueString()).Scan(&id, &name)
+	if err != nil {
+		resp.Diagnostics.AddError("Read db error", fmt.Sprintf("Unable to read database descriptor id, got error: %s", err))
+		return
+	}
+	resp.Diagnostics.Append(setPrivateID(ctx, resp.Private, privateKeyDescriptorID, id)...)
+
+	if !data.Labels.IsNull() {
+		resp.Diagnostics.Append(writeLabels(ctx, client, labelObjectDatabase, data.sqlName().ValueString(), data.Labels)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if !data.GCTTLSeconds.IsNull() {
+		err = setDatabaseGCTTL(ctx, client, data.sqlName().ValueString(), data.GCTTLSeconds.ValueInt64())
+		if err != nil {
+			resp.Diagnostics.AddError("Create db error", fmt.Sprintf("Unable to configure gc ttl, got error: %s", err))
+			return
+		}
+	}
+
+	resp.Diagnostics.Append(applyPublicSchemaGrants(ctx, client, data.sqlName().ValueString(), data.PublicSchemaCreate, data.PublicSchemaUsage)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(applySchemaOwnership(ctx, client, data.sqlName().ValueString(), data.DefaultSchemaOwner, data.AdditionalSchemas)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// init_sql may have started index builds or other schema changes dependent resources rely on
+	resp.Diagnostics.Append(awaitSchemaChanges(ctx, client, data.sqlName().ValueString(), mark, timeout)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	identity, err := data.identity(ctx, client)
+	if err != nil {
+		resp.Diagnostics.AddError("Create db error", fmt.Sprintf("Unable to read cluster id, got error: %s", err))
+		return
+	}
+	resp.Diagnostics.Append(setIdentity(ctx, resp.Identity, identity)...)
+	data.Fingerprint = types.StringValue(environmentFingerprint(identity.ClusterID.ValueString(), id))
+
+	resp.Diagnostics.Append(data.readContents(ctx, client)...)
+	resp.Diagnostics.Append(data.readPlacement(ctx, client)...)
+
+	statements, diags := client.appliedStatements(ctx)
+	resp.Diagnostics.Append(diags...)
+	data.LastAppliedStatements = statements
+
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
@@ -106,7 +377,7 @@ func (r *DatabaseResource) Read(ctx context.Context, req resource.ReadRequest, r
 		return
 	}
 
-	client, err := r.db.Connect()
+	client, err := r.db.ConnectForRead(ctx)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Failed to connect to cockroach",
@@ -114,38 +385,218 @@ func (r *DatabaseResource) Read(ctx context.Context, req resource.ReadRequest, r
 		)
 		return
 	}
+	defer client.Close()
 
-	queryName := strings.Replace(data.Name.String(), "\"", "", -1)
+	dialect, err := client.Dialect(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Read db error", fmt.Sprintf("Unable to determine server version, got error: %s", err))
+		return
+	}
+	query, diags := dialect.Databases()
+	resp.Diagnostics.Append(diags...)
+
+	queryName := data.sqlName().ValueString()
 	var name string
+	var id int64
+
+	// Follow the descriptor first so a database renamed outside of terraform shows up as name drift instead of a new database
+	storedID, ok, diags := getPrivateID(ctx, req.Private, privateKeyDescriptorID)
+	resp.Diagnostics.Append(diags...)
+	if ok {
+		id = storedID
+		name, err = client.cache().databaseByID(ctx, client, query, storedID)
+		if err == nil && name != queryName {
+			resp.Diagnostics.AddWarning(
+				"Database was renamed",
+				fmt.Sprintf("Database %s (descriptor id %d) was renamed to %s outside of terraform.", queryName, id, name),
+			)
+		}
+	}
 
-	q := fmt.Sprintf("SELECT name FROM crdb_internal.databases WHERE name = '%s'", queryName)
-	err = client.QueryRow(q).Scan(&name)
+	if !ok || err == sql.ErrNoRows {
+		name = queryName
+		id, err = client.cache().databaseByName(ctx, client, query, queryName)
+		if err == sql.ErrNoRows {
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		// A different descriptor under the same name means the database was dropped and recreated outside of terraform
+		if err == nil && ok {
+			resp.Diagnostics.AddWarning(
+				"Database was recreated",
+				fmt.Sprintf("Database %s now has descriptor id %d instead of %d, it was dropped and recreated outside of terraform.", name, id, storedID),
+			)
+		}
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Read db error", fmt.Sprintf("Unable to read database, got error: %s", err))
+		return
+	}
+	resp.Diagnostics.Append(setPrivateID(ctx, resp.Private, privateKeyDescriptorID, id)...)
 
-	if err == sql.ErrNoRows {
-		data.Name = types.StringValue(name)
-		resp.State.RemoveResource(ctx)
+	if data.FullName.IsNull() {
+		data.Name = exactIdentifierValue(name)
+	} else {
+		data.FullName = types.StringValue(name)
 	}
 
-	if types.StringValue(name) != data.Name {
-		data.Name = types.StringValue(name)
-		resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	labels, diags := readLabels(ctx, client, labelObjectDatabase, name)
+	resp.Diagnostics.Append(diags...)
+	data.Labels = labels
+
+	if !data.GCTTLSeconds.IsNull() {
+		raw, err := databaseZoneConfig(ctx, client, name)
+		if err != nil {
+			resp.Diagnostics.AddError("Read db error", fmt.Sprintf("Unable to read zone configuration, got error: %s", err))
+			return
+		}
+		if ttl, ok := zoneConfigInt(raw, "gc.ttlseconds"); ok {
+			data.GCTTLSeconds = types.Int64Value(ttl)
+		}
 	}
 
-	defer client.Close()
+	if !data.PublicSchemaCreate.IsNull() {
+		creators, err := schemaGrantees(ctx, client, name, "public", "CREATE")
+		if err != nil {
+			resp.Diagnostics.AddError("Read db error", fmt.Sprintf("Unable to read public schema grants, got error: %s", err))
+			return
+		}
+		data.PublicSchemaCreate = types.BoolValue(slices.Contains(creators, "public"))
+	}
+
+	if !data.PublicSchemaUsage.IsNull() {
+		users, err := schemaGrantees(ctx, client, name, "public", "USAGE")
+		if err != nil {
+			resp.Diagnostics.AddError("Read db error", fmt.Sprintf("Unable to read public schema grants, got error: %s", err))
+			return
+		}
+		set, diags := types.SetValueFrom(ctx, types.StringType, users)
+		resp.Diagnostics.Append(diags...)
+		data.PublicSchemaUsage = set
+	}
+
+	if !data.DefaultSchemaOwner.IsNull() || !data.AdditionalSchemas.IsNull() {
+		resp.Diagnostics.Append(data.readSchemaOwnership(ctx, client, name)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	identity, err := data.identity(ctx, client)
+	if err != nil {
+		resp.Diagnostics.AddError("Read db error", fmt.Sprintf("Unable to read cluster id, got error: %s", err))
+		return
+	}
+	resp.Diagnostics.Append(setIdentity(ctx, resp.Identity, identity)...)
+	data.Fingerprint = types.StringValue(environmentFingerprint(identity.ClusterID.ValueString(), id))
+
+	resp.Diagnostics.Append(data.readContents(ctx, client)...)
+	resp.Diagnostics.Append(data.readPlacement(ctx, client)...)
 
 	// Save updated data into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
+// Update renames the database in place, keeping its descriptor and data, and applies label and zone config changes
 func (r *DatabaseResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
 	var data *DatabaseResourceModel
+	var state *DatabaseResourceModel
 
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, err := r.db.Connect(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to connect to cockroach",
+			err.Error(),
+		)
+		return
+	}
+	defer client.Close()
+
+	timeout, diags := data.Timeouts.Update(ctx, defaultSchemaChangeTimeout)
+	resp.Diagnostics.Append(diags...)
+	mark, err := schemaChangeMark(ctx, client)
+	if err != nil {
+		resp.Diagnostics.AddError("Update db error", fmt.Sprintf("Unable to read the cluster time, got error: %s", err))
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !state.Name.Equal(data.Name) {
+		sql := fmt.Sprintf("ALTER DATABASE %s RENAME TO %s", state.sqlName().String(), data.sqlName().String())
+		_, err = client.ExecContext(ctx, sql)
+		if err != nil {
+			resp.Diagnostics.AddError("Update db error", fmt.Sprintf("Unable to rename database, got error: %s", err))
+			return
+		}
+
+		resp.Diagnostics.Append(deleteLabels(ctx, client, labelObjectDatabase, state.sqlName().ValueString())...)
+		tflog.Trace(ctx, "renamed a database")
+	}
+
+	if !state.Name.Equal(data.Name) || !state.Labels.Equal(data.Labels) {
+		resp.Diagnostics.Append(writeLabels(ctx, client, labelObjectDatabase, data.sqlName().ValueString(), data.Labels)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if !state.InitSQL.Equal(data.InitSQL) {
+		resp.Diagnostics.AddWarning("init_sql not applied", "init_sql only runs when the database is created, the changed statements were recorded but not executed.")
+	}
+
+	if !state.GCTTLSeconds.Equal(data.GCTTLSeconds) {
+		ttl := int64(-1)
+		if !data.GCTTLSeconds.IsNull() {
+			ttl = data.GCTTLSeconds.ValueInt64()
+		}
+		err = setDatabaseGCTTL(ctx, client, data.sqlName().ValueString(), ttl)
+		if err != nil {
+			resp.Diagnostics.AddError("Update db error", fmt.Sprintf("Unable to configure gc ttl, got error: %s", err))
+			return
+		}
+	}
+
+	if !state.PublicSchemaCreate.Equal(data.PublicSchemaCreate) || !state.PublicSchemaUsage.Equal(data.PublicSchemaUsage) {
+		resp.Diagnostics.Append(applyPublicSchemaGrants(ctx, client, data.sqlName().ValueString(), data.PublicSchemaCreate, data.PublicSchemaUsage)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if !state.DefaultSchemaOwner.Equal(data.DefaultSchemaOwner) || !state.AdditionalSchemas.Equal(data.AdditionalSchemas) {
+		resp.Diagnostics.Append(applySchemaOwnership(ctx, client, data.sqlName().ValueString(), data.DefaultSchemaOwner, data.AdditionalSchemas)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
 
+	resp.Diagnostics.Append(awaitSchemaChanges(ctx, client, data.sqlName().ValueString(), mark, timeout)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
+	identity, err := data.identity(ctx, client)
+	if err != nil {
+		resp.Diagnostics.AddError("Update db error", fmt.Sprintf("Unable to read cluster id, got error: %s", err))
+		return
+	}
+	resp.Diagnostics.Append(setIdentity(ctx, resp.Identity, identity)...)
+
+	resp.Diagnostics.Append(data.readContents(ctx, client)...)
+	resp.Diagnostics.Append(data.readPlacement(ctx, client)...)
+
+	statements, diags := client.appliedStatements(ctx)
+	resp.Diagnostics.Append(diags...)
+	data.LastAppliedStatements = statements
+
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
@@ -154,7 +605,12 @@ func (r *DatabaseResource) Delete(ctx context.Context, req resource.DeleteReques
 	var data *DatabaseResourceModel
 	req.State.Get(ctx, &data)
 
-	client, err := r.db.Connect()
+	resp.Diagnostics.Append(r.db.checkDeletionProtection("database", data.sqlName().ValueString(), data.AllowDestroy)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, err := r.db.Connect(ctx)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Failed to connect to cockroach",
@@ -164,25 +620,351 @@ func (r *DatabaseResource) Delete(ctx context.Context, req resource.DeleteReques
 	}
 	defer client.Close()
 
+	// Dropping the database under a running job leaves it failing forever
+	if !data.ForceDestroy.ValueBool() {
+		jobs, err := activeJobsOn(ctx, client, data.sqlName().ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Delete db error", fmt.Sprintf("Unable to check for active jobs, got error: %s", err))
+			return
+		}
+		if len(jobs) > 0 {
+			list := []string{}
+			for _, j := range jobs {
+				list = append(list, j.String())
+			}
+			resp.Diagnostics.AddError(
+				"Database has active jobs",
+				fmt.Sprintf("Refusing to drop database %s while these jobs target it:\n%s\nCancel them or set force_destroy = true.", data.sqlName().ValueString(), strings.Join(list, "\n")),
+			)
+			return
+		}
+	}
+
 	sql := ""
 	disabled := data.DisableProtection.ValueBool()
 
 	if disabled {
-		sql = fmt.Sprintf("DROP DATABASE %s CASCADE", data.Name.String())
+		sql = fmt.Sprintf("DROP DATABASE %s CASCADE", data.sqlName().String())
 	} else {
-		sql = fmt.Sprintf("DROP DATABASE %s RESTRICT", data.Name.String())
+		sql = fmt.Sprintf("DROP DATABASE %s RESTRICT", data.sqlName().String())
 	}
 
-	_, err = client.Exec(sql)
+	_, err = client.ExecContext(ctx, sql)
 	if err != nil {
 		resp.Diagnostics.AddError("Delete db error", fmt.Sprintf("Unable to delete database, got error: %s", err))
 		return
 	}
 	tflog.Trace(ctx, "deleted a database")
 
+	resp.Diagnostics.Append(deleteLabels(ctx, client, labelObjectDatabase, data.sqlName().ValueString())...)
+
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
+// applyPublicSchemaGrants grants or revokes CREATE on the public schema for everyone and makes the roles with USAGE
+// match, unset values are left alone
+func applyPublicSchemaGrants(ctx context.Context, client Executor, database string, create types.Bool, usage types.Set) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	schema := pq.QuoteIdentifier(database) + ".public"
+	statements := []string{}
+
+	if !create.IsNull() {
+		if create.ValueBool() {
+			statements = append(statements, fmt.Sprintf("GRANT CREATE ON SCHEMA %s TO public", schema))
+		} else {
+			statements = append(statements, fmt.Sprintf("REVOKE CREATE ON SCHEMA %s FROM public", schema))
+		}
+	}
+
+	if !usage.IsNull() {
+		desired := []string{}
+		diags.Append(usage.ElementsAs(ctx, &desired, false)...)
+		if diags.HasError() {
+			return diags
+		}
+		current, err := schemaGrantees(ctx, client, database, "public", "USAGE")
+		if err != nil {
+			diags.AddError("Public schema grants error", fmt.Sprintf("Unable to read public schema grants, got error: %s", err))
+			return diags
+		}
+		for _, role := range desired {
+			if !slices.Contains(current, role) {
+				statements = append(statements, fmt.Sprintf("GRANT USAGE ON SCHEMA %s TO %s", schema, roleName(role)))
+			}
+		}
+		for _, role := range current {
+			if !slices.Contains(desired, role) {
+				statements = append(statements, fmt.Sprintf("REVOKE USAGE ON SCHEMA %s FROM %s", schema, roleName(role)))
+			}
+		}
+	}
+
+	for _, statement := range statements {
+		if _, err := client.ExecContext(ctx, statement); err != nil {
+			diags.AddError("Public schema grants error", fmt.Sprintf("Unable to run %s, got error: %s", statement, err))
+			return diags
+		}
+	}
+	tflog.Trace(ctx, "applied public schema grants")
+	return diags
+}
+
+// applySchemaOwnership creates the additional schemas and hands them and the public schema to the owner, unset values
+// are left alone
+func applySchemaOwnership(ctx context.Context, client Executor, database string, owner types.String, additional types.Set) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	schemas := []string{}
+	diags.Append(additional.ElementsAs(ctx, &schemas, false)...)
+	if diags.HasError() {
+		return diags
+	}
+	sort.Strings(schemas)
+
+	statements := []string{}
+	for _, schema := range schemas {
+		statements = append(statements, fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s.%s", pq.QuoteIdentifier(database), pq.QuoteIdentifier(schema)))
+	}
+	if !owner.IsNull() {
+		for _, schema := range append([]string{"public"}, schemas...) {
+			statements = append(statements, fmt.Sprintf("ALTER SCHEMA %s.%s OWNER TO %s", pq.QuoteIdentifier(database), pq.QuoteIdentifier(schema), pq.QuoteIdentifier(owner.ValueString())))
+		}
+	}
+
+	for _, statement := range statements {
+		if _, err := client.ExecContext(ctx, statement); err != nil {
+			diags.AddError("Schema ownership error", fmt.Sprintf("Unable to run %s, got error: %s", statement, err))
+			return diags
+		}
+	}
+	tflog.Trace(ctx, "applied schema ownership")
+	return diags
+}
+
+// readSchemaOwnership refreshes which additional schemas exist and who owns them. A schema owned by someone else
+// shows up as a different default_schema_owner, so the next apply transfers it back.
+func (m *DatabaseResourceModel) readSchemaOwnership(ctx context.Context, client Executor, database string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	owners, err := schemaOwners(ctx, client, database)
+	if err != nil {
+		diags.AddError("Read db error", fmt.Sprintf("Unable to read schema owners, got error: %s", err))
+		return diags
+	}
+
+	managed := []string{"public"}
+	if !m.AdditionalSchemas.IsNull() {
+		declared := []string{}
+		diags.Append(m.AdditionalSchemas.ElementsAs(ctx, &declared, false)...)
+		found := []string{}
+		for _, schema := range declared {
+			if _, ok := owners[schema]; ok {
+				found = append(found, schema)
+			}
+		}
+		set, d := types.SetValueFrom(ctx, types.StringType, found)
+		diags.Append(d...)
+		m.AdditionalSchemas = set
+		managed = append(managed, found...)
+	}
+
+	if !m.DefaultSchemaOwner.IsNull() {
+		for _, schema := range managed {
+			if owner := owners[schema]; owner != m.DefaultSchemaOwner.ValueString() {
+				m.DefaultSchemaOwner = types.StringValue(owner)
+				break
+			}
+		}
+	}
+	return diags
+}
+
+// schemaOwners maps the schemas of a database to their owners
+func schemaOwners(ctx context.Context, client Executor, database string) (map[string]string, error) {
+	rows, err := client.QueryContext(ctx, fmt.Sprintf("SELECT schema_name, owner FROM [SHOW SCHEMAS FROM %s]", pq.QuoteIdentifier(database)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	owners := map[string]string{}
+	for rows.Next() {
+		var schema string
+		var owner sql.NullString
+		if err := rows.Scan(&schema, &owner); err != nil {
+			return nil, err
+		}
+		owners[schema] = owner.String
+	}
+	return owners, rows.Err()
+}
+
+// identity is the resource identity of the database on the connected cluster
+func (m *DatabaseResourceModel) identity(ctx context.Context, client Session) (databaseIdentityModel, error) {
+	clusterID, err := client.ClusterID(ctx)
+	if err != nil {
+		return databaseIdentityModel{}, err
+	}
+	return databaseIdentityModel{Name: m.sqlName().StringValue, ClusterID: types.StringValue(clusterID)}, nil
+}
+
+// nullUnknowns clears the computed attributes a failed create didn't get to, state can't hold unknown values
+func (m *DatabaseResourceModel) nullUnknowns() {
+	if m.NumReplicas.IsUnknown() {
+		m.NumReplicas = types.Int64Null()
+	}
+	if m.NumVoters.IsUnknown() {
+		m.NumVoters = types.Int64Null()
+	}
+	if m.Constraints.IsUnknown() {
+		m.Constraints = types.StringNull()
+	}
+	if m.VoterConstraints.IsUnknown() {
+		m.VoterConstraints = types.StringNull()
+	}
+	if m.LeasePreferences.IsUnknown() {
+		m.LeasePreferences = types.StringNull()
+	}
+	if m.Fingerprint.IsUnknown() {
+		m.Fingerprint = types.StringNull()
+	}
+}
+
+// readContents fills in the schemas and table count of the database
+func (m *DatabaseResourceModel) readContents(ctx context.Context, client Executor) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	schemas, tables, err := databaseContents(ctx, client, m.sqlName().ValueString())
+	if err != nil {
+		diags.AddError("Read db error", fmt.Sprintf("Unable to read schemas and tables, got error: %s", err))
+		return diags
+	}
+
+	list, d := types.ListValueFrom(ctx, types.StringType, schemas)
+	diags.Append(d...)
+	m.Schemas = list
+	m.TableCount = types.Int64Value(tables)
+	return diags
+}
+
+// readPlacement fills in the replica placement and lease preferences from the zone configuration, so applications can
+// route reads in multi-region deployments
+func (m *DatabaseResourceModel) readPlacement(ctx context.Context, client Executor) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	raw, err := databaseZoneConfig(ctx, client, m.sqlName().ValueString())
+	if err != nil {
+		diags.AddError("Read db error", fmt.Sprintf("Unable to read zone configuration, got error: %s", err))
+		return diags
+	}
+
+	m.NumReplicas, m.NumVoters = types.Int64Null(), types.Int64Null()
+	if replicas, ok := zoneConfigInt(raw, "num_replicas"); ok {
+		m.NumReplicas = types.Int64Value(replicas)
+	}
+	if voters, ok := zoneConfigInt(raw, "num_voters"); ok {
+		m.NumVoters = types.Int64Value(voters)
+	}
+
+	for key, value := range map[string]*types.String{
+		"constraints":       &m.Constraints,
+		"voter_constraints": &m.VoterConstraints,
+		"lease_preferences": &m.LeasePreferences,
+	} {
+		*value = types.StringNull()
+		// Unset constraints show up as empty lists
+		if setting, ok := zoneConfigString(raw, key); ok && setting != "[]" && setting != "" {
+			*value = types.StringValue(setting)
+		}
+	}
+	return diags
+}
+
+// databaseContents lists the user defined schemas of a database and counts its tables
+func databaseContents(ctx context.Context, client Executor, database string) ([]string, int64, error) {
+	rows, err := client.QueryContext(ctx, fmt.Sprintf("SELECT schema_name FROM [SHOW SCHEMAS FROM %s] WHERE schema_name NOT IN ('crdb_internal', 'information_schema', 'pg_catalog', 'pg_extension') ORDER BY schema_name", pq.QuoteIdentifier(database)))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	schemas := []string{}
+	for rows.Next() {
+		var schema string
+		if err := rows.Scan(&schema); err != nil {
+			return nil, 0, err
+		}
+		schemas = append(schemas, schema)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	var tables int64
+	err = client.QueryRowContext(ctx, fmt.Sprintf("SELECT count(*) FROM %s.information_schema.tables WHERE table_type = 'BASE TABLE'", pq.QuoteIdentifier(database))).Scan(&tables)
+	return schemas, tables, err
+}
+
+// runInitSQL executes the bootstrap statements of a freshly created database in one transaction
+func runInitSQL(ctx context.Context, client Executor, database string, initSQL types.List) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	var statements []string
+	diags.Append(initSQL.ElementsAs(ctx, &statements, false)...)
+	if diags.HasError() || len(statements) == 0 {
+		return diags
+	}
+
+	tx, err := client.BeginTx(ctx, nil)
+	if err != nil {
+		diags.AddError("Init sql error", fmt.Sprintf("Unable to start a transaction, got error: %s", err))
+		return diags
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	movedTo(client, database)
+	_, err = tx.ExecContext(ctx, fmt.Sprintf("SET DATABASE = %s", pq.QuoteIdentifier(database)))
+	if err != nil {
+		diags.AddError("Init sql error", fmt.Sprintf("Unable to switch to the new database, got error: %s", err))
+		return diags
+	}
+
+	for i, statement := range statements {
+		_, err = tx.ExecContext(ctx, statement)
+		if err != nil {
+			diags.AddError("Init sql error", fmt.Sprintf("Unable to run init_sql statement %d, got error: %s", i, err))
+			return diags
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		diags.AddError("Init sql error", fmt.Sprintf("Unable to commit init_sql, got error: %s", err))
+	}
+	tflog.Trace(ctx, "ran init sql")
+	return diags
+}
+
+// ImportState takes the database name as identifier, or an identity whose cluster_id is checked when set
 func (r *DatabaseResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+	if req.ID != "" {
+		resource.ImportStatePassthroughID(ctx, path.Root("name"), req, resp)
+		return
+	}
+
+	// Imported by identity, which may pin the cluster
+	var identity databaseIdentityModel
+	resp.Diagnostics.Append(req.Identity.Get(ctx, &identity)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if expected := identity.ClusterID.ValueString(); expected != "" {
+		resp.Diagnostics.Append(verifyClusterID(ctx, r.db, expected)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), identity.Name.ValueString())...)
 }
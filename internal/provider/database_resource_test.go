@@ -0,0 +1,185 @@
+package provider
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestDatabaseContents(t *testing.T) {
+	client := newMockConn(t,
+		mockQuery{contains: `SHOW SCHEMAS FROM "app"`, columns: []string{"schema_name"}, rows: [][]driver.Value{{"audit"}, {"public"}}},
+		mockQuery{contains: `"app".information_schema.tables`, columns: []string{"count"}, rows: [][]driver.Value{{int64(7)}}},
+	)
+
+	schemas, tables, err := databaseContents(context.Background(), client, "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schemas) != 2 || schemas[0] != "audit" || schemas[1] != "public" {
+		t.Errorf("unexpected schemas %v", schemas)
+	}
+	if tables != 7 {
+		t.Errorf("expected 7 tables, got %d", tables)
+	}
+}
+
+func TestApplyPublicSchemaGrants(t *testing.T) {
+	client := newMockConn(t,
+		mockQuery{contains: `SHOW GRANTS ON SCHEMA "app"."public"`, columns: []string{"grantee"}, rows: [][]driver.Value{{"legacy"}, {"public"}}},
+		mockQuery{contains: `REVOKE CREATE ON SCHEMA "app".public FROM public`},
+		mockQuery{contains: `GRANT USAGE ON SCHEMA "app".public TO "reporter"`},
+		mockQuery{contains: `REVOKE USAGE ON SCHEMA "app".public FROM "legacy"`},
+	)
+
+	usage := types.SetValueMust(types.StringType, []attr.Value{types.StringValue("public"), types.StringValue("reporter")})
+	diags := applyPublicSchemaGrants(context.Background(), client, "app", types.BoolValue(false), usage)
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+}
+
+func TestApplySchemaOwnership(t *testing.T) {
+	client := newMockConn(t,
+		mockQuery{contains: `CREATE SCHEMA IF NOT EXISTS "app"."audit"`},
+		mockQuery{contains: `CREATE SCHEMA IF NOT EXISTS "app"."reporting"`},
+		mockQuery{contains: `ALTER SCHEMA "app"."public" OWNER TO "migrator"`},
+		mockQuery{contains: `ALTER SCHEMA "app"."audit" OWNER TO "migrator"`},
+		mockQuery{contains: `ALTER SCHEMA "app"."reporting" OWNER TO "migrator"`},
+	)
+
+	schemas := types.SetValueMust(types.StringType, []attr.Value{types.StringValue("reporting"), types.StringValue("audit")})
+	diags := applySchemaOwnership(context.Background(), client, "app", types.StringValue("migrator"), schemas)
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+}
+
+func TestReadSchemaOwnership(t *testing.T) {
+	client := newMockConn(t, mockQuery{
+		contains: `SHOW SCHEMAS FROM "app"`,
+		columns:  []string{"schema_name", "owner"},
+		rows:     [][]driver.Value{{"public", "migrator"}, {"audit", "root"}},
+	})
+
+	data := DatabaseResourceModel{
+		DefaultSchemaOwner: types.StringValue("migrator"),
+		AdditionalSchemas:  types.SetValueMust(types.StringType, []attr.Value{types.StringValue("audit"), types.StringValue("dropped")}),
+	}
+	diags := data.readSchemaOwnership(context.Background(), client, "app")
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	// The dropped schema disappears and the schema taken over by root shows as owner drift
+	if len(data.AdditionalSchemas.Elements()) != 1 || data.DefaultSchemaOwner.ValueString() != "root" {
+		t.Errorf("unexpected schemas %s owned by %s", data.AdditionalSchemas, data.DefaultSchemaOwner)
+	}
+}
+
+func TestDatabaseCreateKeepsStateOnFailure(t *testing.T) {
+	ctx := context.Background()
+
+	r := &DatabaseResource{db: newMockClient(t,
+		mockQuery{contains: "SELECT now()", columns: []string{"now"}, rows: [][]driver.Value{{time.Now()}}},
+		mockQuery{contains: `CREATE DATABASE "app"`},
+		mockQuery{contains: "SELECT version()", columns: []string{"version"}, rows: [][]driver.Value{{"CockroachDB CCL v23.2.1 (x86_64-pc-linux-gnu)"}}},
+		mockQuery{contains: "FROM crdb_internal.databases", columns: []string{"id", "name"}, rows: [][]driver.Value{{int64(104), "app"}}},
+		mockQuery{contains: "gc.ttlseconds = 3600", err: errors.New("zone configs are disabled")},
+	)}
+
+	plan := newDatabasePlan(t, r, "app")
+	gcTTL := int64(3600)
+	diags := plan.SetAttribute(ctx, path.Root("gc_ttl_seconds"), &gcTTL)
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	resp := resource.CreateResponse{State: tfsdk.State{Schema: plan.Schema, Raw: tftypes.NewValue(plan.Raw.Type(), nil)}}
+	r.Create(ctx, resource.CreateRequest{Plan: plan}, &resp)
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("expected the gc ttl error")
+	}
+
+	// The database exists, so it has to be in state for Terraform to taint it
+	var data DatabaseResourceModel
+	diags = resp.State.Get(ctx, &data)
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	if data.Name.ValueString() != "app" || !data.Fingerprint.IsNull() {
+		t.Errorf("expected the database in state with unknowns cleared, got %+v", data)
+	}
+}
+
+func TestDatabaseCreateResolvesName(t *testing.T) {
+	ctx := context.Background()
+
+	for config, want := range map[string]string{"MyDB": "mydb", `"MyDB"`: "MyDB"} {
+		// The lookup finds nothing, which stops the create right after the statements of interest
+		r := &DatabaseResource{db: newMockClient(t,
+			mockQuery{contains: "SELECT now()", columns: []string{"now"}, rows: [][]driver.Value{{time.Now()}}},
+			mockQuery{contains: fmt.Sprintf(`CREATE DATABASE "%s"`, want)},
+			mockQuery{contains: "SELECT version()", columns: []string{"version"}, rows: [][]driver.Value{{"CockroachDB CCL v23.2.1 (x86_64-pc-linux-gnu)"}}},
+			mockQuery{contains: "FROM crdb_internal.databases", args: []driver.Value{want}, columns: []string{"id", "name"}},
+		)}
+
+		plan := newDatabasePlan(t, r, config)
+		resp := resource.CreateResponse{State: tfsdk.State{Schema: plan.Schema, Raw: tftypes.NewValue(plan.Raw.Type(), nil)}}
+		r.Create(ctx, resource.CreateRequest{Plan: plan}, &resp)
+		if !resp.Diagnostics.HasError() {
+			t.Errorf("expected %s to fail on the empty lookup", config)
+		}
+	}
+}
+
+// newDatabasePlan plans a database with only its name set
+func newDatabasePlan(t *testing.T, r *DatabaseResource, name string) tfsdk.Plan {
+	t.Helper()
+	ctx := context.Background()
+
+	var schemaResp resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+
+	plan := tfsdk.Plan{Schema: schemaResp.Schema, Raw: tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil)}
+	diags := plan.Set(ctx, &DatabaseResourceModel{
+		Name:                  newIdentifierValue(name),
+		DisableProtection:     types.BoolValue(false),
+		Labels:                types.MapNull(types.StringType),
+		GCTTLSeconds:          types.Int64Null(),
+		PublicSchemaCreate:    types.BoolNull(),
+		PublicSchemaUsage:     types.SetNull(types.StringType),
+		AdditionalSchemas:     types.SetNull(types.StringType),
+		AllowDestroy:          types.BoolValue(true),
+		ForceDestroy:          types.BoolValue(false),
+		InitSQL:               types.ListNull(types.StringType),
+		Temporary:             types.BoolValue(false),
+		FullName:              types.StringUnknown(),
+		ExpiresAt:             types.StringUnknown(),
+		Schemas:               types.ListUnknown(types.StringType),
+		TableCount:            types.Int64Unknown(),
+		NumReplicas:           types.Int64Unknown(),
+		NumVoters:             types.Int64Unknown(),
+		Constraints:           types.StringUnknown(),
+		VoterConstraints:      types.StringUnknown(),
+		LeasePreferences:      types.StringUnknown(),
+		Fingerprint:           types.StringUnknown(),
+		LastAppliedStatements: types.ListUnknown(appliedStatementType),
+		Timeouts:              timeouts.Value{Object: types.ObjectNull(map[string]attr.Type{"create": types.StringType, "update": types.StringType})},
+	})
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	return plan
+}
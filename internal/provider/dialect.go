@@ -0,0 +1,161 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/lib/pq"
+)
+
+// Syntax the provider emits which cockroach deprecates in some release
+const (
+	syntaxViewRoleOptions = "view_role_options"
+	syntaxControlFeed     = "control_changefeed"
+)
+
+// deprecatedSyntax records the first release deprecating a piece of syntax and what replaces it
+type deprecatedSyntax struct {
+	Major       int
+	Minor       int
+	Description string
+	Replacement string
+}
+
+var deprecations = map[string]deprecatedSyntax{
+	syntaxViewRoleOptions: {22, 2, "the VIEWACTIVITY and VIEWCLUSTERSETTING role options", "GRANT SYSTEM"},
+	syntaxControlFeed:     {23, 1, "the CONTROLCHANGEFEED role option", "the changefeed table privilege"},
+}
+
+// dialect generates statements for the server version we're connected to. Builders pick the syntax the version
+// supports and return a warning when they have to fall back to something the version deprecates.
+type dialect struct {
+	version serverVersion
+}
+
+// Dialect returns the statement generator for the connected cluster
+func (c *CockroachConn) Dialect(ctx context.Context) (dialect, error) {
+	version, err := c.ServerVersion(ctx)
+	if err != nil {
+		return dialect{}, err
+	}
+	return dialect{version: version}, nil
+}
+
+// use returns a warning if the server deprecates the syntax
+func (d dialect) use(syntax string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	deprecated, ok := deprecations[syntax]
+	if !ok || !d.version.AtLeast(deprecated.Major, deprecated.Minor) {
+		return diags
+	}
+
+	detail := fmt.Sprintf("The cluster runs %s, which deprecates %s. The provider still relies on it and may break after the next upgrade.", d.version, deprecated.Description)
+	if deprecated.Replacement != "" {
+		detail += fmt.Sprintf(" Please report this issue to the provider developers, it should use %s instead.", deprecated.Replacement)
+	}
+	diags.AddWarning("Deprecated CockroachDB syntax", detail)
+	return diags
+}
+
+// DatabaseByName selects the descriptor id and name of a database by name ($1)
+func (d dialect) DatabaseByName() (string, diag.Diagnostics) {
+	return "SELECT id, name FROM crdb_internal.databases WHERE name = $1", nil
+}
+
+// Databases selects the descriptor id and name of every database
+func (d dialect) Databases() (string, diag.Diagnostics) {
+	return "SELECT id, name FROM crdb_internal.databases", nil
+}
+
+// Users selects the username and id of every user
+func (d dialect) Users() (string, diag.Diagnostics) {
+	return "SELECT username, user_id FROM system.users", nil
+}
+
+// UserID selects the id of a user by username ($1)
+func (d dialect) UserID() (string, diag.Diagnostics) {
+	return "SELECT user_id FROM system.users WHERE username = $1", nil
+}
+
+// ObservabilityGrant lets a user see cluster activity and settings. 22.2 turned these into system privileges,
+// older clusters only know them as role options.
+func (d dialect) ObservabilityGrant(username string) (string, diag.Diagnostics) {
+	if d.version.AtLeast(22, 2) {
+		return fmt.Sprintf("GRANT SYSTEM VIEWACTIVITY, VIEWCLUSTERSETTING TO %s", pq.QuoteIdentifier(username)), nil
+	}
+	return fmt.Sprintf("ALTER USER %s WITH VIEWACTIVITY VIEWCLUSTERSETTING", pq.QuoteIdentifier(username)), d.use(syntaxViewRoleOptions)
+}
+
+// ObservabilityRevoke takes back what ObservabilityGrant granted
+func (d dialect) ObservabilityRevoke(username string) (string, diag.Diagnostics) {
+	if d.version.AtLeast(22, 2) {
+		return fmt.Sprintf("REVOKE SYSTEM VIEWACTIVITY, VIEWCLUSTERSETTING FROM %s", pq.QuoteIdentifier(username)), nil
+	}
+	return fmt.Sprintf("ALTER USER %s WITH NOVIEWACTIVITY NOVIEWCLUSTERSETTING", pq.QuoteIdentifier(username)), d.use(syntaxViewRoleOptions)
+}
+
+// ControlChangefeedGrant lets a user create changefeeds on every table it can select from. Newer clusters want the
+// changefeed privilege on each table instead, but still honor the role option.
+func (d dialect) ControlChangefeedGrant(username string) (string, diag.Diagnostics) {
+	return fmt.Sprintf("ALTER USER %s WITH CONTROLCHANGEFEED", pq.QuoteIdentifier(username)), d.use(syntaxControlFeed)
+}
+
+// ControlChangefeedRevoke drops the role option ControlChangefeedGrant set
+func (d dialect) ControlChangefeedRevoke(username string) (string, diag.Diagnostics) {
+	return fmt.Sprintf("ALTER USER %s WITH NOCONTROLCHANGEFEED", pq.QuoteIdentifier(username)), d.use(syntaxControlFeed)
+}
+
+// TablesGrant grants privileges on the existing tables of the schemas in a database. ALL TABLES IN SCHEMA arrived in
+// 21.2, older clusters take a table pattern per schema.
+func (d dialect) TablesGrant(privileges string, database string, schemas []string, username string) string {
+	return fmt.Sprintf("GRANT %s ON %s TO %s", privileges, d.tablesTarget(database, schemas), pq.QuoteIdentifier(username))
+}
+
+// TablesRevoke revokes all privileges on the existing tables of the schemas in a database
+func (d dialect) TablesRevoke(database string, schemas []string, username string) string {
+	return fmt.Sprintf("REVOKE ALL ON %s FROM %s", d.tablesTarget(database, schemas), pq.QuoteIdentifier(username))
+}
+
+// tablesTarget names the tables of the schemas for GRANT and REVOKE
+func (d dialect) tablesTarget(database string, schemas []string) string {
+	qualified := []string{}
+	for _, schema := range schemas {
+		qualified = append(qualified, pq.QuoteIdentifier(database)+"."+pq.QuoteIdentifier(schema))
+	}
+	if d.version.AtLeast(21, 2) {
+		return "ALL TABLES IN SCHEMA " + strings.Join(qualified, ", ")
+	}
+	return "TABLE " + strings.Join(qualified, ".*, ") + ".*"
+}
+
+// DefaultTableReaders selects the grantees whose default privileges let them read tables created in a schema ($1) of
+// a database, whether the defaults hold for all roles or for the tables of one role
+func (d dialect) DefaultTableReaders(database string) (string, diag.Diagnostics) {
+	return fmt.Sprintf(`SELECT DISTINCT grantee FROM %s.crdb_internal.default_privileges
+		WHERE (schema_name = $1 OR schema_name IS NULL) AND object_type = 'tables' AND privilege_type IN ('SELECT', 'ALL')`, pq.QuoteIdentifier(database)), nil
+}
+
+// TableSizes selects schema, table name and live bytes of every table in a database ($1). Clusters before 23.2 have
+// no span stats, the bool is false there.
+func (d dialect) TableSizes() (string, bool, diag.Diagnostics) {
+	if !d.version.AtLeast(23, 2) {
+		return "", false, nil
+	}
+	return `SELECT t.schema_name, t.name, s.live_bytes
+		FROM crdb_internal.tables t
+		JOIN crdb_internal.table_span_stats s ON s.table_id = t.table_id
+		WHERE t.database_name = $1`, true, nil
+}
+
+// ChangefeedProtectedTimestamps selects job id, status, description, the protected HLC timestamp and its age in seconds
+// for every protected timestamp record held by a changefeed. Cockroach has no other view of these records.
+func (d dialect) ChangefeedProtectedTimestamps() (string, diag.Diagnostics) {
+	return `SELECT j.job_id, j.status, j.description, p.ts::STRING, floor(extract(epoch FROM now()) - p.ts / 1e9)::INT8
+		FROM crdb_internal.kv_protected_ts_records p
+		JOIN [SHOW CHANGEFEED JOBS] j ON j.job_id = (p.decoded_meta->>'id')::INT8
+		WHERE p.meta_type = 'jobs'
+		ORDER BY p.ts`, nil
+}
@@ -0,0 +1,44 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDialectDeprecations(t *testing.T) {
+	old := dialect{version: serverVersion{Major: 22, Minor: 1}}
+	if _, diags := old.ObservabilityGrant("monitor"); diags.WarningsCount() != 0 {
+		t.Errorf("expected no warning for role options on %s, got %v", old.version, diags)
+	}
+
+	current := dialect{version: serverVersion{Major: 25, Minor: 3}}
+	query, diags := current.DatabaseByName()
+	if !strings.Contains(query, "crdb_internal") || diags.WarningsCount() != 0 {
+		t.Errorf("expected crdb_internal without warnings on %s, got %q and %v", current.version, query, diags)
+	}
+
+	if _, diags := current.UserID(); diags.WarningsCount() != 0 {
+		t.Errorf("expected no warning for system.users on %s, got %v", current.version, diags)
+	}
+
+	grant, _ := current.ObservabilityGrant("monitor")
+	if grant != `GRANT SYSTEM VIEWACTIVITY, VIEWCLUSTERSETTING TO "monitor"` {
+		t.Errorf("unexpected observability grant %q", grant)
+	}
+
+	if _, diags := current.ControlChangefeedGrant("feeds"); diags.WarningsCount() != 1 {
+		t.Errorf("expected a deprecation warning for CONTROLCHANGEFEED on %s, got %v", current.version, diags)
+	}
+}
+
+func TestDialectTablesGrant(t *testing.T) {
+	current := dialect{version: serverVersion{Major: 23, Minor: 2}}
+	if grant := current.TablesGrant("SELECT", "app", []string{"public", "reports"}, "reader"); grant != `GRANT SELECT ON ALL TABLES IN SCHEMA "app"."public", "app"."reports" TO "reader"` {
+		t.Errorf("unexpected grant %q", grant)
+	}
+
+	old := dialect{version: serverVersion{Major: 21, Minor: 1}}
+	if revoke := old.TablesRevoke("app", []string{"public", "reports"}, "reader"); revoke != `REVOKE ALL ON TABLE "app"."public".*, "app"."reports".* FROM "reader"` {
+		t.Errorf("unexpected revoke %q", revoke)
+	}
+}
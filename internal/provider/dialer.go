@@ -0,0 +1,43 @@
+package provider
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// connDialer dials cockroach, or the auth proxy when one is set, with the provider's dial timeout and TCP keepalive,
+// so a dead network path fails quickly instead of hanging an apply until the OS gives up
+type connDialer struct {
+	dialer net.Dialer
+	proxy  string
+}
+
+// newConnDialer returns a dialer for the client's settings. A zero timeout or keepalive keeps the Go default, a
+// negative keepalive disables it.
+func newConnDialer(proxy string, timeout time.Duration, keepAlive time.Duration) connDialer {
+	return connDialer{dialer: net.Dialer{Timeout: timeout, KeepAlive: keepAlive}, proxy: proxy}
+}
+
+// target swaps in the proxy address when there is one
+func (d connDialer) target(network string, address string) (string, string) {
+	if d.proxy != "" {
+		return proxyDialer{address: d.proxy}.network(), d.proxy
+	}
+	return network, address
+}
+
+func (d connDialer) Dial(network string, address string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, address)
+}
+
+func (d connDialer) DialTimeout(network string, address string, timeout time.Duration) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return d.DialContext(ctx, network, address)
+}
+
+func (d connDialer) DialContext(ctx context.Context, network string, address string) (net.Conn, error) {
+	network, address = d.target(network, address)
+	return d.dialer.DialContext(ctx, network, address)
+}
@@ -0,0 +1,23 @@
+package provider
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestConnDialerProxy(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	// The host from the connection string is replaced by the proxy
+	dialer := newConnDialer(listener.Addr().String(), time.Second, 30*time.Second)
+	conn, err := dialer.DialTimeout("tcp", "cockroach.invalid:26257", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+}
@@ -0,0 +1,53 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the client and its connections satisfy the interfaces resources are written against.
+var _ Database = &CockroachClient{}
+var _ Session = &CockroachConn{}
+
+// Querier runs statements, on a connection or inside a transaction
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Tx is a transaction started by an Executor
+type Tx interface {
+	Querier
+	Commit() error
+	Rollback() error
+}
+
+// Executor runs statements and transactions. Helpers which only need to talk SQL take an Executor rather than a connection, so unit
+// tests can run them against a mock driver instead of a live server.
+type Executor interface {
+	Querier
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (Tx, error)
+}
+
+// Session is the connection one resource operation runs on, an Executor which also knows the cluster behind it
+type Session interface {
+	Executor
+	Close() error
+	Dialect(ctx context.Context) (dialect, error)
+	ClusterID(ctx context.Context) (string, error)
+	fingerprint(ctx context.Context, id int64) (types.String, error)
+	appliedStatements(ctx context.Context) (types.List, diag.Diagnostics)
+	cache() *readCache
+}
+
+// Database opens sessions and applies the provider's policies. Resources and data sources hold it rather than the
+// provider's client.
+type Database interface {
+	Connect(ctx context.Context) (Session, error)
+	ConnectForRead(ctx context.Context) (Session, error)
+	checkDeletionProtection(resourceType string, name string, allowDestroy types.Bool) diag.Diagnostics
+}
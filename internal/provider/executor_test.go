@@ -0,0 +1,243 @@
+package provider
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// mockQuery is a statement the mock driver expects and what it answers with
+type mockQuery struct {
+	contains string
+	args     []driver.Value // checked when set
+	columns  []string
+	rows     [][]driver.Value
+	err      error
+}
+
+// mockConnector is a database/sql driver which answers a script of statements in order, so helpers and resources
+// can be unit tested without a live server. Transactions are accepted but not scripted.
+type mockConnector struct {
+	t       *testing.T
+	mu      sync.Mutex
+	queries []mockQuery
+}
+
+// newMockClient returns a client whose connections are served by the mock, failing the test if any scripted
+// statement isn't run
+func newMockClient(t *testing.T, queries ...mockQuery) *CockroachClient {
+	t.Helper()
+
+	m := &mockConnector{t: t, queries: queries}
+	t.Cleanup(func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		for _, q := range m.queries {
+			t.Errorf("expected a statement containing %q", q.contains)
+		}
+	})
+	return &CockroachClient{connector: m}
+}
+
+// newMockConn returns a connection served by the mock, for testing helpers which take an Executor
+func newMockConn(t *testing.T, queries ...mockQuery) *CockroachConn {
+	t.Helper()
+
+	conn, err := newMockClient(t, queries...).connect(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// readDataSource configures a data source with a client served by the mock, reads it with the given attributes set
+// and the rest null, and returns the state as its model M. Values are Go strings, bools, numbers or slices of them.
+func readDataSource[M any](t *testing.T, ds datasource.DataSource, config map[string]any, queries ...mockQuery) M {
+	t.Helper()
+	ctx := context.Background()
+
+	var configureResp datasource.ConfigureResponse
+	ds.(datasource.DataSourceWithConfigure).Configure(ctx, datasource.ConfigureRequest{ProviderData: newMockClient(t, queries...)}, &configureResp)
+	if configureResp.Diagnostics.HasError() {
+		t.Fatal(configureResp.Diagnostics)
+	}
+
+	var schemaResp datasource.SchemaResponse
+	ds.Schema(ctx, datasource.SchemaRequest{}, &schemaResp)
+	objectType := schemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+
+	values := map[string]tftypes.Value{}
+	for name, attributeType := range objectType.AttributeTypes {
+		values[name] = configValue(attributeType, config[name])
+	}
+
+	req := datasource.ReadRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: tftypes.NewValue(objectType, values)}}
+	resp := datasource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: tftypes.NewValue(objectType, nil)}}
+	ds.Read(ctx, req, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatal(resp.Diagnostics)
+	}
+
+	var data M
+	resp.Diagnostics.Append(resp.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		t.Fatal(resp.Diagnostics)
+	}
+	return data
+}
+
+// configValue converts a Go value to a config value of the attribute type, nil for null
+func configValue(attributeType tftypes.Type, value any) tftypes.Value {
+	elements := reflect.ValueOf(value)
+	if value == nil || elements.Kind() != reflect.Slice {
+		return tftypes.NewValue(attributeType, value)
+	}
+
+	var elementType tftypes.Type
+	switch typ := attributeType.(type) {
+	case tftypes.List:
+		elementType = typ.ElementType
+	case tftypes.Set:
+		elementType = typ.ElementType
+	}
+	list := []tftypes.Value{}
+	for i := 0; i < elements.Len(); i++ {
+		list = append(list, tftypes.NewValue(elementType, elements.Index(i).Interface()))
+	}
+	return tftypes.NewValue(attributeType, list)
+}
+
+// next pops the scripted answer for a statement
+func (m *mockConnector) next(query string, args []driver.NamedValue) (mockQuery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.queries) == 0 {
+		m.t.Errorf("unexpected statement %s", query)
+		return mockQuery{}, fmt.Errorf("mock: unexpected statement")
+	}
+	q := m.queries[0]
+	if !strings.Contains(query, q.contains) {
+		m.t.Errorf("expected a statement containing %q, got %s", q.contains, query)
+		return mockQuery{}, fmt.Errorf("mock: unexpected statement")
+	}
+	if q.args != nil {
+		got := make([]driver.Value, len(args))
+		for i, arg := range args {
+			got[i] = arg.Value
+		}
+		if !reflect.DeepEqual(got, q.args) {
+			m.t.Errorf("expected %s to run with %v, got %v", query, q.args, got)
+		}
+	}
+	m.queries = m.queries[1:]
+	return q, q.err
+}
+
+func (m *mockConnector) Connect(context.Context) (driver.Conn, error) {
+	return &mockConn{m}, nil
+}
+
+func (m *mockConnector) Driver() driver.Driver {
+	return mockDriver{m}
+}
+
+type mockDriver struct {
+	connector *mockConnector
+}
+
+func (d mockDriver) Open(string) (driver.Conn, error) {
+	return &mockConn{d.connector}, nil
+}
+
+type mockConn struct {
+	connector *mockConnector
+}
+
+func (c *mockConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("mock: prepared statements are not supported")
+}
+
+func (c *mockConn) Close() error {
+	return nil
+}
+
+func (c *mockConn) Begin() (driver.Tx, error) {
+	return mockTx{}, nil
+}
+
+func (c *mockConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	q, err := c.connector.next(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(len(q.rows)), nil
+}
+
+func (c *mockConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, err := c.connector.next(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &mockRows{columns: q.columns, rows: q.rows}, nil
+}
+
+type mockTx struct{}
+
+func (mockTx) Commit() error   { return nil }
+func (mockTx) Rollback() error { return nil }
+
+type mockRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *mockRows) Columns() []string {
+	return r.columns
+}
+
+func (r *mockRows) Close() error {
+	return nil
+}
+
+func (r *mockRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func TestMockExecutor(t *testing.T) {
+	ctx := context.Background()
+	client := newMockConn(t,
+		mockQuery{contains: "CREATE TABLE"},
+		mockQuery{contains: "SELECT 1", columns: []string{"?column?"}, rows: [][]driver.Value{{int64(1)}}},
+		mockQuery{contains: "DROP TABLE", err: errors.New("permission denied")},
+	)
+
+	if _, err := client.ExecContext(ctx, "CREATE TABLE t (id INT)"); err != nil {
+		t.Fatal(err)
+	}
+
+	var one int64
+	if err := client.QueryRowContext(ctx, "SELECT 1").Scan(&one); err != nil || one != 1 {
+		t.Fatalf("expected 1, got %d, %v", one, err)
+	}
+
+	if _, err := client.ExecContext(ctx, "DROP TABLE t"); err == nil {
+		t.Fatal("expected the scripted error")
+	}
+}
@@ -0,0 +1,355 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/lib/pq"
+	"golang.org/x/exp/slices"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &FunctionResource{}
+var _ resource.ResourceWithImportState = &FunctionResource{}
+
+var volatilitySlice = []string{"immutable", "stable", "volatile"}
+
+func NewFunctionResource() resource.Resource {
+	return &FunctionResource{}
+}
+
+// FunctionResource manages a user-defined SQL function and who may execute it.
+type FunctionResource struct {
+	db Database
+}
+
+// FunctionResourceModel describes the resource data model.
+type FunctionResourceModel struct {
+	Database              identifierValue `tfsdk:"database"`
+	Schema                identifierValue `tfsdk:"schema"`
+	Name                  identifierValue `tfsdk:"name"`
+	Arguments             types.String    `tfsdk:"arguments"`
+	Returns               types.String    `tfsdk:"returns"`
+	Body                  types.String    `tfsdk:"body"`
+	Volatility            types.String    `tfsdk:"volatility"`
+	ExecuteGrantees       types.List      `tfsdk:"execute_grantees"`
+	LastAppliedStatements types.List      `tfsdk:"last_applied_statements"`
+}
+
+// Metadata appends the resource name to the provider name
+func (r *FunctionResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_function"
+}
+
+// Schema is the shape of the resource - what you need to supply
+func (r *FunctionResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "User-defined function written in SQL",
+		Attributes: map[string]schema.Attribute{
+			"database": schema.StringAttribute{
+				CustomType:          identifierType{},
+				MarkdownDescription: "Database of the function",
+				Required:            true,
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"schema": schema.StringAttribute{
+				CustomType:          identifierType{},
+				MarkdownDescription: "Schema of the function, defaults to `public`",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString("public"),
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"name": schema.StringAttribute{
+				CustomType:          identifierType{},
+				MarkdownDescription: "Name of the function",
+				Required:            true,
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"arguments": schema.StringAttribute{
+				MarkdownDescription: "Argument list, e.g. `a INT, b INT`",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString(""),
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"returns": schema.StringAttribute{
+				MarkdownDescription: "Return type, e.g. `INT` or `SETOF RECORD`",
+				Required:            true,
+			},
+			"body": schema.StringAttribute{
+				MarkdownDescription: "SQL statements making up the function body",
+				Required:            true,
+			},
+			"volatility": schema.StringAttribute{
+				MarkdownDescription: "One of immutable, stable or volatile, defaults to `volatile`",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString("volatile"),
+			},
+			"execute_grantees": schema.ListAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: "Roles and users granted EXECUTE on the function",
+				Optional:            true,
+			},
+			"last_applied_statements": lastAppliedStatementsAttribute(),
+		},
+	}
+}
+
+// Configure adds the provider configured client to the resource
+func (r *FunctionResource) Configure(_ context.Context, req resource.ConfigureRequest, _ *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	r.db = req.ProviderData.(Database)
+}
+
+// qualifiedName is database.schema.name of the function
+func (data *FunctionResourceModel) qualifiedName() string {
+	return fmt.Sprintf("%s.%s.%s", pq.QuoteIdentifier(data.Database.ValueString()), pq.QuoteIdentifier(data.Schema.ValueString()), pq.QuoteIdentifier(data.Name.ValueString()))
+}
+
+// signature is the qualified name with its argument list, as needed by GRANT and DROP
+func (data *FunctionResourceModel) signature() string {
+	return fmt.Sprintf("%s(%s)", data.qualifiedName(), data.Arguments.ValueString())
+}
+
+// createStatement renders CREATE [OR REPLACE] FUNCTION for the model
+func (data *FunctionResourceModel) createStatement(replace bool) string {
+	create := "CREATE"
+	if replace {
+		create = "CREATE OR REPLACE"
+	}
+	return fmt.Sprintf("%s FUNCTION %s RETURNS %s LANGUAGE SQL %s AS %s",
+		create,
+		data.signature(),
+		data.Returns.ValueString(),
+		strings.ToUpper(data.Volatility.ValueString()),
+		pq.QuoteLiteral(data.Body.ValueString()),
+	)
+}
+
+// grantees returns the quoted names of the roles that may execute the function
+func (data *FunctionResourceModel) grantees(ctx context.Context) ([]string, error) {
+	var roles []string
+	if diags := data.ExecuteGrantees.ElementsAs(ctx, &roles, false); diags.HasError() {
+		return nil, fmt.Errorf("invalid execute_grantees")
+	}
+	for i, role := range roles {
+		roles[i] = pq.QuoteIdentifier(role)
+	}
+	return roles, nil
+}
+
+// Create is for creating the function resource
+func (r *FunctionResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data *FunctionResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !slices.Contains(volatilitySlice, strings.ToLower(data.Volatility.ValueString())) {
+		resp.Diagnostics.AddError("Invalid volatility", fmt.Sprintf("Unable to set invalid volatility: %s", data.Volatility.ValueString()))
+		return
+	}
+
+	client, err := r.db.Connect(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to connect to cockroach",
+			err.Error(),
+		)
+		return
+	}
+	defer client.Close()
+
+	_, err = client.ExecContext(ctx, data.createStatement(false))
+	if err != nil {
+		resp.Diagnostics.AddError("Create function error", fmt.Sprintf("Unable to create function, got error: %s", err))
+		return
+	}
+
+	grantees, err := data.grantees(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Create function error", err.Error())
+		return
+	}
+	if len(grantees) > 0 {
+		_, err = client.ExecContext(ctx, fmt.Sprintf("GRANT EXECUTE ON FUNCTION %s TO %s", data.signature(), strings.Join(grantees, ", ")))
+		if err != nil {
+			resp.Diagnostics.AddError("Create function error", fmt.Sprintf("Unable to grant execute, got error: %s", err))
+			return
+		}
+	}
+
+	tflog.Trace(ctx, "created a function")
+
+	statements, diags := client.appliedStatements(ctx)
+	resp.Diagnostics.Append(diags...)
+	data.LastAppliedStatements = statements
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Read checks the function still exists
+func (r *FunctionResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data *FunctionResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, err := r.db.ConnectForRead(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to connect to cockroach",
+			err.Error(),
+		)
+		return
+	}
+	defer client.Close()
+
+	q := fmt.Sprintf(
+		"SELECT p.provolatile FROM %[1]s.pg_catalog.pg_proc p JOIN %[1]s.pg_catalog.pg_namespace n ON p.pronamespace = n.oid WHERE n.nspname = $1 AND p.proname = $2",
+		pq.QuoteIdentifier(data.Database.ValueString()),
+	)
+	var volatility string
+	err = client.QueryRowContext(ctx, q, data.Schema.ValueString(), data.Name.ValueString()).Scan(&volatility)
+	if err == sql.ErrNoRows {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Read function error", fmt.Sprintf("Unable to read function, got error: %s", err))
+		return
+	}
+
+	// pg_proc abbreviates the volatility to its first letter
+	for _, v := range volatilitySlice {
+		if strings.HasPrefix(v, volatility) && !strings.EqualFold(data.Volatility.ValueString(), v) {
+			data.Volatility = types.StringValue(v)
+		}
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Update replaces the function body in place and syncs the EXECUTE grants
+func (r *FunctionResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data *FunctionResourceModel
+	var state *FunctionResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !slices.Contains(volatilitySlice, strings.ToLower(data.Volatility.ValueString())) {
+		resp.Diagnostics.AddError("Invalid volatility", fmt.Sprintf("Unable to set invalid volatility: %s", data.Volatility.ValueString()))
+		return
+	}
+
+	client, err := r.db.Connect(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to connect to cockroach",
+			err.Error(),
+		)
+		return
+	}
+	defer client.Close()
+
+	_, err = client.ExecContext(ctx, data.createStatement(true))
+	if err != nil {
+		resp.Diagnostics.AddError("Update function error", fmt.Sprintf("Unable to replace function, got error: %s", err))
+		return
+	}
+
+	if !state.ExecuteGrantees.Equal(data.ExecuteGrantees) {
+		previous, err := state.grantees(ctx)
+		if err == nil && len(previous) > 0 {
+			_, err = client.ExecContext(ctx, fmt.Sprintf("REVOKE EXECUTE ON FUNCTION %s FROM %s", data.signature(), strings.Join(previous, ", ")))
+		}
+		if err != nil {
+			resp.Diagnostics.AddError("Update function error", fmt.Sprintf("Unable to revoke execute, got error: %s", err))
+			return
+		}
+
+		grantees, err := data.grantees(ctx)
+		if err == nil && len(grantees) > 0 {
+			_, err = client.ExecContext(ctx, fmt.Sprintf("GRANT EXECUTE ON FUNCTION %s TO %s", data.signature(), strings.Join(grantees, ", ")))
+		}
+		if err != nil {
+			resp.Diagnostics.AddError("Update function error", fmt.Sprintf("Unable to grant execute, got error: %s", err))
+			return
+		}
+	}
+
+	tflog.Trace(ctx, "updated a function")
+
+	statements, diags := client.appliedStatements(ctx)
+	resp.Diagnostics.Append(diags...)
+	data.LastAppliedStatements = statements
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Delete drops the function
+func (r *FunctionResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data *FunctionResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, err := r.db.Connect(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to connect to cockroach",
+			err.Error(),
+		)
+		return
+	}
+	defer client.Close()
+
+	_, err = client.ExecContext(ctx, fmt.Sprintf("DROP FUNCTION %s", data.signature()))
+	if err != nil {
+		resp.Diagnostics.AddError("Delete function error", fmt.Sprintf("Unable to drop function, got error: %s", err))
+		return
+	}
+
+	tflog.Trace(ctx, "deleted a function")
+}
+
+// ImportState takes an identifier of the form database.schema.name, the arguments and body have to be added to the config
+func (r *FunctionResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	parts := strings.Split(req.ID, ".")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		resp.Diagnostics.AddError(
+			"Unexpected import identifier",
+			fmt.Sprintf("Expected import identifier with format: database.schema.name. Got: %q", req.ID),
+		)
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("database"), parts[0])...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("schema"), parts[1])...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), parts[2])...)
+}
@@ -0,0 +1,200 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/lib/pq"
+)
+
+// lexedSQL is what the server would see in a batch of statements
+type lexedSQL struct {
+	statements  int
+	identifiers []string
+	literals    []string
+}
+
+// lexSQL splits a batch on semicolons outside of quotes and comments and collects the unquoted identifiers and
+// literals. It follows the cockroach lexer for everything the statement builders emit.
+func lexSQL(sql string) (lexedSQL, error) {
+	lexed := lexedSQL{statements: 1}
+	for i := 0; i < len(sql); i++ {
+		switch {
+		case sql[i] == ';':
+			if strings.TrimSpace(sql[i+1:]) != "" {
+				lexed.statements++
+			}
+		case strings.HasPrefix(sql[i:], "--"):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				return lexed, nil
+			}
+			i += end
+		case strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				return lexed, errors.New("unterminated comment")
+			}
+			i += end + 3
+		case sql[i] == '"':
+			value, n, err := lexQuoted(sql[i:], '"', false)
+			if err != nil {
+				return lexed, err
+			}
+			lexed.identifiers = append(lexed.identifiers, value)
+			i += n - 1
+		case sql[i] == '\'':
+			escaped := i > 0 && (sql[i-1] == 'E' || sql[i-1] == 'e') && (i == 1 || !isIdentifierChar(sql[i-2]))
+			value, n, err := lexQuoted(sql[i:], '\'', escaped)
+			if err != nil {
+				return lexed, err
+			}
+			lexed.literals = append(lexed.literals, value)
+			i += n - 1
+		}
+	}
+	return lexed, nil
+}
+
+// lexQuoted reads a quoted token at the start of s, doubled quotes stand for one. Escaped strings also take
+// backslash escapes.
+func lexQuoted(s string, quote byte, escaped bool) (string, int, error) {
+	var value strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch {
+		case escaped && s[i] == '\\':
+			if i+1 == len(s) {
+				return "", 0, errors.New("unterminated escape")
+			}
+			i++
+			value.WriteByte(s[i])
+		case s[i] == quote && i+1 < len(s) && s[i+1] == quote:
+			i++
+			value.WriteByte(quote)
+		case s[i] == quote:
+			return value.String(), i + 1, nil
+		default:
+			value.WriteByte(s[i])
+		}
+	}
+	return "", 0, errors.New("unterminated quote")
+}
+
+func isIdentifierChar(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// quotedIdentifier is what an identifier becomes after quoting, which cuts it at the first NUL byte
+func quotedIdentifier(s string) string {
+	before, _, _ := strings.Cut(s, "\x00")
+	return before
+}
+
+// addInjectionSeeds adds inputs which try to break out of quotes to a fuzz corpus with n string arguments
+func addInjectionSeeds(f *testing.F, n int) {
+	for _, seed := range []string{
+		"app",
+		`"; DROP DATABASE app; --`,
+		`'; DROP DATABASE app; --`,
+		`a"b`,
+		`it's`,
+		`back\slash'`,
+		`\'; SELECT 1; --`,
+		"nul\x00\"; DROP DATABASE app",
+		"/* ; */",
+		"$$; SELECT 1; $$",
+		"line\n-- comment",
+	} {
+		args := make([]any, n)
+		for i := range args {
+			args[i] = seed
+		}
+		f.Add(args...)
+	}
+}
+
+func FuzzQuoteIdentifier(f *testing.F) {
+	addInjectionSeeds(f, 1)
+	f.Fuzz(func(t *testing.T, name string) {
+		lexed, err := lexSQL("DROP USER " + pq.QuoteIdentifier(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lexed.statements != 1 || len(lexed.identifiers) != 1 || lexed.identifiers[0] != quotedIdentifier(name) {
+			t.Errorf("identifier %q escaped its quotes: %+v", name, lexed)
+		}
+	})
+}
+
+func FuzzQuoteLiteral(f *testing.F) {
+	addInjectionSeeds(f, 1)
+	f.Fuzz(func(t *testing.T, password string) {
+		lexed, err := lexSQL("ALTER USER app WITH PASSWORD " + pq.QuoteLiteral(password))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lexed.statements != 1 || len(lexed.literals) != 1 || lexed.literals[0] != password {
+			t.Errorf("literal %q escaped its quotes: %+v", password, lexed)
+		}
+	})
+}
+
+func FuzzCreateUserStatement(f *testing.F) {
+	addInjectionSeeds(f, 3)
+	f.Fuzz(func(t *testing.T, database string, username string, password string) {
+		for statements, adopt := range map[int]bool{2: false, 3: true} {
+			lexed, err := lexSQL(createUserStatement(useDatabase(nil, database), username, password, adopt))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if lexed.statements != statements || len(lexed.literals) != 1 || lexed.literals[0] != password {
+				t.Errorf("create user escaped its quotes: %+v", lexed)
+			}
+			for _, identifier := range lexed.identifiers {
+				if identifier != quotedIdentifier(database) && identifier != quotedIdentifier(username) {
+					t.Errorf("unexpected identifier %q", identifier)
+				}
+			}
+		}
+	})
+}
+
+func FuzzUserGrantStatements(f *testing.F) {
+	addInjectionSeeds(f, 3)
+	f.Fuzz(func(t *testing.T, database string, schema string, username string) {
+		privileges, _ := types.SetValueFrom(context.Background(), types.StringType, []string{"select"})
+		g := userGrantModel{Database: types.StringValue(database), Schema: types.StringValue(schema), Privileges: privileges}
+
+		// The default privileges are granted after switching to the database
+		d := dialect{version: serverVersion{Major: 23, Minor: 2}}
+		use := useDatabase(nil, database)
+		for _, statements := range [][]string{g.grantStatements(d, use, username), g.revokeStatements(d, use, username)} {
+			for i, statement := range statements {
+				lexed, err := lexSQL(statement)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if lexed.statements != i+1 || len(lexed.literals) != 0 {
+					t.Errorf("grant statement %q escaped its quotes: %+v", statement, lexed)
+				}
+			}
+		}
+	})
+}
+
+func FuzzShowCreateStatement(f *testing.F) {
+	addInjectionSeeds(f, 2)
+	f.Fuzz(func(t *testing.T, database string, name string) {
+		m := ShowCreateDataSourceModel{ObjectType: types.StringValue("table"), Name: types.StringValue(name), Database: types.StringValue(database)}
+		lexed, err := lexSQL(m.showCreateStatement())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lexed.statements != 1 || len(lexed.literals) != 0 {
+			t.Errorf("show create escaped its quotes: %+v", lexed)
+		}
+	})
+}
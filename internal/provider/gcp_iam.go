@@ -0,0 +1,129 @@
+package provider
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/lib/pq"
+)
+
+const (
+	defaultMetadataHost = "metadata.google.internal"
+
+	// gcpTokenMargin refreshes tokens this long before they expire, so a token never runs out during the handshake
+	gcpTokenMargin = time.Minute
+)
+
+// gcpIAMModel configures logins with a Google access token as the password
+type gcpIAMModel struct {
+	ServiceAccount types.String `tfsdk:"service_account"`
+}
+
+// gcpTokenSource hands out Google access tokens, fetching a new one when the last is about to expire. Tokens come from
+// the GOOGLE_OAUTH_ACCESS_TOKEN environment variable if set, otherwise from the metadata server, which serves the
+// workload identity of the pod on GKE.
+type gcpTokenSource struct {
+	ServiceAccount string
+	MetadataHost   string
+	HTTPClient     *http.Client
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+	now    func() time.Time
+}
+
+// newGCPTokenSource reads the token source from the model, the metadata server host can be moved with GCE_METADATA_HOST
+func newGCPTokenSource(g *gcpIAMModel) *gcpTokenSource {
+	source := &gcpTokenSource{
+		ServiceAccount: g.ServiceAccount.ValueString(),
+		MetadataHost:   os.Getenv("GCE_METADATA_HOST"),
+		HTTPClient:     &http.Client{Timeout: 10 * time.Second},
+		now:            time.Now,
+	}
+	if source.ServiceAccount == "" {
+		source.ServiceAccount = "default"
+	}
+	if source.MetadataHost == "" {
+		source.MetadataHost = defaultMetadataHost
+	}
+	return source
+}
+
+// Token returns a token valid for at least gcpTokenMargin
+func (s *gcpTokenSource) Token(ctx context.Context) (string, error) {
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		return token, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && s.now().Add(gcpTokenMargin).Before(s.expiry) {
+		return s.token, nil
+	}
+
+	endpoint := fmt.Sprintf("http://%s/computeMetadata/v1/instance/service-accounts/%s/token", s.MetadataHost, url.PathEscape(s.ServiceAccount))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	res, err := s.HTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("unable to reach the metadata server, is the workload identity set up? %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("metadata server returned %s for service account %s", res.Status, s.ServiceAccount)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("unable to decode the token of the metadata server: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("metadata server returned no token for service account %s", s.ServiceAccount)
+	}
+
+	s.token = token.AccessToken
+	s.expiry = s.now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	return s.token, nil
+}
+
+// tokenConnector opens every connection with a current token as the password, pooled connections outlive the token
+// they were opened with
+type tokenConnector struct {
+	client *CockroachClient
+	params string
+}
+
+// Connect fetches a token and connects with it
+func (t tokenConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	token, err := t.client.Tokens.Token(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get a Google access token: %w", err)
+	}
+	connector, err := t.client.pqConnector(t.params + "&password=" + url.QueryEscape(token))
+	if err != nil {
+		return nil, err
+	}
+	return connector.Connect(ctx)
+}
+
+// Driver returns the pq driver
+func (t tokenConnector) Driver() driver.Driver {
+	return &pq.Driver{}
+}
@@ -0,0 +1,56 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestGCPTokenSource(t *testing.T) {
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "")
+
+	fetched := 0
+	metadata := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" || r.URL.Path != "/computeMetadata/v1/instance/service-accounts/default/token" {
+			http.NotFound(w, r)
+			return
+		}
+		fetched++
+		fmt.Fprintf(w, `{"access_token":"token-%d","expires_in":3600,"token_type":"Bearer"}`, fetched)
+	}))
+	defer metadata.Close()
+	t.Setenv("GCE_METADATA_HOST", strings.TrimPrefix(metadata.URL, "http://"))
+
+	now := time.Now()
+	source := newGCPTokenSource(&gcpIAMModel{ServiceAccount: types.StringNull()})
+	source.now = func() time.Time { return now }
+
+	for _, want := range []string{"token-1", "token-1"} {
+		if token, err := source.Token(context.Background()); err != nil || token != want {
+			t.Fatalf("Token() = %q, %v, want %q", token, err, want)
+		}
+	}
+
+	// Close to the expiry a new token is fetched
+	now = now.Add(time.Hour - 30*time.Second)
+	if token, err := source.Token(context.Background()); err != nil || token != "token-2" {
+		t.Fatalf("expected a refreshed token, got %q, %v", token, err)
+	}
+
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "from-env")
+	if token, err := source.Token(context.Background()); err != nil || token != "from-env" {
+		t.Fatalf("expected the environment token, got %q, %v", token, err)
+	}
+
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "")
+	source = newGCPTokenSource(&gcpIAMModel{ServiceAccount: types.StringValue("deployer@project.iam.gserviceaccount.com")})
+	if _, err := source.Token(context.Background()); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("expected the missing service account to fail, got %v", err)
+	}
+}
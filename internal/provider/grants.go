@@ -0,0 +1,211 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/lib/pq"
+	"golang.org/x/exp/slices"
+)
+
+// grantRow is a single row of SHOW GRANTS FOR, schema and relation are null for database level grants
+type grantRow struct {
+	Database  sql.NullString
+	Schema    sql.NullString
+	Relation  sql.NullString
+	Privilege string
+}
+
+// object names the granted object, e.g. app.public.orders
+func (g grantRow) object() string {
+	parts := []string{g.Database.String}
+	if g.Schema.Valid {
+		parts = append(parts, g.Schema.String)
+	}
+	if g.Relation.Valid {
+		parts = append(parts, g.Relation.String)
+	}
+	return strings.Join(parts, ".")
+}
+
+// target is the object as used in GRANT and REVOKE statements
+func (g grantRow) target() string {
+	switch {
+	case g.Relation.Valid:
+		return "TABLE " + pq.QuoteIdentifier(g.Database.String) + "." + pq.QuoteIdentifier(g.Schema.String) + "." + pq.QuoteIdentifier(g.Relation.String)
+	case g.Schema.Valid:
+		return "SCHEMA " + pq.QuoteIdentifier(g.Database.String) + "." + pq.QuoteIdentifier(g.Schema.String)
+	default:
+		return "DATABASE " + pq.QuoteIdentifier(g.Database.String)
+	}
+}
+
+// showGrants lists the privileges a user holds in a database
+func showGrants(ctx context.Context, client Executor, database string, username string) ([]grantRow, error) {
+	// Connections of the provider share the result through the read cache
+	if session, ok := client.(Session); ok && session.cache() != nil {
+		return session.cache().showGrants(ctx, client, database, username)
+	}
+	return queryGrants(ctx, client, database, username)
+}
+
+// queryGrants runs SHOW GRANTS FOR the user in the database
+func queryGrants(ctx context.Context, client Executor, database string, username string) ([]grantRow, error) {
+	rows, err := client.QueryContext(idempotent(ctx), useDatabase(client, database)+fmt.Sprintf("SHOW GRANTS FOR %s", pq.QuoteIdentifier(username)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	grants := []grantRow{}
+	for rows.Next() {
+		var g grantRow
+		var grantee string
+		var grantable bool
+		if err := rows.Scan(&g.Database, &g.Schema, &g.Relation, &grantee, &g.Privilege, &grantable); err != nil {
+			return nil, err
+		}
+		grants = append(grants, g)
+	}
+	return grants, rows.Err()
+}
+
+// effectiveGrants summarizes the grants of a user as object → comma separated privileges, for review in plans
+func effectiveGrants(ctx context.Context, client Executor, database string, username string) (types.Map, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	grants, err := showGrants(ctx, client, database, username)
+	if err != nil {
+		diags.AddError("Read grants error", fmt.Sprintf("Unable to read grants, got error: %s", err))
+		return types.MapNull(types.StringType), diags
+	}
+
+	privileges := map[string][]string{}
+	for _, g := range grants {
+		privileges[g.object()] = append(privileges[g.object()], strings.ToUpper(g.Privilege))
+	}
+
+	summary := map[string]string{}
+	for object, p := range privileges {
+		sort.Strings(p)
+		summary[object] = strings.Join(p, ", ")
+	}
+
+	m, d := types.MapValueFrom(ctx, types.StringType, summary)
+	diags.Append(d...)
+	return m, diags
+}
+
+// defaultPrivilegesScope is the FOR and IN SCHEMA part of ALTER DEFAULT PRIVILEGES, all roles in all schemas unless
+// narrowed to the tables one role creates or to some schemas
+func defaultPrivilegesScope(forRole string, inSchemas []string) string {
+	scope := "FOR ALL ROLES"
+	if forRole != "" {
+		scope = "FOR ROLE " + pq.QuoteIdentifier(forRole)
+	}
+	if len(inSchemas) > 0 {
+		quoted := []string{}
+		for _, schema := range inSchemas {
+			quoted = append(quoted, pq.QuoteIdentifier(schema))
+		}
+		scope += " IN SCHEMA " + strings.Join(quoted, ", ")
+	}
+	return scope
+}
+
+// schemaGrantees lists the roles holding a privilege on a schema
+func schemaGrantees(ctx context.Context, client Executor, database string, schema string, privilege string) ([]string, error) {
+	rows, err := client.QueryContext(ctx, fmt.Sprintf("SELECT grantee FROM [SHOW GRANTS ON SCHEMA %s.%s] WHERE privilege_type = $1 ORDER BY grantee", pq.QuoteIdentifier(database), pq.QuoteIdentifier(schema)), privilege)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	grantees := []string{}
+	for rows.Next() {
+		var grantee string
+		if err := rows.Scan(&grantee); err != nil {
+			return nil, err
+		}
+		grantees = append(grantees, grantee)
+	}
+	return grantees, rows.Err()
+}
+
+// roleName quotes a role for GRANT and REVOKE, except the public pseudo-role which only works as a keyword
+func roleName(role string) string {
+	if strings.EqualFold(role, "public") {
+		return "public"
+	}
+	return pq.QuoteIdentifier(role)
+}
+
+// applyExternalConnectionGrants grants or revokes USAGE on each external connection, e.g. changefeed sinks
+func applyExternalConnectionGrants(ctx context.Context, client Executor, username string, connections types.Set, revoke bool) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	names := []string{}
+	if !connections.IsNull() && !connections.IsUnknown() {
+		diags.Append(connections.ElementsAs(ctx, &names, false)...)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		statement := fmt.Sprintf("GRANT USAGE ON EXTERNAL CONNECTION %s TO %s", pq.QuoteIdentifier(name), pq.QuoteIdentifier(username))
+		if revoke {
+			statement = fmt.Sprintf("REVOKE USAGE ON EXTERNAL CONNECTION %s FROM %s", pq.QuoteIdentifier(name), pq.QuoteIdentifier(username))
+		}
+		if _, err := client.ExecContext(ctx, statement); err != nil {
+			diags.AddError("External connection grant error", fmt.Sprintf("Unable to run %s, got error: %s", statement, err))
+			return diags
+		}
+	}
+	return diags
+}
+
+// removedElements is the set of strings in previous but no longer in current
+func removedElements(ctx context.Context, previous types.Set, current types.Set) (types.Set, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	before, after := []string{}, []string{}
+	if !previous.IsNull() && !previous.IsUnknown() {
+		diags.Append(previous.ElementsAs(ctx, &before, false)...)
+	}
+	if !current.IsNull() && !current.IsUnknown() {
+		diags.Append(current.ElementsAs(ctx, &after, false)...)
+	}
+
+	removed := []string{}
+	for _, element := range before {
+		if !slices.Contains(after, element) {
+			removed = append(removed, element)
+		}
+	}
+	set, d := types.SetValueFrom(ctx, types.StringType, removed)
+	diags.Append(d...)
+	return set, diags
+}
+
+// externalConnectionGrants lists the external connections the user has USAGE on
+func externalConnectionGrants(ctx context.Context, client Executor, username string) ([]string, error) {
+	rows, err := client.QueryContext(ctx, "SELECT substr(path, length('/externalconn/') + 1) FROM system.privileges WHERE username = $1 AND path LIKE '/externalconn/%' AND 'USAGE' = ANY(privileges) ORDER BY path", username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
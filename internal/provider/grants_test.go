@@ -0,0 +1,110 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestGrantRowObject(t *testing.T) {
+	valid := func(s string) sql.NullString { return sql.NullString{String: s, Valid: true} }
+
+	for _, tc := range []struct {
+		grant  grantRow
+		object string
+		target string
+	}{
+		{grantRow{Database: valid("app")}, "app", `DATABASE "app"`},
+		{grantRow{Database: valid("app"), Schema: valid("public")}, "app.public", `SCHEMA "app"."public"`},
+		{grantRow{Database: valid("app"), Schema: valid("public"), Relation: valid("orders")}, "app.public.orders", `TABLE "app"."public"."orders"`},
+	} {
+		if object := tc.grant.object(); object != tc.object {
+			t.Errorf("expected object %s, got %s", tc.object, object)
+		}
+		if target := tc.grant.target(); target != tc.target {
+			t.Errorf("expected target %s, got %s", tc.target, target)
+		}
+	}
+}
+
+func TestDefaultPrivilegesScope(t *testing.T) {
+	for _, tc := range []struct {
+		forRole   string
+		inSchemas []string
+		expected  string
+	}{
+		{"", nil, "FOR ALL ROLES"},
+		{"migrator", nil, `FOR ROLE "migrator"`},
+		{"", []string{"reporting"}, `FOR ALL ROLES IN SCHEMA "reporting"`},
+		{"migrator", []string{"public", "Audit"}, `FOR ROLE "migrator" IN SCHEMA "public", "Audit"`},
+	} {
+		if scope := defaultPrivilegesScope(tc.forRole, tc.inSchemas); scope != tc.expected {
+			t.Errorf("expected %s, got %s", tc.expected, scope)
+		}
+	}
+}
+
+func TestEffectiveGrants(t *testing.T) {
+	ctx := context.Background()
+	client := newMockConn(t, mockQuery{
+		contains: `SHOW GRANTS FOR "reporter"`,
+		columns:  []string{"database_name", "schema_name", "relation_name", "grantee", "privilege_type", "is_grantable"},
+		rows: [][]driver.Value{
+			{"app", nil, nil, "reporter", "CONNECT", false},
+			{"app", "public", "orders", "reporter", "SELECT", false},
+			{"app", "public", "orders", "reporter", "INSERT", false},
+		},
+	})
+
+	grants, diags := effectiveGrants(ctx, client, "app", "reporter")
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	summary := map[string]string{}
+	diags = grants.ElementsAs(ctx, &summary, false)
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	if len(summary) != 2 || summary["app"] != "CONNECT" || summary["app.public.orders"] != "INSERT, SELECT" {
+		t.Errorf("unexpected grants %v", summary)
+	}
+}
+
+func TestExternalConnectionGrants(t *testing.T) {
+	ctx := context.Background()
+	connections, diags := types.SetValueFrom(ctx, types.StringType, []string{"kafka", "audit_bucket"})
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	client := newMockConn(t,
+		mockQuery{contains: `GRANT USAGE ON EXTERNAL CONNECTION "audit_bucket" TO "feeds"`},
+		mockQuery{contains: `GRANT USAGE ON EXTERNAL CONNECTION "kafka" TO "feeds"`},
+		mockQuery{contains: `REVOKE USAGE ON EXTERNAL CONNECTION "audit_bucket" FROM "feeds"`},
+		mockQuery{contains: `REVOKE USAGE ON EXTERNAL CONNECTION "kafka" FROM "feeds"`},
+		mockQuery{
+			contains: "/externalconn/",
+			columns:  []string{"substr"},
+			rows:     [][]driver.Value{{"kafka"}},
+		},
+	)
+
+	if diags := applyExternalConnectionGrants(ctx, client, "feeds", connections, false); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if diags := applyExternalConnectionGrants(ctx, client, "feeds", connections, true); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	names, err := externalConnectionGrants(ctx, client, "feeds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 || names[0] != "kafka" {
+		t.Errorf("unexpected external connections %v", names)
+	}
+}
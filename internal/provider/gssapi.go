@@ -0,0 +1,70 @@
+package provider
+
+import (
+	"net/url"
+	"os"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/lib/pq"
+)
+
+// gssProvider creates the GSSAPI implementation lib/pq authenticates with. lib/pq doesn't ship one, a build which
+// links a Kerberos library sets it from an init function.
+var gssProvider pq.NewGSSFunc
+
+// gssapiModel configures Kerberos logins
+type gssapiModel struct {
+	ServiceName types.String `tfsdk:"service_name"`
+	SPN         types.String `tfsdk:"spn"`
+	CCachePath  types.String `tfsdk:"ccache_path"`
+	KeytabPath  types.String `tfsdk:"keytab_path"`
+	ConfigPath  types.String `tfsdk:"config_path"`
+}
+
+// configureGSSAPI registers the GSSAPI implementation and points the Kerberos library at the configured files
+func configureGSSAPI(g *gssapiModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if gssProvider == nil {
+		diags.AddAttributeError(
+			path.Root("gssapi"),
+			"GSSAPI not supported",
+			"This build of the provider doesn't include a GSSAPI implementation, so it can't log in with Kerberos. Use password authentication or a build with Kerberos support.",
+		)
+		return diags
+	}
+	pq.RegisterGSSProvider(gssProvider)
+
+	// Kerberos libraries read their files from the environment
+	files := map[string]string{"KRB5_CONFIG": g.ConfigPath.ValueString(), "KRB5_CLIENT_KTNAME": g.KeytabPath.ValueString()}
+	if g.CCachePath.ValueString() != "" {
+		files["KRB5CCNAME"] = "FILE:" + g.CCachePath.ValueString()
+	}
+	for env, value := range files {
+		if value == "" {
+			continue
+		}
+		if err := os.Setenv(env, value); err != nil {
+			diags.AddAttributeError(path.Root("gssapi"), "GSSAPI configuration error", err.Error())
+		}
+	}
+	return diags
+}
+
+// gssapiParams are the connection string parameters selecting the Kerberos principal of the server
+func gssapiParams(g *gssapiModel) string {
+	if g == nil {
+		return ""
+	}
+
+	params := ""
+	if g.ServiceName.ValueString() != "" {
+		params += "&krbsrvname=" + url.QueryEscape(g.ServiceName.ValueString())
+	}
+	if g.SPN.ValueString() != "" {
+		params += "&krbspn=" + url.QueryEscape(g.SPN.ValueString())
+	}
+	return params
+}
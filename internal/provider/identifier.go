@@ -0,0 +1,129 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/lib/pq"
+)
+
+// Ensure the identifier types satisfy the framework interfaces for semantic equality.
+var _ basetypes.StringTypable = identifierType{}
+var _ basetypes.StringValuableWithSemanticEquals = identifierValue{}
+
+// identifierType is a string holding a SQL identifier. Cockroach folds unquoted identifiers to lowercase, so MyDB in
+// the config and mydb in the cluster are the same database and must not show up as a diff. Quote the identifier in the
+// config, e.g. "\"MyDB\"", to keep its case.
+type identifierType struct {
+	basetypes.StringType
+}
+
+func (t identifierType) Equal(o attr.Type) bool {
+	other, ok := o.(identifierType)
+	if !ok {
+		return false
+	}
+	return t.StringType.Equal(other.StringType)
+}
+
+func (t identifierType) String() string {
+	return "identifierType"
+}
+
+func (t identifierType) ValueFromString(ctx context.Context, in basetypes.StringValue) (basetypes.StringValuable, diag.Diagnostics) {
+	return identifierValue{StringValue: in}, nil
+}
+
+func (t identifierType) ValueFromTerraform(ctx context.Context, in tftypes.Value) (attr.Value, error) {
+	attrValue, err := t.StringType.ValueFromTerraform(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	stringValue, ok := attrValue.(basetypes.StringValue)
+	if !ok {
+		return nil, fmt.Errorf("unexpected value type of %T", attrValue)
+	}
+
+	stringValuable, diags := t.ValueFromString(ctx, stringValue)
+	if diags.HasError() {
+		return nil, fmt.Errorf("unexpected error converting StringValue to StringValuable: %v", diags)
+	}
+	return stringValuable, nil
+}
+
+func (t identifierType) ValueType(ctx context.Context) attr.Value {
+	return identifierValue{}
+}
+
+// identifierValue is the value of an identifierType attribute
+type identifierValue struct {
+	basetypes.StringValue
+}
+
+// newIdentifierValue wraps an identifier as written in a config, quoted or not
+func newIdentifierValue(value string) identifierValue {
+	return identifierValue{StringValue: basetypes.NewStringValue(value)}
+}
+
+// exactIdentifierValue wraps a name read back from the cluster, quoting it where cockroach would otherwise fold it
+func exactIdentifierValue(name string) identifierValue {
+	if name != strings.ToLower(name) {
+		name = `"` + name + `"`
+	}
+	return newIdentifierValue(name)
+}
+
+// ValueString is the identifier as cockroach resolves it, use it for statements and lookups alike
+func (v identifierValue) ValueString() string {
+	return normalizeIdentifier(v.StringValue.ValueString())
+}
+
+// String quotes the resolved identifier for statements
+func (v identifierValue) String() string {
+	if v.IsNull() || v.IsUnknown() {
+		return v.StringValue.String()
+	}
+	return pq.QuoteIdentifier(v.ValueString())
+}
+
+func (v identifierValue) Equal(o attr.Value) bool {
+	other, ok := o.(identifierValue)
+	if !ok {
+		return false
+	}
+	return v.StringValue.Equal(other.StringValue)
+}
+
+func (v identifierValue) Type(ctx context.Context) attr.Type {
+	return identifierType{}
+}
+
+// StringSemanticEquals compares identifiers the way cockroach resolves them
+func (v identifierValue) StringSemanticEquals(ctx context.Context, newValuable basetypes.StringValuable) (bool, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	newValue, ok := newValuable.(identifierValue)
+	if !ok {
+		diags.AddError(
+			"Semantic Equality Check Error",
+			fmt.Sprintf("Expected value type %T but got value type %T. Please report this issue to the provider developers.", v, newValuable),
+		)
+		return false, diags
+	}
+
+	return v.ValueString() == newValue.ValueString(), diags
+}
+
+// normalizeIdentifier folds an unquoted identifier to lowercase and strips the double quotes around a quoted one
+func normalizeIdentifier(s string) string {
+	if len(s) >= 2 && strings.HasPrefix(s, `"`) && strings.HasSuffix(s, `"`) {
+		return s[1 : len(s)-1]
+	}
+	return strings.ToLower(s)
+}
@@ -0,0 +1,55 @@
+package provider
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"testing"
+)
+
+func TestIdentifierSemanticEquals(t *testing.T) {
+	for _, tc := range []struct {
+		config, cluster string
+		equal           bool
+	}{
+		{"MyDB", "mydb", true},
+		{"MYDB", "mydb", true},
+		{`"MyDB"`, "MyDB", true},
+		{`"MyDB"`, "mydb", false},
+		{"MyDB", "MyDB", false},
+		{"mydb", "otherdb", false},
+	} {
+		equal, diags := newIdentifierValue(tc.config).StringSemanticEquals(context.Background(), exactIdentifierValue(tc.cluster))
+		if diags.HasError() {
+			t.Fatalf("unexpected error comparing %s and %s: %v", tc.config, tc.cluster, diags)
+		}
+		if equal != tc.equal {
+			t.Errorf("expected %s and %s equal to be %v", tc.config, tc.cluster, tc.equal)
+		}
+	}
+}
+
+func TestIdentifierValueSQL(t *testing.T) {
+	for config, want := range map[string]string{
+		"MyUser":   "myuser",
+		`"MyUser"`: "MyUser",
+		"my user":  "my user",
+	} {
+		value := newIdentifierValue(config)
+		if value.ValueString() != want {
+			t.Errorf("expected %s to resolve to %s, got %s", config, want, value.ValueString())
+		}
+
+		// Statements quote the resolved name and lookups use it as is, so both find the same role
+		client := newMockConn(t,
+			mockQuery{contains: fmt.Sprintf(`CREATE USER "%s"`, want)},
+			mockQuery{contains: "FROM [SHOW USERS]", args: []driver.Value{want}, columns: []string{"options"}, rows: [][]driver.Value{{""}}},
+		)
+		if _, err := client.ExecContext(context.Background(), fmt.Sprintf("CREATE USER %s", value)); err != nil {
+			t.Fatal(err)
+		}
+		if diags := verifyUser(context.Background(), client, value.ValueString(), nil); diags.HasError() {
+			t.Errorf("unexpected error verifying %s: %v", config, diags)
+		}
+	}
+}
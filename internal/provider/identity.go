@@ -0,0 +1,73 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource/identityschema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// databaseIdentityModel identifies a database by its name on a cluster, so a move between workspaces can't point it at
+// another cluster
+type databaseIdentityModel struct {
+	Name      types.String `tfsdk:"name"`
+	ClusterID types.String `tfsdk:"cluster_id"`
+}
+
+var databaseIdentitySchema = identityschema.Schema{
+	Attributes: map[string]identityschema.Attribute{
+		"name": identityschema.StringAttribute{
+			Description:       "Name of the database, including the suffix of a temporary database",
+			RequiredForImport: true,
+		},
+		"cluster_id": identityschema.StringAttribute{
+			Description:       "Id of the cluster the database lives in. Imports check it against the connected cluster when set",
+			OptionalForImport: true,
+		},
+	},
+}
+
+// userIdentityModel identifies a user by its username and the database its privileges are managed in
+type userIdentityModel struct {
+	Database types.String `tfsdk:"database"`
+	Username types.String `tfsdk:"username"`
+}
+
+var userIdentitySchema = identityschema.Schema{
+	Attributes: map[string]identityschema.Attribute{
+		"database": identityschema.StringAttribute{
+			Description:       "Database to which the user belongs",
+			RequiredForImport: true,
+		},
+		"username": identityschema.StringAttribute{
+			Description:       "Name of the user, including the suffix of a temporary user",
+			RequiredForImport: true,
+		},
+	},
+}
+
+// environmentFingerprint ties an object to the cluster it lives in, e.g. 9b1c.../104. It changes when the object is
+// recreated or the state is applied against another cluster, which promotion pipelines can check before applying.
+func environmentFingerprint(clusterID string, id int64) string {
+	return fmt.Sprintf("%s/%d", clusterID, id)
+}
+
+// fingerprint is the environment fingerprint of the object with the given id on the connected cluster
+func (c *CockroachConn) fingerprint(ctx context.Context, id int64) (types.String, error) {
+	clusterID, err := c.ClusterID(ctx)
+	if err != nil {
+		return types.StringNull(), err
+	}
+	return types.StringValue(environmentFingerprint(clusterID, id)), nil
+}
+
+// setIdentity stores the identity of a resource. Terraform before 1.12 doesn't know identities and sends none.
+func setIdentity(ctx context.Context, identity *tfsdk.ResourceIdentity, model any) diag.Diagnostics {
+	if identity == nil {
+		return nil
+	}
+	return identity.Set(ctx, model)
+}
@@ -0,0 +1,132 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// activeJobStatuses are the statuses of jobs which still act on their targets
+const activeJobStatuses = `'running', 'paused', 'pending', 'pause-requested', 'reverting'`
+
+// schemaChangeJobTypes are the job types of DDL cockroach runs asynchronously, e.g. ALTER TABLE or CREATE INDEX
+const schemaChangeJobTypes = `'SCHEMA CHANGE', 'NEW SCHEMA CHANGE'`
+
+// Defaults of the create and update timeouts of resources waiting for schema changes
+const defaultSchemaChangeTimeout = 20 * time.Minute
+
+// schemaChangePollInterval is how often schema change jobs are checked while waiting
+var schemaChangePollInterval = 2 * time.Second
+
+// jobRow is a single row of SHOW JOBS
+type jobRow struct {
+	ID          int64
+	Type        string
+	Description string
+}
+
+func (j jobRow) String() string {
+	return fmt.Sprintf("%d %s: %s", j.ID, j.Type, j.Description)
+}
+
+// jobTargetPattern matches job descriptions naming the database, e.g. BACKUP DATABASE app, or an object in it, e.g.
+// CREATE CHANGEFEED FOR TABLE app.public.orders, but not a path like gs://backups/app.bak. Cockroach evaluates it
+// with the same regexp syntax as Go.
+func jobTargetPattern(database string) string {
+	name := `"?` + regexp.QuoteMeta(database) + `"?`
+	return `(^|[^\w."/])` + name + `\.|DATABASE\s+` + name + `(\W|$)`
+}
+
+// activeJobsOn lists the unfinished jobs whose description targets the database
+func activeJobsOn(ctx context.Context, client Executor, database string) ([]jobRow, error) {
+	rows, err := client.QueryContext(ctx, "SELECT job_id, job_type, description FROM [SHOW JOBS] WHERE status IN ("+activeJobStatuses+") AND description ~* $1 ORDER BY job_id", jobTargetPattern(database))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []jobRow{}
+	for rows.Next() {
+		var j jobRow
+		if err := rows.Scan(&j.ID, &j.Type, &j.Description); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+// schemaChangeMark is the cluster time before running DDL, waitForSchemaChanges only considers jobs created after it
+func schemaChangeMark(ctx context.Context, client Executor) (time.Time, error) {
+	var mark time.Time
+	err := client.QueryRowContext(ctx, "SELECT now()").Scan(&mark)
+	return mark, err
+}
+
+// waitForSchemaChanges blocks until the schema change jobs on the database created since the mark have finished,
+// logging their progress. A failed or canceled job is an error, as is the context expiring first.
+func waitForSchemaChanges(ctx context.Context, client Executor, database string, since time.Time) error {
+	for {
+		rows, err := client.QueryContext(ctx, "SELECT job_id, status, coalesce(fraction_completed, 0), coalesce(error, '') FROM [SHOW JOBS] WHERE job_type IN ("+schemaChangeJobTypes+") AND created >= $1 AND description ~* $2 ORDER BY job_id", since, jobTargetPattern(database))
+		if err != nil {
+			return err
+		}
+
+		var pending *jobProgress
+		for rows.Next() {
+			var p jobProgress
+			if err := rows.Scan(&p.ID, &p.Status, &p.FractionCompleted, &p.Error); err != nil {
+				rows.Close()
+				return err
+			}
+			switch p.Status {
+			case "succeeded":
+			case "failed", "canceled":
+				rows.Close()
+				return fmt.Errorf("schema change job %d %s: %s", p.ID, p.Status, p.Error)
+			default:
+				if pending == nil {
+					pending = &p
+				}
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if pending == nil {
+			return nil
+		}
+
+		tflog.Info(ctx, "waiting for schema change job", map[string]interface{}{"job_id": pending.ID, "status": pending.Status, "fraction_completed": pending.FractionCompleted})
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for schema change job %d, %.0f%% done: %w", pending.ID, pending.FractionCompleted*100, ctx.Err())
+		case <-time.After(schemaChangePollInterval):
+		}
+	}
+}
+
+// awaitSchemaChanges waits up to the timeout for the schema changes started on the database since the mark
+func awaitSchemaChanges(ctx context.Context, client Executor, database string, mark time.Time, timeout time.Duration) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if err := waitForSchemaChanges(ctx, client, database, mark); err != nil {
+		diags.AddError("Schema change error", fmt.Sprintf("Unable to complete the schema changes of %s, got error: %s", database, err))
+	}
+	return diags
+}
+
+// jobProgress is the state of a job being waited on
+type jobProgress struct {
+	ID                int64
+	Status            string
+	FractionCompleted float64
+	Error             string
+}
@@ -0,0 +1,62 @@
+package provider
+
+import (
+	"context"
+	"database/sql/driver"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJobTargetPattern(t *testing.T) {
+	pattern := regexp.MustCompile("(?i)" + jobTargetPattern("app"))
+
+	for description, targets := range map[string]bool{
+		"CREATE CHANGEFEED FOR TABLE app.public.orders INTO 'kafka://broker'": true,
+		`ALTER TABLE "app".public.orders ADD COLUMN note STRING`:              true,
+		"BACKUP DATABASE app INTO 'gs://backups'":                             true,
+		"BACKUP DATABASE app, billing INTO 'gs://backups'":                    true,
+		"BACKUP DATABASE apps INTO 'gs://backups'":                            false,
+		"CREATE CHANGEFEED FOR TABLE myapp.public.orders":                     false,
+		"BACKUP DATABASE billing INTO 'gs://backups/app.bak'":                 false,
+	} {
+		if pattern.MatchString(description) != targets {
+			t.Errorf("expected %q to target app: %t", description, targets)
+		}
+	}
+}
+
+func TestWaitForSchemaChanges(t *testing.T) {
+	defer func(interval time.Duration) { schemaChangePollInterval = interval }(schemaChangePollInterval)
+	schemaChangePollInterval = time.Millisecond
+
+	columns := []string{"job_id", "status", "fraction_completed", "error"}
+	mark := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	client := newMockConn(t,
+		mockQuery{contains: "FROM [SHOW JOBS]", columns: columns, rows: [][]driver.Value{{int64(7), "running", 0.5, ""}}},
+		mockQuery{contains: "FROM [SHOW JOBS]", columns: columns, rows: [][]driver.Value{{int64(7), "succeeded", 1.0, ""}}},
+	)
+	if err := waitForSchemaChanges(context.Background(), client, "app", mark); err != nil {
+		t.Fatal(err)
+	}
+
+	client = newMockConn(t,
+		mockQuery{contains: "FROM [SHOW JOBS]", columns: columns, rows: [][]driver.Value{{int64(8), "failed", 0.2, "duplicate key value"}}},
+	)
+	if err := waitForSchemaChanges(context.Background(), client, "app", mark); err == nil || !strings.Contains(err.Error(), "duplicate key value") {
+		t.Errorf("expected the job error, got %v", err)
+	}
+
+	// The job keeps running past the deadline
+	schemaChangePollInterval = time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	client = newMockConn(t,
+		mockQuery{contains: "FROM [SHOW JOBS]", columns: columns, rows: [][]driver.Value{{int64(9), "running", 0.25, ""}}},
+	)
+	if err := waitForSchemaChanges(ctx, client, "app", mark); err == nil || !strings.Contains(err.Error(), "25% done") {
+		t.Errorf("expected a timeout, got %v", err)
+	}
+}
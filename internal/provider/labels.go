@@ -0,0 +1,147 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/lib/pq"
+)
+
+// Labels live in a provider managed table, created the first time a resource sets labels
+const (
+	metadataDatabase = "cockroachgke_metadata"
+	labelsTable      = metadataDatabase + ".public.labels"
+
+	labelObjectDatabase = "database"
+	labelObjectUser     = "user"
+
+	// reservedLabelPrefix marks keys the provider writes for itself, they're hidden from the labels attribute
+	reservedLabelPrefix = "cockroachgke:"
+)
+
+// ensureLabelsTable lazily creates the metadata database and labels table
+func ensureLabelsTable(ctx context.Context, client Querier) error {
+	_, err := client.ExecContext(ctx, "CREATE DATABASE IF NOT EXISTS "+metadataDatabase)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+labelsTable+` (
+		object_type STRING NOT NULL,
+		object_name STRING NOT NULL,
+		key STRING NOT NULL,
+		value STRING NOT NULL,
+		PRIMARY KEY (object_type, object_name, key)
+	)`)
+	return err
+}
+
+// isMissingMetadata reports whether an error only means the labels table was never created
+func isMissingMetadata(err error) bool {
+	var pqErr *pq.Error
+	// undefined_table or invalid_catalog_name
+	return errors.As(err, &pqErr) && (pqErr.Code == "42P01" || pqErr.Code == "3D000")
+}
+
+// writeLabels replaces all labels of an object, keeping the reserved ones
+func writeLabels(ctx context.Context, client Executor, objectType string, objectName string, labels types.Map) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	values := map[string]string{}
+	diags.Append(labels.ElementsAs(ctx, &values, false)...)
+	if diags.HasError() {
+		return diags
+	}
+
+	for key := range values {
+		if strings.HasPrefix(key, reservedLabelPrefix) {
+			diags.AddError("Labels error", fmt.Sprintf("Label key %s uses the reserved prefix %s", key, reservedLabelPrefix))
+			return diags
+		}
+	}
+
+	if len(values) == 0 {
+		_, err := client.ExecContext(ctx, "DELETE FROM "+labelsTable+" WHERE object_type = $1 AND object_name = $2 AND key NOT LIKE $3", objectType, objectName, reservedLabelPrefix+"%")
+		if err != nil && !isMissingMetadata(err) {
+			diags.AddError("Labels error", fmt.Sprintf("Unable to clear labels, got error: %s", err))
+		}
+		return diags
+	}
+
+	if err := ensureLabelsTable(ctx, client); err != nil {
+		diags.AddError("Labels error", fmt.Sprintf("Unable to create the labels table, got error: %s", err))
+		return diags
+	}
+
+	tx, err := client.BeginTx(ctx, nil)
+	if err != nil {
+		diags.AddError("Labels error", fmt.Sprintf("Unable to start a transaction, got error: %s", err))
+		return diags
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM "+labelsTable+" WHERE object_type = $1 AND object_name = $2 AND key NOT LIKE $3", objectType, objectName, reservedLabelPrefix+"%")
+	if err != nil {
+		diags.AddError("Labels error", fmt.Sprintf("Unable to clear labels, got error: %s", err))
+		return diags
+	}
+	for key, value := range values {
+		_, err = tx.ExecContext(ctx, "INSERT INTO "+labelsTable+" (object_type, object_name, key, value) VALUES ($1, $2, $3, $4)", objectType, objectName, key, value)
+		if err != nil {
+			diags.AddError("Labels error", fmt.Sprintf("Unable to write label %s, got error: %s", key, err))
+			return diags
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		diags.AddError("Labels error", fmt.Sprintf("Unable to commit labels, got error: %s", err))
+	}
+	return diags
+}
+
+// readLabels returns the labels of an object, null if it has none
+func readLabels(ctx context.Context, client Executor, objectType string, objectName string) (types.Map, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	rows, err := client.QueryContext(ctx, "SELECT key, value FROM "+labelsTable+" WHERE object_type = $1 AND object_name = $2 AND key NOT LIKE $3", objectType, objectName, reservedLabelPrefix+"%")
+	if isMissingMetadata(err) {
+		return types.MapNull(types.StringType), diags
+	}
+	if err != nil {
+		diags.AddError("Labels error", fmt.Sprintf("Unable to read labels, got error: %s", err))
+		return types.MapNull(types.StringType), diags
+	}
+	defer rows.Close()
+
+	values := map[string]string{}
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			diags.AddError("Labels error", fmt.Sprintf("Unable to read labels, got error: %s", err))
+			return types.MapNull(types.StringType), diags
+		}
+		values[key] = value
+	}
+	if len(values) == 0 {
+		return types.MapNull(types.StringType), diags
+	}
+
+	labels, d := types.MapValueFrom(ctx, types.StringType, values)
+	diags.Append(d...)
+	return labels, diags
+}
+
+// deleteLabels removes all labels of an object, including the reserved ones
+func deleteLabels(ctx context.Context, client Querier, objectType string, objectName string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	_, err := client.ExecContext(ctx, "DELETE FROM "+labelsTable+" WHERE object_type = $1 AND object_name = $2", objectType, objectName)
+	if err != nil && !isMissingMetadata(err) {
+		diags.AddError("Labels error", fmt.Sprintf("Unable to delete labels, got error: %s", err))
+	}
+	return diags
+}
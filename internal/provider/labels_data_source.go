@@ -0,0 +1,179 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &LabelsDataSource{}
+
+func NewLabelsDataSource() datasource.DataSource {
+	return &LabelsDataSource{}
+}
+
+// LabelsDataSource queries the labels resources wrote to the provider metadata table.
+type LabelsDataSource struct {
+	db Database
+}
+
+// LabelsDataSourceModel describes the data source data model.
+type LabelsDataSourceModel struct {
+	ObjectType types.String `tfsdk:"object_type"`
+	Key        types.String `tfsdk:"key"`
+	Value      types.String `tfsdk:"value"`
+	Labels     []labelModel `tfsdk:"labels"`
+}
+
+// labelModel is a single row of the labels table
+type labelModel struct {
+	ObjectType types.String `tfsdk:"object_type"`
+	ObjectName types.String `tfsdk:"object_name"`
+	Key        types.String `tfsdk:"key"`
+	Value      types.String `tfsdk:"value"`
+}
+
+// Metadata appends the data source name to the provider name
+func (d *LabelsDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_labels"
+}
+
+// Schema is the shape of the data source - what you can filter on and what you get back
+func (d *LabelsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Labels set on databases and users managed by this provider",
+		Attributes: map[string]schema.Attribute{
+			"object_type": schema.StringAttribute{
+				MarkdownDescription: "Only return labels of this object type, `database` or `user`",
+				Optional:            true,
+			},
+			"key": schema.StringAttribute{
+				MarkdownDescription: "Only return labels with this key",
+				Optional:            true,
+			},
+			"value": schema.StringAttribute{
+				MarkdownDescription: "Only return labels with this value",
+				Optional:            true,
+			},
+			"labels": schema.ListNestedAttribute{
+				MarkdownDescription: "Matching labels",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"object_type": schema.StringAttribute{
+							MarkdownDescription: "Type of the labelled object",
+							Computed:            true,
+						},
+						"object_name": schema.StringAttribute{
+							MarkdownDescription: "Name of the labelled object",
+							Computed:            true,
+						},
+						"key": schema.StringAttribute{
+							MarkdownDescription: "Label key",
+							Computed:            true,
+						},
+						"value": schema.StringAttribute{
+							MarkdownDescription: "Label value",
+							Computed:            true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Configure adds the provider configured client to the data source
+func (d *LabelsDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(Database)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *CockroachClient, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.db = client
+}
+
+// Read queries the labels table with the given filters
+func (d *LabelsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data LabelsDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, err := d.db.ConnectForRead(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to connect to cockroach",
+			err.Error(),
+		)
+		return
+	}
+	defer client.Close()
+
+	filters := []string{}
+	args := []any{}
+	for column, value := range map[string]types.String{"object_type": data.ObjectType, "key": data.Key, "value": data.Value} {
+		if !value.IsNull() {
+			args = append(args, value.ValueString())
+			filters = append(filters, fmt.Sprintf("%s = $%d", column, len(args)))
+		}
+	}
+
+	q := "SELECT object_type, object_name, key, value FROM " + labelsTable
+	if len(filters) > 0 {
+		q += " WHERE " + strings.Join(filters, " AND ")
+	}
+	q += " ORDER BY object_type, object_name, key"
+
+	data.Labels = []labelModel{}
+
+	rows, err := client.QueryContext(ctx, q, args...)
+	if isMissingMetadata(err) {
+		// Nothing was ever labelled
+		resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Read labels error", fmt.Sprintf("Unable to query labels, got error: %s", err))
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var objectType, objectName, key, value string
+		if err := rows.Scan(&objectType, &objectName, &key, &value); err != nil {
+			resp.Diagnostics.AddError("Read labels error", fmt.Sprintf("Unable to scan label, got error: %s", err))
+			return
+		}
+		data.Labels = append(data.Labels, labelModel{
+			ObjectType: types.StringValue(objectType),
+			ObjectName: types.StringValue(objectName),
+			Key:        types.StringValue(key),
+			Value:      types.StringValue(value),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		resp.Diagnostics.AddError("Read labels error", fmt.Sprintf("Unable to query labels, got error: %s", err))
+		return
+	}
+
+	tflog.Trace(ctx, "read labels")
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
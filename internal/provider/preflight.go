@@ -0,0 +1,82 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+// requiredRoleOptions lists the role options the configured user needs per resource type suffix, unless it's an admin
+var requiredRoleOptions = map[string][]string{
+	"_database":    {"CREATEDB"},
+	"_user":        {"CREATEROLE"},
+	"_credentials": {"CREATEROLE"},
+}
+
+// preflight checks the configured user's privileges up front, so missing permissions show up as a targeted
+// warning per resource type rather than a generic SQL error halfway through an apply.
+func preflight(ctx context.Context, client *CockroachClient) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	conn, err := client.Connect(ctx)
+	if err != nil {
+		diags.AddWarning("Skipped permission preflight", fmt.Sprintf("Unable to connect to cockroach, got error: %s", err))
+		return diags
+	}
+	defer conn.Close()
+
+	var isAdmin bool
+	if err := conn.QueryRowContext(ctx, "SELECT crdb_internal.is_admin()").Scan(&isAdmin); err != nil {
+		diags.AddWarning("Skipped permission preflight", fmt.Sprintf("Unable to check admin status, got error: %s", err))
+		return diags
+	}
+	if isAdmin {
+		return diags
+	}
+
+	// options is a string on older versions and an array on newer ones, the cast covers both
+	var options string
+	if err := conn.QueryRowContext(ctx, "SELECT options::STRING FROM [SHOW ROLES] WHERE username = current_user()").Scan(&options); err != nil {
+		diags.AddWarning("Skipped permission preflight", fmt.Sprintf("Unable to read role options, got error: %s", err))
+		return diags
+	}
+
+	missing := missingRoleOptions(options)
+	resourceTypes := make([]string, 0, len(missing))
+	for resourceType := range missing {
+		resourceTypes = append(resourceTypes, resourceType)
+	}
+	sort.Strings(resourceTypes)
+
+	for _, resourceType := range resourceTypes {
+		diags.AddWarning(
+			"Missing permissions for "+resourceType,
+			fmt.Sprintf("The configured user is not an admin and lacks %s, so %s resources will fail to apply.", strings.Join(missing[resourceType], ", "), resourceType),
+		)
+	}
+
+	return diags
+}
+
+// missingRoleOptions compares the user's role options against requiredRoleOptions
+func missingRoleOptions(options string) map[string][]string {
+	// Split on anything but letters so NOCREATEDB doesn't count as CREATEDB
+	granted := map[string]bool{}
+	for _, option := range strings.FieldsFunc(strings.ToUpper(options), func(r rune) bool { return r < 'A' || r > 'Z' }) {
+		granted[option] = true
+	}
+
+	missing := map[string][]string{}
+	for suffix, required := range requiredRoleOptions {
+		resourceType := typeName + suffix
+		for _, option := range required {
+			if !granted[option] {
+				missing[resourceType] = append(missing[resourceType], option)
+			}
+		}
+	}
+	return missing
+}
@@ -0,0 +1,26 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMissingRoleOptions(t *testing.T) {
+	got := missingRoleOptions("{CREATEROLE,NOCREATEDB}")
+	want := map[string][]string{
+		"cockroachgke_database": {"CREATEDB"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("missingRoleOptions() = %v, want %v", got, want)
+	}
+
+	if got := missingRoleOptions("CREATEDB, CREATEROLE"); len(got) != 0 {
+		t.Errorf("expected nothing missing, got %v", got)
+	}
+
+	defer func(previous string) { typeName = previous }(typeName)
+	typeName = "crdb"
+	if got := missingRoleOptions("CREATEDB"); !reflect.DeepEqual(got, map[string][]string{"crdb_user": {"CREATEROLE"}, "crdb_credentials": {"CREATEROLE"}}) {
+		t.Errorf("expected resource types named after the provider type, got %v", got)
+	}
+}
@@ -0,0 +1,117 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// Private state keys for identifiers cockroach assigns to the objects we manage.
+// These are not user facing, but let Read tell a renamed object from a recreated one.
+const (
+	privateKeyDescriptorID = "descriptor_id"
+	privateKeyRoleID       = "role_id"
+	privateKeyProgress     = "progress"
+)
+
+// privateStateGetter is satisfied by the framework's private state on requests
+type privateStateGetter interface {
+	GetKey(ctx context.Context, key string) ([]byte, diag.Diagnostics)
+}
+
+// privateStateSetter is satisfied by the framework's private state on responses
+type privateStateSetter interface {
+	SetKey(ctx context.Context, key string, value []byte) diag.Diagnostics
+}
+
+// getPrivateID reads a server assigned identifier from private state. The bool is false when nothing has been stored yet,
+// e.g. for resources created by an older version of the provider or freshly imported ones.
+func getPrivateID(ctx context.Context, p privateStateGetter, key string) (int64, bool, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	value, d := p.GetKey(ctx, key)
+	diags.Append(d...)
+	if diags.HasError() || len(value) == 0 {
+		return 0, false, diags
+	}
+
+	var id int64
+	if err := json.Unmarshal(value, &id); err != nil {
+		diags.AddError("Invalid private state", fmt.Sprintf("Unable to decode %s from private state, got error: %s", key, err))
+		return 0, false, diags
+	}
+
+	return id, true, diags
+}
+
+// setPrivateID stores a server assigned identifier in private state
+func setPrivateID(ctx context.Context, p privateStateSetter, key string, id int64) diag.Diagnostics {
+	value, err := json.Marshal(id)
+	if err != nil {
+		var diags diag.Diagnostics
+		diags.AddError("Invalid private state", fmt.Sprintf("Unable to encode %s for private state, got error: %s", key, err))
+		return diags
+	}
+
+	return p.SetKey(ctx, key, value)
+}
+
+// operationProgress records how many steps of a multi-statement operation succeeded. A retried apply of the same change
+// continues after them instead of repeating statements which fail once they have run, e.g. DROP USER.
+type operationProgress struct {
+	Operation string `json:"operation"`
+	Digest    string `json:"digest"`
+	Done      int    `json:"done"`
+}
+
+// progressDigest identifies a change by the values it goes from and to
+func progressDigest(values ...tftypes.Value) string {
+	hash := sha256.New()
+	for _, value := range values {
+		hash.Write([]byte(value.String()))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// getProgress returns how many steps of the operation already succeeded for the same change, 0 for a fresh start
+func getProgress(ctx context.Context, p privateStateGetter, operation string, digest string) (int, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	value, d := p.GetKey(ctx, privateKeyProgress)
+	diags.Append(d...)
+	if diags.HasError() || len(value) == 0 {
+		return 0, diags
+	}
+
+	var progress operationProgress
+	if err := json.Unmarshal(value, &progress); err != nil {
+		diags.AddError("Invalid private state", fmt.Sprintf("Unable to decode %s from private state, got error: %s", privateKeyProgress, err))
+		return 0, diags
+	}
+	if progress.Operation != operation || progress.Digest != digest {
+		return 0, diags
+	}
+	return progress.Done, diags
+}
+
+// setProgress records that the first done steps of the operation succeeded
+func setProgress(ctx context.Context, p privateStateSetter, operation string, digest string, done int) diag.Diagnostics {
+	value, err := json.Marshal(operationProgress{Operation: operation, Digest: digest, Done: done})
+	if err != nil {
+		var diags diag.Diagnostics
+		diags.AddError("Invalid private state", fmt.Sprintf("Unable to encode %s for private state, got error: %s", privateKeyProgress, err))
+		return diags
+	}
+
+	return p.SetKey(ctx, privateKeyProgress, value)
+}
+
+// clearProgress forgets the progress once the operation completed
+func clearProgress(ctx context.Context, p privateStateSetter) diag.Diagnostics {
+	return p.SetKey(ctx, privateKeyProgress, nil)
+}
@@ -0,0 +1,54 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// privateStateMap stands in for the framework's private state
+type privateStateMap map[string][]byte
+
+func (p privateStateMap) GetKey(_ context.Context, key string) ([]byte, diag.Diagnostics) {
+	return p[key], nil
+}
+
+func (p privateStateMap) SetKey(_ context.Context, key string, value []byte) diag.Diagnostics {
+	if len(value) == 0 {
+		delete(p, key)
+		return nil
+	}
+	p[key] = value
+	return nil
+}
+
+func TestProgress(t *testing.T) {
+	ctx := context.Background()
+	private := privateStateMap{}
+	digest := progressDigest(tftypes.NewValue(tftypes.String, "app"))
+
+	if done, diags := getProgress(ctx, private, operationRecreateUser, digest); diags.HasError() || done != 0 {
+		t.Fatalf("expected a fresh start, got %d (%v)", done, diags)
+	}
+
+	setProgress(ctx, private, operationRecreateUser, digest, progressDropped)
+	if done, _ := getProgress(ctx, private, operationRecreateUser, digest); done != progressDropped {
+		t.Errorf("expected to resume after the drop, got %d", done)
+	}
+
+	// A different change or operation starts over
+	other := progressDigest(tftypes.NewValue(tftypes.String, "app_v2"))
+	if done, _ := getProgress(ctx, private, operationRecreateUser, other); done != 0 {
+		t.Errorf("expected a changed plan to start over, got %d", done)
+	}
+	if done, _ := getProgress(ctx, private, operationCreateUser, digest); done != 0 {
+		t.Errorf("expected another operation to start over, got %d", done)
+	}
+
+	clearProgress(ctx, private)
+	if done, _ := getProgress(ctx, private, operationRecreateUser, digest); done != 0 {
+		t.Errorf("expected the progress to be cleared, got %d", done)
+	}
+}
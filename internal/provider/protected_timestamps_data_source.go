@@ -0,0 +1,185 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"golang.org/x/exp/slices"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &ProtectedTimestampsDataSource{}
+
+func NewProtectedTimestampsDataSource() datasource.DataSource {
+	return &ProtectedTimestampsDataSource{}
+}
+
+// ProtectedTimestampsDataSource lists the protected timestamps held by changefeeds.
+type ProtectedTimestampsDataSource struct {
+	db Database
+}
+
+// ProtectedTimestampsDataSourceModel describes the data source data model.
+type ProtectedTimestampsDataSourceModel struct {
+	JobIDs              types.Set                 `tfsdk:"job_ids"`
+	OldestAgeSeconds    types.Int64               `tfsdk:"oldest_age_seconds"`
+	ProtectedTimestamps []protectedTimestampModel `tfsdk:"protected_timestamps"`
+}
+
+// protectedTimestampModel is a single protected timestamp record of a changefeed
+type protectedTimestampModel struct {
+	JobID              types.Int64  `tfsdk:"job_id"`
+	Status             types.String `tfsdk:"status"`
+	Description        types.String `tfsdk:"description"`
+	ProtectedTimestamp types.String `tfsdk:"protected_timestamp"`
+	AgeSeconds         types.Int64  `tfsdk:"age_seconds"`
+}
+
+// Metadata appends the data source name to the provider name
+func (d *ProtectedTimestampsDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_protected_timestamps"
+}
+
+// Schema is the shape of the data source - what you need to supply and what you get back
+func (d *ProtectedTimestampsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Protected timestamps held by changefeeds and their ages. Old protected timestamps keep garbage collection from removing old row versions, alert on `oldest_age_seconds` before that fills the cluster",
+		Attributes: map[string]schema.Attribute{
+			"job_ids": schema.SetAttribute{
+				ElementType:         types.Int64Type,
+				MarkdownDescription: "Only list the records of these changefeed jobs, e.g. those managed by this workspace. Lists all changefeeds when unset or empty",
+				Optional:            true,
+			},
+			"oldest_age_seconds": schema.Int64Attribute{
+				MarkdownDescription: "Age of the oldest listed protected timestamp in seconds, 0 without any",
+				Computed:            true,
+			},
+			"protected_timestamps": schema.ListNestedAttribute{
+				MarkdownDescription: "Protected timestamp records, oldest first",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"job_id": schema.Int64Attribute{
+							MarkdownDescription: "Id of the changefeed job holding the record",
+							Computed:            true,
+						},
+						"status": schema.StringAttribute{
+							MarkdownDescription: "Status of the job, paused changefeeds keep their protected timestamp",
+							Computed:            true,
+						},
+						"description": schema.StringAttribute{
+							MarkdownDescription: "Statement which created the changefeed",
+							Computed:            true,
+						},
+						"protected_timestamp": schema.StringAttribute{
+							MarkdownDescription: "HLC timestamp from which on row versions are kept",
+							Computed:            true,
+						},
+						"age_seconds": schema.Int64Attribute{
+							MarkdownDescription: "Seconds since the protected timestamp",
+							Computed:            true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Configure adds the provider configured client to the data source
+func (d *ProtectedTimestampsDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(Database)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *CockroachClient, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.db = client
+}
+
+// Read lists the protected timestamp records of changefeed jobs
+func (d *ProtectedTimestampsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data ProtectedTimestampsDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	jobIDs := []int64{}
+	if !data.JobIDs.IsNull() {
+		resp.Diagnostics.Append(data.JobIDs.ElementsAs(ctx, &jobIDs, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	client, err := d.db.Connect(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to connect to cockroach",
+			err.Error(),
+		)
+		return
+	}
+	defer client.Close()
+
+	dialect, err := client.Dialect(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Read protected timestamps error", fmt.Sprintf("Unable to determine server version, got error: %s", err))
+		return
+	}
+	query, diags := dialect.ChangefeedProtectedTimestamps()
+	resp.Diagnostics.Append(diags...)
+
+	rows, err := client.QueryContext(ctx, query)
+	if err != nil {
+		resp.Diagnostics.AddError("Read protected timestamps error", fmt.Sprintf("Unable to list protected timestamps, got error: %s", err))
+		return
+	}
+	defer rows.Close()
+
+	data.OldestAgeSeconds = types.Int64Value(0)
+	data.ProtectedTimestamps = []protectedTimestampModel{}
+	for rows.Next() {
+		var jobID, age int64
+		var status, description, ts string
+		if err := rows.Scan(&jobID, &status, &description, &ts, &age); err != nil {
+			resp.Diagnostics.AddError("Read protected timestamps error", fmt.Sprintf("Unable to scan protected timestamp, got error: %s", err))
+			return
+		}
+		if len(jobIDs) > 0 && !slices.Contains(jobIDs, jobID) {
+			continue
+		}
+
+		data.ProtectedTimestamps = append(data.ProtectedTimestamps, protectedTimestampModel{
+			JobID:              types.Int64Value(jobID),
+			Status:             types.StringValue(status),
+			Description:        types.StringValue(scrubSecrets(description)),
+			ProtectedTimestamp: types.StringValue(ts),
+			AgeSeconds:         types.Int64Value(age),
+		})
+		if age > data.OldestAgeSeconds.ValueInt64() {
+			data.OldestAgeSeconds = types.Int64Value(age)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		resp.Diagnostics.AddError("Read protected timestamps error", fmt.Sprintf("Unable to list protected timestamps, got error: %s", err))
+		return
+	}
+
+	tflog.Trace(ctx, "read protected timestamps")
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -3,20 +3,34 @@ package provider
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
+	"math"
+	"net/url"
+	"os"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework-validators/boolvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/objectvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 // Ensure CockroachGKEProvider satisfies various provider interfaces.
 var _ provider.Provider = &CockroachGKEProvider{}
+var _ provider.ProviderWithEphemeralResources = &CockroachGKEProvider{}
 
 // New is a helper function to simplify provider server and testing implementation.
 func New(version string) func() provider.Provider {
@@ -27,18 +41,198 @@ func New(version string) func() provider.Provider {
 	}
 }
 
-// Pass around the connection string in a struct
+const (
+	defaultPort    = 26257
+	defaultSSLMode = "verify-full"
+)
+
+// Pass around the connection string and retry policy in a struct. The remaining fields describe what we connect to,
+// without any secrets.
 type CockroachClient struct {
 	ConnectionString *string
+	Retry            retryPolicy
+
+	Host     string
+	Port     int64
+	SSLMode  string
+	CertPath string
+
+	// ProxyAddress is dialed instead of the host when set
+	ProxyAddress string
+
+	// TLS tracks the certificate files, which are read lazily for every connection
+	TLS *tlsMaterial
+
+	// DialTimeout and KeepAlive tune the TCP connections, MaxConnLifetime recycles pooled connections
+	DialTimeout     time.Duration
+	KeepAlive       time.Duration
+	MaxConnLifetime time.Duration
+
+	// Tokens supplies the password of every new connection when logging in with a Google access token
+	Tokens *gcpTokenSource
+
+	// ConnectRetry waits for the cluster to accept connections before a connection is handed out
+	ConnectRetry connectRetry
+
+	// MaxOpenConns and MaxIdleConns size the pool of every connection, zero keeps the database/sql defaults
+	MaxOpenConns int
+	MaxIdleConns int
+
+	// DeletionProtection refuses destroys of resources which don't set allow_destroy
+	DeletionProtection bool
+
+	// FollowerReads makes refreshes read slightly stale data from the nearest replica instead of the leaseholder
+	FollowerReads bool
+
+	// RecordStatements keeps the statements each apply runs, for the last_applied_statements attributes
+	RecordStatements bool
+
+	// Database is where the sessions start, statements in it need no SET DATABASE
+	Database string
+
+	versions versionCache
+	reads    readCache
+
+	// connector replaces the pq connector when set, unit tests inject a mock driver through it
+	connector driver.Connector
 }
 
 // Connect to cockroach
-func (c *CockroachClient) Connect() (*sql.DB, error) {
-	db, err := sql.Open("postgres", *c.ConnectionString)
+func (c *CockroachClient) Connect(ctx context.Context) (Session, error) {
+	return c.connect(ctx, "")
+}
+
+// ConnectForRead connects for refreshes, whose sessions use follower reads when the provider enables them
+func (c *CockroachClient) ConnectForRead(ctx context.Context) (Session, error) {
+	if !c.FollowerReads {
+		return c.Connect(ctx)
+	}
+	// Unknown parameters are sent to the server as session variables
+	return c.connect(ctx, "&default_transaction_use_follower_reads=on")
+}
+
+// connect opens a pool on the connection string with extra parameters, or on the injected connector
+func (c *CockroachClient) connect(ctx context.Context, params string) (*CockroachConn, error) {
+	var conn *CockroachConn
+	if c.connector != nil {
+		conn = c.newConn(c.connector)
+	} else if c.Tokens != nil {
+		conn = c.newConn(tokenConnector{client: c, params: params})
+	} else {
+		connector, err := c.pqConnector(params)
+		if err != nil {
+			return nil, err
+		}
+		conn = c.newConn(connector)
+	}
+
+	// Pools connect lazily, so the first connection is only tried up front when it should be retried. Cancelling the
+	// operation stops the retries.
+	if c.ConnectRetry.enabled() {
+		if err := c.ConnectRetry.do(ctx, func() error { return conn.PingContext(ctx) }); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// pqConnector parses the connection string with extra parameters into a pq connector dialing through the client's dialer
+func (c *CockroachClient) pqConnector(params string) (*pq.Connector, error) {
+	connector, err := pq.NewConnector(*c.ConnectionString + params)
 	if err != nil {
-		return nil, err
+		// Parse errors quote the connection string, password included
+		return nil, errors.New(scrubSecrets(err.Error()))
+	}
+	if c.ProxyAddress != "" || c.DialTimeout != 0 || c.KeepAlive != 0 {
+		connector.Dialer(newConnDialer(c.ProxyAddress, c.DialTimeout, c.KeepAlive))
+	}
+	return connector, nil
+}
+
+// newConn opens a pool on the connector with the client's settings
+func (c *CockroachClient) newConn(connector driver.Connector) *CockroachConn {
+	conn := &CockroachConn{DB: sql.OpenDB(connector), retry: c.Retry, versions: &c.versions, reads: &c.reads, database: c.Database}
+	// Certificate errors are retried if the files change while the pool is open
+	if checksum, err := c.TLS.current(); c.TLS != nil && err == nil {
+		conn.retry.rotated = c.TLS.rotatedSince(checksum)
+	}
+	conn.SetConnMaxLifetime(c.MaxConnLifetime)
+	if c.MaxOpenConns > 0 {
+		conn.SetMaxOpenConns(c.MaxOpenConns)
 	}
-	return db, nil
+	if c.MaxIdleConns > 0 {
+		conn.SetMaxIdleConns(c.MaxIdleConns)
+	}
+	if c.RecordStatements {
+		conn.recorder = &statementRecorder{}
+	}
+	return conn
+}
+
+// ClusterID returns the id of the connected cluster, asked once per provider instance
+func (c *CockroachConn) ClusterID(ctx context.Context) (string, error) {
+	if c.versions != nil {
+		c.versions.mu.Lock()
+		defer c.versions.mu.Unlock()
+		if c.versions.clusterID != "" {
+			return c.versions.clusterID, nil
+		}
+	}
+
+	var id string
+	if err := c.QueryRowContext(ctx, "SELECT crdb_internal.cluster_id()::STRING").Scan(&id); err != nil {
+		return "", err
+	}
+
+	if c.versions != nil {
+		c.versions.clusterID = id
+	}
+	return id, nil
+}
+
+// checkDeletionProtection refuses a destroy while deletion_protection is on, unless the resource opts out with allow_destroy
+func (c *CockroachClient) checkDeletionProtection(resourceType string, name string, allowDestroy types.Bool) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if c.DeletionProtection && !allowDestroy.ValueBool() {
+		diags.AddError(
+			"Deletion protection",
+			fmt.Sprintf("Refusing to destroy %s %s because the provider has deletion_protection enabled. Set allow_destroy = true on the resource and apply before destroying it.", resourceType, name),
+		)
+	}
+	return diags
+}
+
+// verifyClusterID refuses to continue when the provider is pointed at a different cluster than expected
+func verifyClusterID(ctx context.Context, client Database, expected string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	conn, err := client.Connect(ctx)
+	if err != nil {
+		diags.AddError("Failed to connect to cockroach", err.Error())
+		return diags
+	}
+	defer conn.Close()
+
+	id, err := conn.ClusterID(ctx)
+	if err != nil {
+		diags.AddAttributeError(
+			path.Root("expected_cluster_id"),
+			"Unable to verify Cockroach cluster id",
+			fmt.Sprintf("The provider cannot verify it is connected to the expected Cockroach cluster, got error: %s", err),
+		)
+		return diags
+	}
+
+	if !strings.EqualFold(id, expected) {
+		diags.AddAttributeError(
+			path.Root("expected_cluster_id"),
+			"Unexpected Cockroach cluster",
+			fmt.Sprintf("The provider is connected to cluster %s but expected_cluster_id is %s. Refusing to continue, check the host and credentials.", id, expected),
+		)
+	}
+	return diags
 }
 
 // CockroachGKEProvider defines the provider implementation.
@@ -51,15 +245,51 @@ type CockroachGKEProvider struct {
 
 // CockroachGKEProviderModel describes the provider data model.
 type CockroachGKEProviderModel struct {
-	Host     types.String `tfsdk:"host"`
-	Username types.String `tfsdk:"username"`
-	Password types.String `tfsdk:"password"`
-	CertPath types.String `tfsdk:"certpath"`
+	Host               types.String       `tfsdk:"host"`
+	Username           types.String       `tfsdk:"username"`
+	Password           types.String       `tfsdk:"password"`
+	CertPath           types.String       `tfsdk:"certpath"`
+	CACertPEM          types.String       `tfsdk:"ca_cert_pem"`
+	SSLCert            types.String       `tfsdk:"sslcert"`
+	SSLKey             types.String       `tfsdk:"sslkey"`
+	MaxRetries         types.Int64        `tfsdk:"max_retries"`
+	RetryBackoff       types.String       `tfsdk:"retry_backoff"`
+	FailFast           types.Bool         `tfsdk:"fail_fast"`
+	ProxyAddress       types.String       `tfsdk:"proxy_address"`
+	ProxyCommand       types.List         `tfsdk:"proxy_command"`
+	ExpectedClusterID  types.String       `tfsdk:"expected_cluster_id"`
+	DeletionProtection types.Bool         `tfsdk:"deletion_protection"`
+	Database           types.String       `tfsdk:"database"`
+	DefaultDatabase    types.String       `tfsdk:"default_database"`
+	SearchPath         types.String       `tfsdk:"search_path"`
+	RecordStatements   types.Bool         `tfsdk:"record_statements"`
+	GSSAPI             *gssapiModel       `tfsdk:"gssapi"`
+	GCPIAM             *gcpIAMModel       `tfsdk:"gcp_iam"`
+	ConnectRetry       *connectRetryModel `tfsdk:"connect_retry"`
+	FollowerReads      types.Bool         `tfsdk:"follower_reads"`
+	SkipConnectivity   types.Bool         `tfsdk:"skip_connectivity_check"`
+	DialTimeout        types.String       `tfsdk:"dial_timeout"`
+	TCPKeepAlive       types.String       `tfsdk:"tcp_keepalive"`
+	MaxConnLifetime    types.String       `tfsdk:"max_conn_lifetime"`
+	ConnectTimeout     types.String       `tfsdk:"connect_timeout"`
+	StatementTimeout   types.String       `tfsdk:"statement_timeout"`
+	MaxOpenConns       types.Int64        `tfsdk:"max_open_conns"`
+	MaxIdleConns       types.Int64        `tfsdk:"max_idle_conns"`
+	Port               types.Int64        `tfsdk:"port"`
+	SSLMode            types.String       `tfsdk:"sslmode"`
+	Insecure           types.Bool         `tfsdk:"insecure"`
+	TLSMinVersion      types.String       `tfsdk:"tls_min_version"`
+	TLSCipherSuites    types.List         `tfsdk:"tls_cipher_suites"`
 }
 
+// typeName prefixes every resource and data source. Builds for clusters outside GKE can register the provider under
+// a neutral name with -ldflags "-X github.com/ntschl/terraform-provider-cockroachgke/internal/provider.typeName=crdb",
+// the default keeps existing configurations working.
+var typeName = "cockroachgke"
+
 // Metadata is for naming the proivder and its resources and data sources.
 func (p *CockroachGKEProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
-	resp.TypeName = "cockroachgke"
+	resp.TypeName = typeName
 	resp.Version = p.version
 }
 
@@ -69,21 +299,223 @@ func (p *CockroachGKEProvider) Schema(ctx context.Context, req provider.SchemaRe
 		Description: "Interact with Cockroach.",
 		Attributes: map[string]schema.Attribute{
 			"host": schema.StringAttribute{
-				Description: "Host for the Cockroach database.",
-				Required:    true,
+				Description: "Host for the Cockroach database. Defaults to the CRDB_HOST environment variable.",
+				Optional:    true,
+			},
+			"port": schema.Int64Attribute{
+				Description: "SQL port of the Cockroach database. Defaults to 26257.",
+				Optional:    true,
+				Validators: []validator.Int64{
+					int64validator.Between(1, 65535),
+				},
 			},
 			"username": schema.StringAttribute{
-				Description: "Username for the Cockroach user with cluster admin permissions.",
-				Required:    true,
+				Description: "Username for the Cockroach user with cluster admin permissions. Defaults to the CRDB_USERNAME environment variable.",
+				Optional:    true,
 			},
 			"password": schema.StringAttribute{
-				Description: "Password for the Cockroach user with cluster admin permissions.",
+				Description: "Password for the Cockroach user with cluster admin permissions. Defaults to the CRDB_PASSWORD environment variable. Accepts ephemeral values, e.g. from an ephemeral vault secret, which Terraform keeps out of plan and state files.",
 				Sensitive:   true,
-				Required:    true,
+				Optional:    true,
 			},
 			"certpath": schema.StringAttribute{
-				Description: "Path to certificate authority for Cockroach cluster.",
-				Required:    true,
+				Description: "Path to certificate authority for Cockroach cluster. Defaults to the CRDB_CERTPATH environment variable.",
+				Optional:    true,
+			},
+			"ca_cert_pem": schema.StringAttribute{
+				Description: "PEM contents of the certificate authority, for runners without a stable path for certpath such as Terraform Cloud. Conflicts with certpath.",
+				Optional:    true,
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(path.MatchRoot("certpath")),
+				},
+			},
+			"sslcert": schema.StringAttribute{
+				Description: "Path to a client certificate to authenticate with instead of a password, e.g. client.root.crt. Requires sslkey.",
+				Optional:    true,
+				Validators: []validator.String{
+					stringvalidator.AlsoRequires(path.MatchRoot("sslkey")),
+				},
+			},
+			"sslkey": schema.StringAttribute{
+				Description: "Path to the private key of sslcert. The file must not be readable by group or others. Requires sslcert.",
+				Optional:    true,
+				Validators: []validator.String{
+					stringvalidator.AlsoRequires(path.MatchRoot("sslcert")),
+				},
+			},
+			"max_retries": schema.Int64Attribute{
+				Description: "How often a statement is retried when Cockroach reports a transient error such as a serialization failure. Batches of several statements aren't retried after ambiguous errors, which may leave them partially applied. Defaults to 3.",
+				Optional:    true,
+			},
+			"retry_backoff": schema.StringAttribute{
+				Description: "Wait before the first retry as a Go duration, doubled for every further retry. Defaults to 500ms.",
+				Optional:    true,
+			},
+			"fail_fast": schema.BoolAttribute{
+				Description: "Disable retries entirely and fail on the first error, e.g. for CI.",
+				Optional:    true,
+			},
+			"dial_timeout": schema.StringAttribute{
+				Description: "How long to wait for a TCP connection to the cluster, e.g. 10s. Defaults to the operating system timeout, which can be minutes.",
+				Optional:    true,
+			},
+			"tcp_keepalive": schema.StringAttribute{
+				Description: "Interval of TCP keepalive probes, e.g. 30s, so connections dropped by a NAT or firewall are noticed instead of hanging. 0s disables keepalives, defaults to 15s.",
+				Optional:    true,
+			},
+			"max_conn_lifetime": schema.StringAttribute{
+				Description: "Close pooled connections after this long, e.g. 5m, so long applies don't keep using connections a load balancer has silently dropped. Defaults to no limit.",
+				Optional:    true,
+			},
+			"connect_timeout": schema.StringAttribute{
+				Description: "How long establishing a connection may take in total, including TLS and authentication, e.g. 30s. Rounded up to whole seconds. Unlike dial_timeout it also bounds a node that accepts the TCP connection but never answers. Defaults to no limit.",
+				Optional:    true,
+			},
+			"statement_timeout": schema.StringAttribute{
+				Description: "Default statement_timeout of every session, e.g. 5m, so a statement stuck on a hung node fails instead of blocking the apply. Defaults to the cluster setting.",
+				Optional:    true,
+			},
+			"max_open_conns": schema.Int64Attribute{
+				Description: "Most connections a single operation opens to the cluster at once. Terraform runs operations in parallel, so the cluster sees up to this times -parallelism connections. Defaults to no limit.",
+				Optional:    true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"max_idle_conns": schema.Int64Attribute{
+				Description: "Most idle connections an operation keeps for reuse. Defaults to 2.",
+				Optional:    true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"sslmode": schema.StringAttribute{
+				Description: "How the connection is secured: disable, require, verify-ca or verify-full. verify-ca skips the hostname check, e.g. behind a load balancer, require also skips the CA check. certpath is only needed for verify-ca and verify-full. Defaults to verify-full.",
+				Optional:    true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(sslModes...),
+				},
+			},
+			"insecure": schema.BoolAttribute{
+				Description: "Connect to a cluster started with --insecure, e.g. cockroach start-single-node --insecure for local development and acceptance tests. Turns TLS off like sslmode = \"disable\" and makes password optional, since insecure clusters don't check it. Never use it for a real cluster.",
+				Optional:    true,
+				Validators: []validator.Bool{
+					boolvalidator.ConflictsWith(path.MatchRoot("sslmode")),
+				},
+			},
+			"tls_min_version": schema.StringAttribute{
+				Description: "Lowest TLS version the cluster may negotiate, 1.2 or 1.3. Checked when the provider is configured, unless skip_connectivity_check is set. Connections never use less than TLS 1.2.",
+				Optional:    true,
+			},
+			"tls_cipher_suites": schema.ListAttribute{
+				ElementType: types.StringType,
+				Description: "Cipher suites the cluster may negotiate, by IANA name such as TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 or TLS_AES_128_GCM_SHA256. Checked when the provider is configured, unless skip_connectivity_check is set.",
+				Optional:    true,
+			},
+			"proxy_address": schema.StringAttribute{
+				Description: "Address of a local auth proxy (host:port or an absolute unix socket path) to dial instead of the host, for workspaces that cannot reach the SQL port directly.",
+				Optional:    true,
+			},
+			"proxy_command": schema.ListAttribute{
+				ElementType: types.StringType,
+				Description: "Command and arguments that start the proxy. It is spawned at configure time and must listen on proxy_address.",
+				Optional:    true,
+			},
+			"expected_cluster_id": schema.StringAttribute{
+				Description: "Refuse to do anything unless the connected cluster has this id, as returned by crdb_internal.cluster_id(). Guards against applying to the wrong cluster.",
+				Optional:    true,
+			},
+			"deletion_protection": schema.BoolAttribute{
+				Description: "Turn every destroy of a database or user into an error unless the resource sets allow_destroy = true.",
+				Optional:    true,
+			},
+			"connect_retry": schema.SingleNestedAttribute{
+				Description: "Keep trying to reach the cluster while it doesn't accept connections, e.g. while its pods become ready after bootstrap. Applies to the connectivity check and to every connection the provider opens. Authentication and certificate errors are not retried.",
+				Optional:    true,
+				Attributes: map[string]schema.Attribute{
+					"attempts": schema.Int64Attribute{
+						Description: "Most attempts in total. Defaults to no limit other than max_elapsed_time.",
+						Optional:    true,
+						Validators: []validator.Int64{
+							int64validator.AtLeast(1),
+						},
+					},
+					"interval": schema.StringAttribute{
+						Description: "Wait between attempts as a Go duration. Defaults to 5s.",
+						Optional:    true,
+					},
+					"max_elapsed_time": schema.StringAttribute{
+						Description: "Give up once another attempt would start after this long. Defaults to 2m.",
+						Optional:    true,
+					},
+				},
+			},
+			"gssapi": schema.SingleNestedAttribute{
+				Description: "Log in with Kerberos instead of a password. Needs a build of the provider with a GSSAPI implementation.",
+				Optional:    true,
+				Attributes: map[string]schema.Attribute{
+					"service_name": schema.StringAttribute{
+						Description: "Kerberos service name of the server, combined with the host into the SPN. Defaults to postgres.",
+						Optional:    true,
+					},
+					"spn": schema.StringAttribute{
+						Description: "Full Kerberos SPN of the server, takes priority over service_name.",
+						Optional:    true,
+					},
+					"ccache_path": schema.StringAttribute{
+						Description: "Path to the Kerberos credential cache, e.g. as filled by kinit.",
+						Optional:    true,
+					},
+					"keytab_path": schema.StringAttribute{
+						Description: "Path to a client keytab used to obtain credentials.",
+						Optional:    true,
+					},
+					"config_path": schema.StringAttribute{
+						Description: "Path to krb5.conf. Defaults to /etc/krb5.conf.",
+						Optional:    true,
+					},
+				},
+			},
+			"gcp_iam": schema.SingleNestedAttribute{
+				Description: "Log in with a Google access token as the password instead of a static one, e.g. the workload identity of the pod on GKE. Tokens come from the GOOGLE_OAUTH_ACCESS_TOKEN environment variable or the metadata server and are refreshed before they expire.",
+				Optional:    true,
+				Attributes: map[string]schema.Attribute{
+					"service_account": schema.StringAttribute{
+						Description: "Email of the service account whose token the metadata server hands out. Defaults to the one bound to the workload.",
+						Optional:    true,
+					},
+				},
+				Validators: []validator.Object{
+					objectvalidator.ConflictsWith(path.MatchRoot("password"), path.MatchRoot("gssapi")),
+				},
+			},
+			"follower_reads": schema.BoolAttribute{
+				Description: "Refresh databases, users and functions and read the labels, schedule and table size data sources with follower reads, i.e. AS OF SYSTEM TIME follower_read_timestamp(). Takes load off the leaseholders during large plans, at the cost of data a few seconds stale. Needs an enterprise license.",
+				Optional:    true,
+			},
+			"skip_connectivity_check": schema.BoolAttribute{
+				Description: "Skip the SELECT version() query run when the provider is configured, which fails early on a bad CA, expired certificate, wrong password or unreachable host. Needed when the cluster is created in the same apply.",
+				Optional:    true,
+			},
+			"record_statements": schema.BoolAttribute{
+				Description: "Record the statements each apply runs, with secrets scrubbed, in the last_applied_statements attribute of databases, users, functions and the system database survival.",
+				Optional:    true,
+			},
+			"database": schema.StringAttribute{
+				Description: "Database the provider's sessions start in. Defaults to the server default, usually defaultdb. Resources in this database run their statements without switching to it first.",
+				Optional:    true,
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(path.MatchRoot("default_database")),
+				},
+			},
+			"default_database": schema.StringAttribute{
+				Description:        "Database the provider's sessions start in. Defaults to the server default, usually defaultdb.",
+				DeprecationMessage: "Use database instead.",
+				Optional:           true,
+			},
+			"search_path": schema.StringAttribute{
+				Description: "Comma separated schemas set as the search_path of the provider's sessions.",
+				Optional:    true,
 			},
 		},
 	}
@@ -99,6 +531,13 @@ func (p *CockroachGKEProvider) Configure(ctx context.Context, req provider.Confi
 		return
 	}
 
+	// Connection settings from an ephemeral resource, such as a vault secret, are unknown during plan while the
+	// resource's own inputs are. Terraform versions supporting deferrals plan our resources in a later round instead.
+	if req.ClientCapabilities.DeferralAllowed && (data.Host.IsUnknown() || data.Username.IsUnknown() || data.Password.IsUnknown() || data.CertPath.IsUnknown() || data.CACertPEM.IsUnknown()) {
+		resp.Deferred = &provider.Deferred{Reason: provider.DeferredReasonProviderConfigUnknown}
+		return
+	}
+
 	// Configuration values are now available.
 	// if data.Endpoint.IsNull() { /* ... */ }
 	if data.Host.IsUnknown() {
@@ -133,15 +572,42 @@ func (p *CockroachGKEProvider) Configure(ctx context.Context, req provider.Confi
 		)
 	}
 
+	if data.CACertPEM.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("ca_cert_pem"),
+			"Unknown Cockroach database certificate authority",
+			"The provider cannot create a Cockroach database connection because there is an unknown configuration value for the Cockroach certificate authority.",
+		)
+	}
+
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
+	// Omitted connection settings come from the environment, so credentials can stay out of the configuration
+	data.Host = envFallback(data.Host, "CRDB_HOST")
+	data.Username = envFallback(data.Username, "CRDB_USERNAME")
+	data.Password = envFallback(data.Password, "CRDB_PASSWORD")
+	data.CertPath = envFallback(data.CertPath, "CRDB_CERTPATH")
+
+	if data.CACertPEM.ValueString() != "" {
+		certPath, err := writeCACert(data.CACertPEM.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("ca_cert_pem"),
+				"Invalid Cockroach database certificate authority",
+				fmt.Sprintf("The provider cannot create a Cockroach database connection because the certificate authority could not be stored: %s", err),
+			)
+			return
+		}
+		data.CertPath = types.StringValue(certPath)
+	}
+
 	if data.Host.ValueString() == "" {
 		resp.Diagnostics.AddAttributeError(
 			path.Root("host"),
 			"Missing Cockroach database host",
-			"The provider cannot create a Cockroach database connection because there is a missing configuration value for the Cockroach host.",
+			"The provider cannot create a Cockroach database connection because there is a missing configuration value for the Cockroach host. Set it in the configuration or use the CRDB_HOST environment variable.",
 		)
 	}
 
@@ -149,23 +615,127 @@ func (p *CockroachGKEProvider) Configure(ctx context.Context, req provider.Confi
 		resp.Diagnostics.AddAttributeError(
 			path.Root("username"),
 			"Missing Cockroach database username",
-			"The provider cannot create a Cockroach database connection because there is a missing configuration value for the Cockroach username.",
+			"The provider cannot create a Cockroach database connection because there is a missing configuration value for the Cockroach username. Set it in the configuration or use the CRDB_USERNAME environment variable.",
 		)
 	}
 
-	if data.Password.ValueString() == "" {
+	// Kerberos, token and certificate logins don't have a password, insecure clusters ignore it
+	if data.Password.ValueString() == "" && data.GSSAPI == nil && data.GCPIAM == nil && data.SSLCert.ValueString() == "" && !data.Insecure.ValueBool() {
 		resp.Diagnostics.AddAttributeError(
 			path.Root("password"),
 			"Missing Cockroach database password",
-			"The provider cannot create a Cockroach database connection because there is a missing configuration value for the Cockroach password.",
+			"The provider cannot create a Cockroach database connection because there is a missing configuration value for the Cockroach password. Set it in the configuration or use the CRDB_PASSWORD environment variable.",
 		)
 	}
 
-	if data.CertPath.ValueString() == "" {
+	if data.CertPath.ValueString() == "" && verifiesCertificate(data.sslMode()) {
 		resp.Diagnostics.AddAttributeError(
 			path.Root("certpath"),
 			"Missing Cockroach database cert path",
-			"The provider cannot create a Cockroach database connection because there is a missing configuration value for the path to the Cockroach certificate authority.",
+			"The provider cannot create a Cockroach database connection because there is a missing configuration value for the path to the Cockroach certificate authority. Set it or ca_cert_pem in the configuration, or use the CRDB_CERTPATH environment variable.",
+		)
+	}
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if data.GSSAPI != nil {
+		resp.Diagnostics.Append(configureGSSAPI(data.GSSAPI)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	retry := retryPolicy{
+		MaxRetries: defaultMaxRetries,
+		Backoff:    defaultRetryBackoff,
+		FailFast:   data.FailFast.ValueBool(),
+	}
+
+	if !data.MaxRetries.IsNull() {
+		if data.MaxRetries.ValueInt64() < 0 {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("max_retries"),
+				"Invalid Cockroach max retries",
+				"The provider cannot create a Cockroach database connection because max_retries is negative.",
+			)
+		}
+		retry.MaxRetries = int(data.MaxRetries.ValueInt64())
+	}
+
+	if !data.RetryBackoff.IsNull() {
+		backoff, err := time.ParseDuration(data.RetryBackoff.ValueString())
+		if err != nil || backoff < 0 {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("retry_backoff"),
+				"Invalid Cockroach retry backoff",
+				fmt.Sprintf("The provider cannot create a Cockroach database connection because %q is not a valid duration such as 500ms.", data.RetryBackoff.ValueString()),
+			)
+		}
+		retry.Backoff = backoff
+	}
+
+	dialTimeout := parseDurationAttribute(data.DialTimeout, "dial_timeout", &resp.Diagnostics)
+	maxConnLifetime := parseDurationAttribute(data.MaxConnLifetime, "max_conn_lifetime", &resp.Diagnostics)
+	parseDurationAttribute(data.ConnectTimeout, "connect_timeout", &resp.Diagnostics)
+	parseDurationAttribute(data.StatementTimeout, "statement_timeout", &resp.Diagnostics)
+	connectRetry := data.ConnectRetry.policy(&resp.Diagnostics)
+	keepAlive := parseDurationAttribute(data.TCPKeepAlive, "tcp_keepalive", &resp.Diagnostics)
+	// net.Dialer treats zero as the default and a negative interval as disabled
+	if !data.TCPKeepAlive.IsNull() && keepAlive == 0 {
+		keepAlive = -1
+	}
+
+	var policy tlsPolicy
+	if !data.TLSMinVersion.IsNull() {
+		version, ok := tlsVersions[data.TLSMinVersion.ValueString()]
+		if !ok {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("tls_min_version"),
+				"Invalid Cockroach TLS version",
+				fmt.Sprintf("The provider cannot create a Cockroach database connection because %q is not a TLS version, use 1.2 or 1.3.", data.TLSMinVersion.ValueString()),
+			)
+		}
+		policy.MinVersion = version
+	}
+	var cipherSuites []string
+	resp.Diagnostics.Append(data.TLSCipherSuites.ElementsAs(ctx, &cipherSuites, false)...)
+	if len(cipherSuites) > 0 {
+		ids, err := parseCipherSuites(cipherSuites)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("tls_cipher_suites"),
+				"Invalid Cockroach cipher suite",
+				fmt.Sprintf("The provider cannot create a Cockroach database connection because %s.", err),
+			)
+		}
+		policy.CipherSuites = ids
+	}
+
+	var proxyCommand []string
+	resp.Diagnostics.Append(data.ProxyCommand.ElementsAs(ctx, &proxyCommand, false)...)
+	if data.sslMode() == "disable" && (policy.MinVersion != 0 || len(policy.CipherSuites) > 0) {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("sslmode"),
+			"Conflicting Cockroach TLS configuration",
+			"The provider cannot enforce tls_min_version or tls_cipher_suites because sslmode = \"disable\" turns TLS off.",
+		)
+	}
+
+	if data.sslMode() == "disable" && data.SSLCert.ValueString() != "" {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("sslmode"),
+			"Conflicting Cockroach TLS configuration",
+			"The provider cannot authenticate with sslcert because sslmode = \"disable\" turns TLS off.",
+		)
+	}
+
+	if len(proxyCommand) > 0 && data.ProxyAddress.ValueString() == "" {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("proxy_address"),
+			"Missing Cockroach proxy address",
+			"The provider cannot start the Cockroach proxy because proxy_command is set without a proxy_address to connect to.",
 		)
 	}
 
@@ -173,19 +743,152 @@ func (p *CockroachGKEProvider) Configure(ctx context.Context, req provider.Confi
 		return
 	}
 
+	if len(proxyCommand) > 0 {
+		if err := startProxy(proxyCommand, data.ProxyAddress.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("proxy_command"),
+				"Unable to start Cockroach proxy",
+				fmt.Sprintf("The provider cannot create a Cockroach database connection because the proxy failed to start: %s", err),
+			)
+			return
+		}
+	}
+
 	// Create connection to cockroach cluster
 	cnx := generateConnectionString(data)
 	client := &CockroachClient{}
 	client.ConnectionString = &cnx
+	client.Retry = retry
+	client.Host = data.Host.ValueString()
+	client.Port = data.port()
+	client.SSLMode = data.sslMode()
+	if verifiesCertificate(client.SSLMode) {
+		client.CertPath = data.CertPath.ValueString()
+	}
+	client.TLS = newTLSMaterial(client.CertPath, data.SSLCert.ValueString(), data.SSLKey.ValueString())
+	client.ProxyAddress = data.ProxyAddress.ValueString()
+	client.DialTimeout = dialTimeout
+	client.KeepAlive = keepAlive
+	client.MaxConnLifetime = maxConnLifetime
+	client.ConnectRetry = connectRetry
+	client.MaxOpenConns = int(data.MaxOpenConns.ValueInt64())
+	client.MaxIdleConns = int(data.MaxIdleConns.ValueInt64())
+	client.DeletionProtection = data.DeletionProtection.ValueBool()
+	client.RecordStatements = data.RecordStatements.ValueBool()
+	client.FollowerReads = data.FollowerReads.ValueBool()
+	client.Database = data.database()
+	if data.GCPIAM != nil {
+		client.Tokens = newGCPTokenSource(data.GCPIAM)
+	}
+
+	if !data.SkipConnectivity.ValueBool() {
+		resp.Diagnostics.Append(checkConnectivity(ctx, client)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if !data.SkipConnectivity.ValueBool() && (policy.MinVersion != 0 || len(policy.CipherSuites) > 0) {
+		resp.Diagnostics.Append(checkTLSPolicy(ctx, client, policy)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if expected := data.ExpectedClusterID.ValueString(); expected != "" {
+		resp.Diagnostics.Append(verifyClusterID(ctx, client, expected)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	resp.Diagnostics.Append(preflight(ctx, client)...)
 
 	resp.DataSourceData = client
 	resp.ResourceData = client
+	resp.EphemeralResourceData = client
+}
+
+// sslModes are the values of the sslmode attribute
+var sslModes = []string{"disable", "require", "verify-ca", "verify-full"}
+
+// port is the configured port, 26257 by default
+func (m CockroachGKEProviderModel) port() int64 {
+	if m.Port.IsNull() || m.Port.IsUnknown() {
+		return defaultPort
+	}
+	return m.Port.ValueInt64()
 }
 
-// Not implemented
+// database is the configured database of the sessions, empty for the server default
+func (m CockroachGKEProviderModel) database() string {
+	if m.Database.ValueString() != "" {
+		return m.Database.ValueString()
+	}
+	return m.DefaultDatabase.ValueString()
+}
+
+// sslMode is the configured sslmode, verify-full by default
+func (m CockroachGKEProviderModel) sslMode() string {
+	if m.Insecure.ValueBool() {
+		return "disable"
+	}
+	if m.SSLMode.ValueString() == "" {
+		return defaultSSLMode
+	}
+	return m.SSLMode.ValueString()
+}
+
+// verifiesCertificate reports whether the sslmode checks the server certificate against certpath
+func verifiesCertificate(sslMode string) bool {
+	return sslMode == "verify-ca" || sslMode == "verify-full"
+}
+
+// envFallback returns the value of the environment variable when the attribute is not configured
+func envFallback(value types.String, env string) types.String {
+	if value.IsNull() {
+		if fromEnv, ok := os.LookupEnv(env); ok {
+			return types.StringValue(fromEnv)
+		}
+	}
+	return value
+}
+
+// parseDurationAttribute parses an optional duration such as 30s, zero when unset
+func parseDurationAttribute(value types.String, attribute string, diags *diag.Diagnostics) time.Duration {
+	if value.IsNull() {
+		return 0
+	}
+
+	duration, err := time.ParseDuration(value.ValueString())
+	if err != nil || duration < 0 {
+		diags.AddAttributeError(
+			path.Root(attribute),
+			"Invalid Cockroach "+strings.ReplaceAll(attribute, "_", " "),
+			fmt.Sprintf("The provider cannot create a Cockroach database connection because %q is not a valid duration such as 30s.", value.ValueString()),
+		)
+		return 0
+	}
+	return duration
+}
+
+// Assigns the data sources to the provider
 func (p *CockroachGKEProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
 	return []func() datasource.DataSource{
 		NewExampleDataSource,
+		NewScheduleDataSource,
+		NewConnectionInfoDataSource,
+		NewLabelsDataSource,
+		NewTableSizesDataSource,
+		NewCleanupDataSource,
+		NewAdvisoryLockDataSource,
+		NewRegionsDataSource,
+		NewClusterSettingsDataSource,
+		NewChangefeedsDataSource,
+		NewShowCreateDataSource,
+		NewProtectedTimestampsDataSource,
+		NewUnmanagedObjectsDataSource,
+		NewAccessReviewDataSource,
 	}
 }
 
@@ -194,17 +897,49 @@ func (p *CockroachGKEProvider) Resources(ctx context.Context) []func() resource.
 	return []func() resource.Resource{
 		NewDatabaseResource,
 		NewUserResource,
+		NewFunctionResource,
+		NewAdvisoryLockResource,
+		NewSystemSurvivalResource,
+		NewTableStatisticsResource,
+		NewTableStorageParamsResource,
+		NewCleanupRunResource,
+	}
+}
+
+// Assigns the ephemeral resources to the provider
+func (p *CockroachGKEProvider) EphemeralResources(ctx context.Context) []func() ephemeral.EphemeralResource {
+	return []func() ephemeral.EphemeralResource{
+		NewCredentialsEphemeralResource,
 	}
 }
 
-// TODO: Change SSL mode back to verify-full
 // Generates connection string for crdb
 func generateConnectionString(model CockroachGKEProviderModel) string {
-	cnxStr := fmt.Sprintf("postgres://%s:%s@%s:26257?sslmode=verify-full&sslrootcert=%s",
-		strings.Replace(model.Username.String(), "\"", "", -1),
-		strings.Replace(model.Password.String(), "\"", "", -1),
+	// Generated secrets, e.g. from vault, may contain characters with a meaning in URLs
+	cnxStr := fmt.Sprintf("postgres://%s@%s:%d/%s?sslmode=%s",
+		url.UserPassword(strings.Replace(model.Username.String(), "\"", "", -1), model.Password.ValueString()).String(),
 		strings.Replace(model.Host.String(), "\"", "", -1),
-		strings.Replace(model.CertPath.String(), "\"", "", -1),
+		model.port(),
+		url.PathEscape(model.database()),
+		model.sslMode(),
 	)
-	return cnxStr
+	// The driver verifies against a root certificate whenever one is given, even for require
+	if verifiesCertificate(model.sslMode()) {
+		cnxStr += "&sslrootcert=" + url.QueryEscape(model.CertPath.ValueString())
+	}
+	if model.SSLCert.ValueString() != "" {
+		cnxStr += "&sslcert=" + url.QueryEscape(model.SSLCert.ValueString()) + "&sslkey=" + url.QueryEscape(model.SSLKey.ValueString())
+	}
+	// The driver takes whole seconds
+	if timeout, err := time.ParseDuration(model.ConnectTimeout.ValueString()); err == nil && timeout > 0 {
+		cnxStr += fmt.Sprintf("&connect_timeout=%d", int64(math.Ceil(timeout.Seconds())))
+	}
+	// Unknown parameters are sent to the server as session variables
+	if model.SearchPath.ValueString() != "" {
+		cnxStr += "&search_path=" + url.QueryEscape(model.SearchPath.ValueString())
+	}
+	if timeout, err := time.ParseDuration(model.StatementTimeout.ValueString()); err == nil && timeout > 0 {
+		cnxStr += fmt.Sprintf("&statement_timeout=%d", timeout.Milliseconds())
+	}
+	return cnxStr + gssapiParams(model.GSSAPI)
 }
@@ -0,0 +1,74 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// proxyStartTimeout bounds how long we wait for a spawned proxy to accept connections
+const proxyStartTimeout = 30 * time.Second
+
+// proxyDialer sends every connection to a local auth proxy instead of the cockroach host. TLS is still negotiated
+// with the host from the connection string, so sslmode=verify-full keeps working through a plain TCP forwarder.
+type proxyDialer struct {
+	address string
+}
+
+// network picks a unix socket for absolute paths and TCP for host:port
+func (d proxyDialer) network() string {
+	if strings.HasPrefix(d.address, "/") {
+		return "unix"
+	}
+	return "tcp"
+}
+
+func (d proxyDialer) Dial(_, _ string) (net.Conn, error) {
+	return net.Dial(d.network(), d.address)
+}
+
+func (d proxyDialer) DialTimeout(_, _ string, timeout time.Duration) (net.Conn, error) {
+	return net.DialTimeout(d.network(), d.address, timeout)
+}
+
+func (d proxyDialer) DialContext(ctx context.Context, _, _ string) (net.Conn, error) {
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, d.network(), d.address)
+}
+
+// startProxy spawns the proxy command and waits until it accepts connections on address. The proxy keeps running
+// for the lifetime of the provider process.
+func startProxy(command []string, address string) error {
+	cmd := exec.Command(command[0], command[1:]...)
+	setProxyProcAttr(cmd)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	dialer := proxyDialer{address: address}
+	deadline := time.Now().Add(proxyStartTimeout)
+	for {
+		conn, err := dialer.DialTimeout("", "", time.Second)
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+
+		select {
+		case waitErr := <-exited:
+			return fmt.Errorf("proxy exited before accepting connections on %s: %v", address, waitErr)
+		case <-time.After(250 * time.Millisecond):
+		}
+
+		if time.Now().After(deadline) {
+			_ = cmd.Process.Kill()
+			return fmt.Errorf("proxy did not accept connections on %s within %s: %w", address, proxyStartTimeout, err)
+		}
+	}
+}
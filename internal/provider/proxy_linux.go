@@ -0,0 +1,13 @@
+//go:build linux
+
+package provider
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProxyProcAttr makes the kernel stop the proxy when the provider process goes away
+func setProxyProcAttr(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Pdeathsig: syscall.SIGTERM}
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package provider
+
+import "os/exec"
+
+// setProxyProcAttr is a no-op where the kernel can't tie the proxy's lifetime to ours
+func setProxyProcAttr(cmd *exec.Cmd) {}
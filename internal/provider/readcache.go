@@ -0,0 +1,149 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// readCache shares cluster-wide lookups between the refreshes of one provider instance. Terraform refreshes hundreds
+// of users and databases in parallel; the first refresh loads every user or database in one query and the others
+// look themselves up in the result. Any write through the provider empties the cache.
+type readCache struct {
+	mu sync.Mutex
+
+	// users maps usernames to user ids
+	users map[string]int64
+
+	// databases maps descriptor ids to database names
+	databases map[int64]string
+
+	// grants holds SHOW GRANTS FOR per database and user, which a user refresh otherwise runs several times
+	grants map[[2]string][]grantRow
+}
+
+// invalidate forgets everything, the next lookup loads from the cluster again
+func (r *readCache) invalidate() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users, r.databases, r.grants = nil, nil, nil
+}
+
+// userID returns the id of the user, loading all users with query (username, user_id) on first use. A missing user is
+// sql.ErrNoRows.
+func (r *readCache) userID(ctx context.Context, client Executor, query string, username string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.users == nil {
+		rows, err := client.QueryContext(ctx, query)
+		if err != nil {
+			return 0, err
+		}
+		defer rows.Close()
+
+		users := map[string]int64{}
+		for rows.Next() {
+			var name string
+			var id int64
+			if err := rows.Scan(&name, &id); err != nil {
+				return 0, err
+			}
+			users[name] = id
+		}
+		if err := rows.Err(); err != nil {
+			return 0, err
+		}
+		r.users = users
+	}
+
+	id, ok := r.users[username]
+	if !ok {
+		return 0, sql.ErrNoRows
+	}
+	return id, nil
+}
+
+// loadDatabases fills the databases with query (id, name), callers hold the lock
+func (r *readCache) loadDatabases(ctx context.Context, client Executor, query string) error {
+	if r.databases != nil {
+		return nil
+	}
+
+	rows, err := client.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	databases := map[int64]string{}
+	for rows.Next() {
+		var id int64
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return err
+		}
+		databases[id] = name
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	r.databases = databases
+	return nil
+}
+
+// databaseByID returns the name of the database with the descriptor id, a missing database is sql.ErrNoRows
+func (r *readCache) databaseByID(ctx context.Context, client Executor, query string, id int64) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.loadDatabases(ctx, client, query); err != nil {
+		return "", err
+	}
+	name, ok := r.databases[id]
+	if !ok {
+		return "", sql.ErrNoRows
+	}
+	return name, nil
+}
+
+// databaseByName returns the descriptor id of the database, a missing database is sql.ErrNoRows
+func (r *readCache) databaseByName(ctx context.Context, client Executor, query string, name string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.loadDatabases(ctx, client, query); err != nil {
+		return 0, err
+	}
+	for id, database := range r.databases {
+		if database == name {
+			return id, nil
+		}
+	}
+	return 0, sql.ErrNoRows
+}
+
+// showGrants runs SHOW GRANTS FOR the user in the database once
+func (r *readCache) showGrants(ctx context.Context, client Executor, database string, username string) ([]grantRow, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := [2]string{database, username}
+	if grants, ok := r.grants[key]; ok {
+		return grants, nil
+	}
+
+	grants, err := queryGrants(ctx, client, database, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.grants == nil {
+		r.grants = map[[2]string][]grantRow{}
+	}
+	r.grants[key] = grants
+	return grants, nil
+}
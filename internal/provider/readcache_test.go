@@ -0,0 +1,54 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+)
+
+func TestReadCache(t *testing.T) {
+	ctx := context.Background()
+	users := mockQuery{contains: "FROM system.users", columns: []string{"username", "user_id"}, rows: [][]driver.Value{{"app", int64(101)}, {"reporter", int64(102)}}}
+	client := newMockConn(t,
+		users,
+		mockQuery{contains: "FROM crdb_internal.databases", columns: []string{"id", "name"}, rows: [][]driver.Value{{int64(52), "app"}}},
+		mockQuery{contains: `SHOW GRANTS FOR "reporter"`, columns: []string{"database_name", "schema_name", "relation_name", "grantee", "privilege_type", "is_grantable"}, rows: [][]driver.Value{{"app", nil, nil, "reporter", "CONNECT", false}}},
+		mockQuery{contains: "DROP USER"},
+		users,
+	)
+	query := "SELECT username, user_id FROM system.users"
+
+	// Every lookup after the first is answered from the cache
+	if id, err := client.reads.userID(ctx, client, query, "app"); err != nil || id != 101 {
+		t.Errorf("expected user id 101, got %d (%v)", id, err)
+	}
+	if id, err := client.reads.userID(ctx, client, query, "reporter"); err != nil || id != 102 {
+		t.Errorf("expected user id 102, got %d (%v)", id, err)
+	}
+	if _, err := client.reads.userID(ctx, client, query, "missing"); err != sql.ErrNoRows {
+		t.Errorf("expected no rows for a missing user, got %v", err)
+	}
+
+	databases := "SELECT id, name FROM crdb_internal.databases"
+	if name, err := client.reads.databaseByID(ctx, client, databases, 52); err != nil || name != "app" {
+		t.Errorf("expected database app, got %q (%v)", name, err)
+	}
+	if id, err := client.reads.databaseByName(ctx, client, databases, "app"); err != nil || id != 52 {
+		t.Errorf("expected descriptor id 52, got %d (%v)", id, err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if grants, err := showGrants(ctx, client, "app", "reporter"); err != nil || len(grants) != 1 {
+			t.Errorf("expected one grant, got %v (%v)", grants, err)
+		}
+	}
+
+	// Writes empty the cache
+	if _, err := client.ExecContext(ctx, "DROP USER app"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.reads.userID(ctx, client, query, "app"); err != nil {
+		t.Errorf("expected users to be loaded again, got %v", err)
+	}
+}
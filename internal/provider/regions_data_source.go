@@ -0,0 +1,143 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/lib/pq"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &RegionsDataSource{}
+
+func NewRegionsDataSource() datasource.DataSource {
+	return &RegionsDataSource{}
+}
+
+// RegionsDataSource lists the regions and zones the nodes of the cluster run in.
+type RegionsDataSource struct {
+	db Database
+}
+
+// RegionsDataSourceModel describes the data source data model.
+type RegionsDataSourceModel struct {
+	Names   []types.String `tfsdk:"names"`
+	Regions []regionModel  `tfsdk:"regions"`
+}
+
+// regionModel is a single row of SHOW REGIONS FROM CLUSTER
+type regionModel struct {
+	Name  types.String   `tfsdk:"name"`
+	Zones []types.String `tfsdk:"zones"`
+}
+
+// Metadata appends the data source name to the provider name
+func (d *RegionsDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_regions"
+}
+
+// Schema is the shape of the data source - everything is computed
+func (d *RegionsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Regions and zones known to the cluster, e.g. to validate the regions of a multi-region database before applying it",
+		Attributes: map[string]schema.Attribute{
+			"names": schema.ListAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: "Names of all regions",
+				Computed:            true,
+			},
+			"regions": schema.ListNestedAttribute{
+				MarkdownDescription: "Regions with their zones",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
+							MarkdownDescription: "Name of the region, as set by the `--locality` flag of the nodes",
+							Computed:            true,
+						},
+						"zones": schema.ListAttribute{
+							ElementType:         types.StringType,
+							MarkdownDescription: "Availability zones in the region",
+							Computed:            true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Configure adds the provider configured client to the data source
+func (d *RegionsDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(Database)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *CockroachClient, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.db = client
+}
+
+// Read runs SHOW REGIONS FROM CLUSTER
+func (d *RegionsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data RegionsDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, err := d.db.Connect(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to connect to cockroach",
+			err.Error(),
+		)
+		return
+	}
+	defer client.Close()
+
+	rows, err := client.QueryContext(ctx, "SELECT region, zones FROM [SHOW REGIONS FROM CLUSTER] ORDER BY region")
+	if err != nil {
+		resp.Diagnostics.AddError("Read regions error", fmt.Sprintf("Unable to query regions, got error: %s", err))
+		return
+	}
+	defer rows.Close()
+
+	data.Names = []types.String{}
+	data.Regions = []regionModel{}
+	for rows.Next() {
+		var name string
+		var zones []string
+		if err := rows.Scan(&name, pq.Array(&zones)); err != nil {
+			resp.Diagnostics.AddError("Read regions error", fmt.Sprintf("Unable to scan region, got error: %s", err))
+			return
+		}
+
+		region := regionModel{Name: types.StringValue(name), Zones: []types.String{}}
+		for _, zone := range zones {
+			region.Zones = append(region.Zones, types.StringValue(zone))
+		}
+		data.Names = append(data.Names, region.Name)
+		data.Regions = append(data.Regions, region)
+	}
+	if err := rows.Err(); err != nil {
+		resp.Diagnostics.AddError("Read regions error", fmt.Sprintf("Unable to query regions, got error: %s", err))
+		return
+	}
+
+	tflog.Trace(ctx, "read regions")
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,365 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/lib/pq"
+)
+
+const (
+	defaultMaxRetries   = 3
+	defaultRetryBackoff = 500 * time.Millisecond
+
+	defaultConnectRetryInterval   = 5 * time.Second
+	defaultConnectRetryMaxElapsed = 2 * time.Minute
+)
+
+// retryableSQLStates are the error codes cockroach expects clients to retry on
+var retryableSQLStates = map[pq.ErrorCode]bool{
+	"40001": true, // serialization_failure, the transaction should be retried
+	"40003": true, // statement_completion_unknown, ambiguous result after a node failure
+	"57P01": true, // admin_shutdown, the node is draining
+}
+
+// idempotentKey marks a context whose statements may safely run twice
+type idempotentKey struct{}
+
+// idempotent marks the statements run with the returned context as safe to run twice, so ambiguous errors are retried
+// for them even when they are batches
+func idempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+// retryPolicy controls how statements are retried when cockroach reports a transient error
+type retryPolicy struct {
+	MaxRetries int
+	Backoff    time.Duration
+	FailFast   bool
+
+	// rotated reports whether the certificates changed on disk, certificate errors are retried if they did since
+	// the server may have switched to the new certificate first
+	rotated func() bool
+}
+
+// isRetryable classifies an error as transient
+func isRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		// Class 08 covers all connection exceptions
+		return retryableSQLStates[pqErr.Code] || pqErr.Code.Class() == "08"
+	}
+
+	var netErr net.Error
+	return errors.Is(err, driver.ErrBadConn) || errors.As(err, &netErr)
+}
+
+// isAmbiguous reports whether a statement may have been applied despite the error, so running it again could apply it
+// twice
+func isAmbiguous(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "40003" || pqErr.Code.Class() == "08"
+	}
+
+	// database/sql only reports a bad connection when nothing was sent on it
+	var netErr net.Error
+	return !errors.Is(err, driver.ErrBadConn) && errors.As(err, &netErr)
+}
+
+// isSingleStatement reports whether a query holds one statement, ignoring semicolons in strings including E” escape
+// strings, quoted identifiers, dollar quoted bodies, and -- and nested /* */ comments
+func isSingleStatement(query string) bool {
+	statements := 0
+	pending := false
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; {
+		case (c == 'E' || c == 'e') && strings.HasPrefix(query[i+1:], "'") && (i == 0 || !isIdentifierByte(query[i-1])):
+			end := escapeStringEnd(query[i+2:])
+			if end < 0 {
+				return statements == 0
+			}
+			i += end + 2
+			pending = true
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(query[i+1:], c)
+			if end < 0 {
+				return statements == 0
+			}
+			i += end + 1
+			pending = true
+		case c == '$':
+			tag := dollarTag.FindString(query[i:])
+			if tag == "" {
+				pending = true
+				continue
+			}
+			end := strings.Index(query[i+len(tag):], tag)
+			if end < 0 {
+				return statements == 0
+			}
+			i += len(tag) + end + len(tag) - 1
+			pending = true
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				i = len(query)
+				continue
+			}
+			i += end
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := blockCommentEnd(query[i:])
+			if end < 0 {
+				return statements == 0
+			}
+			i += end
+		case c == ';':
+			if pending {
+				statements++
+				pending = false
+			}
+		case !unicode.IsSpace(rune(c)):
+			pending = true
+		}
+	}
+	if pending {
+		statements++
+	}
+	return statements <= 1
+}
+
+// escapeStringEnd is the index of the quote closing an E” string whose opening quote came before s, backslashes
+// escape the next byte, -1 when unterminated
+func escapeStringEnd(s string) int {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '\'':
+			return i
+		}
+	}
+	return -1
+}
+
+// blockCommentEnd is the index of the last byte of the /* */ comment s starts with, which may nest, -1 when unterminated
+func blockCommentEnd(s string) int {
+	depth := 0
+	for i := 0; i+1 < len(s); i++ {
+		switch s[i : i+2] {
+		case "/*":
+			depth++
+			i++
+		case "*/":
+			depth--
+			i++
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// isIdentifierByte reports whether b can be part of an unquoted identifier, so an E before a quote is a name rather
+// than an escape string prefix
+func isIdentifierByte(b byte) bool {
+	return b == '_' || b == '$' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= 0x80
+}
+
+// dollarTag matches the opening of a dollar quoted string, e.g. $$ or $body$
+var dollarTag = regexp.MustCompile(`^\$[A-Za-z_]*\$`)
+
+// statementRetryable classifies the errors a statement is retried on. Ambiguous errors are only retried for single
+// statements or statements marked idempotent, a batch may have been partially applied.
+func statementRetryable(ctx context.Context, query string) func(error) bool {
+	if ctx.Value(idempotentKey{}) != nil || isSingleStatement(query) {
+		return isRetryable
+	}
+	return func(err error) bool {
+		return isRetryable(err) && !isAmbiguous(err)
+	}
+}
+
+// do runs fn until it succeeds, fails with a non transient error or runs out of retries. The backoff doubles each attempt.
+func (p retryPolicy) do(ctx context.Context, fn func() error) error {
+	return p.retryOn(ctx, isRetryable, fn)
+}
+
+// retryOn is do with the given classification of transient errors
+func (p retryPolicy) retryOn(ctx context.Context, retryable func(error) bool, fn func() error) error {
+	retries := p.MaxRetries
+	if p.FailFast {
+		retries = 0
+	}
+
+	backoff := p.Backoff
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= retries || !(retryable(err) || p.retryCertificate(err)) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// connectRetry keeps trying to reach the cluster when a connection can't be established, e.g. while its pods are still
+// starting. The zero value tries once.
+type connectRetry struct {
+	Attempts   int
+	Interval   time.Duration
+	MaxElapsed time.Duration
+}
+
+// connectRetryModel configures connectRetry
+type connectRetryModel struct {
+	Attempts       types.Int64  `tfsdk:"attempts"`
+	Interval       types.String `tfsdk:"interval"`
+	MaxElapsedTime types.String `tfsdk:"max_elapsed_time"`
+}
+
+// policy fills in the defaults of the configured connect retry, nil disables it
+func (m *connectRetryModel) policy(diags *diag.Diagnostics) connectRetry {
+	if m == nil {
+		return connectRetry{}
+	}
+
+	r := connectRetry{
+		Attempts:   int(m.Attempts.ValueInt64()),
+		Interval:   defaultConnectRetryInterval,
+		MaxElapsed: defaultConnectRetryMaxElapsed,
+	}
+	for attribute, value := range map[string]types.String{"interval": m.Interval, "max_elapsed_time": m.MaxElapsedTime} {
+		if value.IsNull() {
+			continue
+		}
+		duration, err := time.ParseDuration(value.ValueString())
+		if err != nil || duration <= 0 {
+			diags.AddAttributeError(
+				path.Root("connect_retry").AtName(attribute),
+				"Invalid Cockroach connect retry",
+				fmt.Sprintf("The provider cannot create a Cockroach database connection because %q is not a positive duration such as 30s.", value.ValueString()),
+			)
+			continue
+		}
+		if attribute == "interval" {
+			r.Interval = duration
+		} else {
+			r.MaxElapsed = duration
+		}
+	}
+	return r
+}
+
+// enabled reports whether connections are checked and retried before they are handed out
+func (r connectRetry) enabled() bool {
+	return r.MaxElapsed > 0
+}
+
+// do runs fn until it succeeds, fails with a non transient error, or the attempts or elapsed time run out
+func (r connectRetry) do(ctx context.Context, fn func() error) error {
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !isRetryable(err) {
+			return err
+		}
+		if (r.Attempts > 0 && attempt >= r.Attempts) || time.Since(start)+r.Interval > r.MaxElapsed {
+			return fmt.Errorf("gave up after %d attempts: %w", attempt, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(r.Interval):
+		}
+	}
+}
+
+// retryCertificate retries a certificate error caused by a rotation in progress
+func (p retryPolicy) retryCertificate(err error) bool {
+	return p.rotated != nil && isCertificateError(err) && p.rotated()
+}
+
+// CockroachConn is a connection pool to cockroach which retries transient errors
+type CockroachConn struct {
+	*sql.DB
+	retry    retryPolicy
+	versions *versionCache
+	reads    *readCache
+	recorder *statementRecorder
+
+	// database is where the sessions start, moved is set once a statement switched one of them elsewhere
+	database string
+	moved    atomic.Bool
+}
+
+// ExecContext runs a statement, retrying it according to the provider's retry policy until ctx is cancelled
+func (c *CockroachConn) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	var result sql.Result
+	err := c.retry.retryOn(ctx, statementRetryable(ctx, query), func() error {
+		var err error
+		result, err = c.DB.ExecContext(ctx, query, args...)
+		return err
+	})
+	c.reads.invalidate()
+	if err == nil {
+		c.recorder.record(query)
+	}
+	return result, err
+}
+
+// BeginTx starts a transaction, which may write, so the read cache is emptied
+func (c *CockroachConn) BeginTx(ctx context.Context, opts *sql.TxOptions) (Tx, error) {
+	c.reads.invalidate()
+	return c.DB.BeginTx(ctx, opts)
+}
+
+// cache is the read cache shared with the other connections of the provider
+func (c *CockroachConn) cache() *readCache {
+	return c.reads
+}
+
+// QueryContext runs a query, retrying it according to the provider's retry policy until ctx is cancelled
+func (c *CockroachConn) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	var rows *sql.Rows
+	err := c.retry.retryOn(ctx, statementRetryable(ctx, query), func() error {
+		var err error
+		rows, err = c.DB.QueryContext(ctx, query, args...)
+		return err
+	})
+	return rows, err
+}
+
+// QueryRowContext runs a query expected to return at most one row, retrying it according to the provider's retry
+// policy until ctx is cancelled. Errors surface on Scan as with *sql.DB.
+func (c *CockroachConn) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	var row *sql.Row
+	_ = c.retry.retryOn(ctx, statementRetryable(ctx, query), func() error {
+		row = c.DB.QueryRowContext(ctx, query, args...)
+		return row.Err()
+	})
+	return row
+}
@@ -0,0 +1,167 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestIsRetryable(t *testing.T) {
+	cases := map[string]struct {
+		err  error
+		want bool
+	}{
+		"nil":                   {nil, false},
+		"serialization failure": {&pq.Error{Code: "40001"}, true},
+		"connection exception":  {&pq.Error{Code: "08006"}, true},
+		"syntax error":          {&pq.Error{Code: "42601"}, false},
+		"no rows":               {sql.ErrNoRows, false},
+	}
+
+	for name, c := range cases {
+		if got := isRetryable(c.err); got != c.want {
+			t.Errorf("%s: isRetryable() = %v, want %v", name, got, c.want)
+		}
+	}
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	transient := &pq.Error{Code: "40001"}
+
+	calls := 0
+	err := retryPolicy{MaxRetries: 2}.do(context.Background(), func() error {
+		calls++
+		return transient
+	})
+	if !errors.Is(err, transient) || calls != 3 {
+		t.Errorf("expected 3 calls ending in the transient error, got %d calls and %v", calls, err)
+	}
+
+	calls = 0
+	_ = retryPolicy{MaxRetries: 2, FailFast: true}.do(context.Background(), func() error {
+		calls++
+		return transient
+	})
+	if calls != 1 {
+		t.Errorf("expected fail fast to stop after 1 call, got %d", calls)
+	}
+}
+
+func TestRetryPolicyDoCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := 0
+	_ = retryPolicy{MaxRetries: 5, Backoff: time.Hour}.do(ctx, func() error {
+		calls++
+		return &pq.Error{Code: "40001"}
+	})
+	if calls != 1 {
+		t.Errorf("expected a cancelled context to stop retrying after 1 call, got %d", calls)
+	}
+}
+
+// startingConnector refuses connections until it has been dialed a number of times, like a cluster whose pods are
+// still starting
+type startingConnector struct {
+	*mockConnector
+	refusals int
+}
+
+func (c *startingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	if c.refusals > 0 {
+		c.refusals--
+		return nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	}
+	return c.mockConnector.Connect(ctx)
+}
+
+func TestConnectRetry(t *testing.T) {
+	client := newMockClient(t)
+	client.connector = &startingConnector{mockConnector: client.connector.(*mockConnector), refusals: 2}
+	client.ConnectRetry = connectRetry{Interval: time.Millisecond, MaxElapsed: time.Minute}
+
+	conn, err := client.Connect(context.Background())
+	if err != nil {
+		t.Fatalf("expected the third attempt to connect, got %v", err)
+	}
+	conn.Close()
+
+	client.connector = &startingConnector{mockConnector: client.connector.(*startingConnector).mockConnector, refusals: 5}
+	client.ConnectRetry.Attempts = 3
+	if _, err := client.Connect(context.Background()); err == nil {
+		t.Error("expected to give up after 3 attempts")
+	}
+}
+
+func TestIsSingleStatement(t *testing.T) {
+	cases := map[string]bool{
+		"SELECT 1":                               true,
+		"SELECT 1;":                              true,
+		"SET DATABASE=app; CREATE USER bob":      false,
+		"SELECT ';' FROM t":                      true,
+		`CREATE USER "a;b"`:                      true,
+		"CREATE FUNCTION f() AS $$ SELECT 1; $$": true,
+		"SELECT $1 -- first; second":             true,
+		"SELECT 1; -- trailing comment":          true,
+		"SELECT 1;\nSELECT 2":                    false,
+		"SELECT 1 /* first; second */":           true,
+		"SELECT /* outer /* inner; */ ; */ 1":    true,
+		"SELECT 1 /* done */; SELECT 2":          false,
+		`SELECT E'it\'s; fine'`:                  true,
+		`SELECT e'\\'; SELECT 2`:                 false,
+		`SELECT name'; x'`:                       true,
+	}
+
+	for query, want := range cases {
+		if got := isSingleStatement(query); got != want {
+			t.Errorf("isSingleStatement(%q) = %v, want %v", query, got, want)
+		}
+	}
+}
+
+func TestCockroachConnRetriesOnlySafeStatements(t *testing.T) {
+	ambiguous := &pq.Error{Code: "40003"}
+	batch := "SET DATABASE=app; CREATE USER bob"
+
+	conn := newMockConn(t,
+		mockQuery{contains: "SELECT 1", err: &pq.Error{Code: "40001"}},
+		mockQuery{contains: "SELECT 1", columns: []string{"?column?"}, rows: [][]driver.Value{{int64(1)}}},
+		mockQuery{contains: batch, err: ambiguous},
+		mockQuery{contains: batch, err: ambiguous},
+		mockQuery{contains: batch},
+	)
+	conn.retry = retryPolicy{MaxRetries: 2}
+
+	var one int64
+	if err := conn.QueryRowContext(context.Background(), "SELECT 1").Scan(&one); err != nil || one != 1 {
+		t.Errorf("expected QueryRowContext to retry the serialization failure, got %d and %v", one, err)
+	}
+
+	// The batch may have created the user before the node failed, so it isn't run again
+	if _, err := conn.ExecContext(context.Background(), batch); !errors.Is(err, ambiguous) {
+		t.Errorf("expected the ambiguous error without a retry, got %v", err)
+	}
+
+	if _, err := conn.ExecContext(idempotent(context.Background()), batch); err != nil {
+		t.Errorf("expected an idempotent batch to be retried, got %v", err)
+	}
+}
+
+func TestConnectRetryCancelled(t *testing.T) {
+	client := newMockClient(t)
+	client.connector = &startingConnector{mockConnector: client.connector.(*mockConnector), refusals: 5}
+	client.ConnectRetry = connectRetry{Interval: time.Hour, MaxElapsed: 24 * time.Hour}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.Connect(ctx); err == nil {
+		t.Error("expected a cancelled operation to stop waiting for the cluster")
+	}
+}
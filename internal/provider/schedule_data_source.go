@@ -0,0 +1,171 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &ScheduleDataSource{}
+
+func NewScheduleDataSource() datasource.DataSource {
+	return &ScheduleDataSource{}
+}
+
+// ScheduleDataSource lists the scheduled jobs (backups, row level TTL, changefeed exports) of the cluster.
+type ScheduleDataSource struct {
+	db Database
+}
+
+// ScheduleDataSourceModel describes the data source data model.
+type ScheduleDataSourceModel struct {
+	Label     types.String    `tfsdk:"label"`
+	Schedules []scheduleModel `tfsdk:"schedules"`
+}
+
+// scheduleModel is a single row of SHOW SCHEDULES
+type scheduleModel struct {
+	ID         types.Int64  `tfsdk:"id"`
+	Label      types.String `tfsdk:"label"`
+	Status     types.String `tfsdk:"status"`
+	Recurrence types.String `tfsdk:"recurrence"`
+	NextRun    types.String `tfsdk:"next_run"`
+}
+
+// Metadata appends the data source name to the provider name
+func (d *ScheduleDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_schedule"
+}
+
+// Schema is the shape of the data source - what you can filter on and what you get back
+func (d *ScheduleDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Schedules of the cluster such as backups, row level TTL and changefeed exports",
+		Attributes: map[string]schema.Attribute{
+			"label": schema.StringAttribute{
+				MarkdownDescription: "Only return schedules with this label",
+				Optional:            true,
+			},
+			"schedules": schema.ListNestedAttribute{
+				MarkdownDescription: "Matching schedules",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"id": schema.Int64Attribute{
+							MarkdownDescription: "Schedule id",
+							Computed:            true,
+						},
+						"label": schema.StringAttribute{
+							MarkdownDescription: "Schedule label",
+							Computed:            true,
+						},
+						"status": schema.StringAttribute{
+							MarkdownDescription: "Schedule status, e.g. `ACTIVE` or `PAUSED`",
+							Computed:            true,
+						},
+						"recurrence": schema.StringAttribute{
+							MarkdownDescription: "Cron expression of the schedule",
+							Computed:            true,
+						},
+						"next_run": schema.StringAttribute{
+							MarkdownDescription: "RFC3339 timestamp of the next run, empty when paused",
+							Computed:            true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Configure adds the provider configured client to the data source
+func (d *ScheduleDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(Database)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *CockroachClient, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.db = client
+}
+
+// Read lists the schedules, optionally only those with a given label
+func (d *ScheduleDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data ScheduleDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, err := d.db.ConnectForRead(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to connect to cockroach",
+			err.Error(),
+		)
+		return
+	}
+	defer client.Close()
+
+	q := "SELECT id, label, schedule_status, recurrence, next_run FROM [SHOW SCHEDULES]"
+	args := []any{}
+	if !data.Label.IsNull() {
+		q += " WHERE label = $1"
+		args = append(args, data.Label.ValueString())
+	}
+	q += " ORDER BY id"
+
+	rows, err := client.QueryContext(ctx, q, args...)
+	if err != nil {
+		resp.Diagnostics.AddError("Read schedules error", fmt.Sprintf("Unable to list schedules, got error: %s", err))
+		return
+	}
+	defer rows.Close()
+
+	data.Schedules = []scheduleModel{}
+	for rows.Next() {
+		var id int64
+		var label, status string
+		var recurrence sql.NullString
+		var nextRun sql.NullTime
+		if err := rows.Scan(&id, &label, &status, &recurrence, &nextRun); err != nil {
+			resp.Diagnostics.AddError("Read schedules error", fmt.Sprintf("Unable to scan schedule, got error: %s", err))
+			return
+		}
+
+		schedule := scheduleModel{
+			ID:         types.Int64Value(id),
+			Label:      types.StringValue(label),
+			Status:     types.StringValue(status),
+			Recurrence: types.StringValue(recurrence.String),
+			NextRun:    types.StringValue(""),
+		}
+		if nextRun.Valid {
+			schedule.NextRun = types.StringValue(nextRun.Time.UTC().Format(time.RFC3339))
+		}
+		data.Schedules = append(data.Schedules, schedule)
+	}
+	if err := rows.Err(); err != nil {
+		resp.Diagnostics.AddError("Read schedules error", fmt.Sprintf("Unable to list schedules, got error: %s", err))
+		return
+	}
+
+	tflog.Trace(ctx, "read schedules")
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
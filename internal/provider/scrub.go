@@ -0,0 +1,60 @@
+package provider
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// redacted replaces secrets in diagnostics and logs
+const redacted = "*****"
+
+// secretPatterns catch secrets by their position in a statement or connection string, even when we don't know the
+// secret itself, e.g. because cockroach echoed part of a statement back in a syntax error.
+var secretPatterns = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`(?i)(PASSWORD\s*=?\s*)E?'(?:[^']|'')*'?`), "${1}'" + redacted + "'"},
+	{regexp.MustCompile(`(?i)(PASSWORD\s*=?\s*)"(?:[^"]|"")*"?`), "${1}\"" + redacted + "\""},
+	{regexp.MustCompile(`(://[^:/@\s]*:)[^@\s]*@`), "${1}" + redacted + "@"},
+	{regexp.MustCompile(`(?i)((?:password|sslpassword|token)=)[^&\s]*`), "${1}" + redacted},
+}
+
+// scrubSecrets removes the given secrets and anything that looks like a password or token from text
+func scrubSecrets(text string, secrets ...string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			text = strings.ReplaceAll(text, secret, redacted)
+		}
+	}
+	for _, p := range secretPatterns {
+		text = p.pattern.ReplaceAllString(text, p.replacement)
+	}
+	return text
+}
+
+// scrubError is the message of err with secrets removed, for use in diagnostics
+func scrubError(err error, secrets ...string) string {
+	if err == nil {
+		return ""
+	}
+	return scrubSecrets(err.Error(), secrets...)
+}
+
+// maskSecrets keeps the secrets out of everything logged with the returned context
+func maskSecrets(ctx context.Context, secrets ...string) context.Context {
+	nonEmpty := []string{}
+	for _, secret := range secrets {
+		if secret != "" {
+			nonEmpty = append(nonEmpty, secret)
+		}
+	}
+	if len(nonEmpty) == 0 {
+		return ctx
+	}
+	ctx = tflog.MaskMessageStrings(ctx, nonEmpty...)
+	return tflog.MaskAllFieldValuesStrings(ctx, nonEmpty...)
+}
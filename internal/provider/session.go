@@ -0,0 +1,26 @@
+package provider
+
+import (
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// useDatabase prefixes statements resolving names in the database with SET DATABASE. Sessions start in the provider's
+// database, statements there need no prefix until a statement moved one of the pooled sessions elsewhere.
+func useDatabase(client Executor, database string) string {
+	if conn, ok := client.(*CockroachConn); ok && conn.database != "" {
+		if database == conn.database && !conn.moved.Load() {
+			return ""
+		}
+		movedTo(client, database)
+	}
+	return fmt.Sprintf("SET DATABASE=%s; ", pq.QuoteIdentifier(database))
+}
+
+// movedTo records that a statement switches a session of the connection to the database
+func movedTo(client Executor, database string) {
+	if conn, ok := client.(*CockroachConn); ok && database != conn.database {
+		conn.moved.Store(true)
+	}
+}
@@ -0,0 +1,31 @@
+package provider
+
+import (
+	"context"
+	"testing"
+)
+
+func TestUseDatabase(t *testing.T) {
+	client := newMockClient(t)
+	client.Database = "app"
+	conn, err := client.Connect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if use := useDatabase(conn, "app"); use != "" {
+		t.Errorf("sessions start in the provider database, got %q", use)
+	}
+	if use := useDatabase(conn, "reporting"); use != `SET DATABASE="reporting"; ` {
+		t.Errorf("unexpected prefix %q", use)
+	}
+	// A pooled session may still be in reporting
+	if use := useDatabase(conn, "app"); use != `SET DATABASE="app"; ` {
+		t.Errorf("expected a switch back after moving, got %q", use)
+	}
+
+	if use := useDatabase(newMockConn(t), "app"); use != `SET DATABASE="app"; ` {
+		t.Errorf("expected a switch without a provider database, got %q", use)
+	}
+}
@@ -0,0 +1,133 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/lib/pq"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &ShowCreateDataSource{}
+
+func NewShowCreateDataSource() datasource.DataSource {
+	return &ShowCreateDataSource{}
+}
+
+// ShowCreateDataSource returns the canonical CREATE statement of an object.
+type ShowCreateDataSource struct {
+	db Database
+}
+
+// ShowCreateDataSourceModel describes the data source data model.
+type ShowCreateDataSourceModel struct {
+	ObjectType      types.String `tfsdk:"object_type"`
+	Name            types.String `tfsdk:"name"`
+	Database        types.String `tfsdk:"database"`
+	CreateStatement types.String `tfsdk:"create_statement"`
+}
+
+// showCreateObjectTypes are the object types SHOW CREATE accepts
+var showCreateObjectTypes = []string{"table", "view", "sequence", "database"}
+
+// Metadata appends the data source name to the provider name
+func (d *ShowCreateDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_show_create"
+}
+
+// Schema is the shape of the data source - what you need to supply and what you get back
+func (d *ShowCreateDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Canonical CREATE statement of an existing object, e.g. to audit drift or to seed resources from objects made by hand",
+		Attributes: map[string]schema.Attribute{
+			"object_type": schema.StringAttribute{
+				MarkdownDescription: "One of `table`, `view`, `sequence` and `database`",
+				Required:            true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(showCreateObjectTypes...),
+				},
+			},
+			"name": schema.StringAttribute{
+				MarkdownDescription: "Name of the object, optionally qualified with its schema, e.g. `audit.events`",
+				Required:            true,
+			},
+			"database": schema.StringAttribute{
+				MarkdownDescription: "Database of a table, view or sequence, defaults to the database of the provider connection",
+				Optional:            true,
+			},
+			"create_statement": schema.StringAttribute{
+				MarkdownDescription: "Statement which creates the object as it is now",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+// Configure adds the provider configured client to the data source
+func (d *ShowCreateDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(Database)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *CockroachClient, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.db = client
+}
+
+// showCreateStatement builds the SHOW CREATE statement, quoting every part of the name
+func (m ShowCreateDataSourceModel) showCreateStatement() string {
+	parts := []string{}
+	if m.Database.ValueString() != "" && m.ObjectType.ValueString() != "database" {
+		parts = append(parts, pq.QuoteIdentifier(m.Database.ValueString()))
+	}
+	for _, part := range strings.Split(m.Name.ValueString(), ".") {
+		parts = append(parts, pq.QuoteIdentifier(part))
+	}
+	return fmt.Sprintf("SHOW CREATE %s %s", strings.ToUpper(m.ObjectType.ValueString()), strings.Join(parts, "."))
+}
+
+// Read runs SHOW CREATE for the object
+func (d *ShowCreateDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data ShowCreateDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, err := d.db.ConnectForRead(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to connect to cockroach",
+			err.Error(),
+		)
+		return
+	}
+	defer client.Close()
+
+	var name, statement string
+	err = client.QueryRowContext(ctx, data.showCreateStatement()).Scan(&name, &statement)
+	if err != nil {
+		resp.Diagnostics.AddError("Read show create error", fmt.Sprintf("Unable to show the create statement of %s %s, got error: %s", data.ObjectType.ValueString(), data.Name.ValueString(), err))
+		return
+	}
+	data.CreateStatement = types.StringValue(statement)
+
+	tflog.Trace(ctx, "read create statement")
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,39 @@
+package provider
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestShowCreateStatement(t *testing.T) {
+	for _, tc := range []struct {
+		model    ShowCreateDataSourceModel
+		expected string
+	}{
+		{ShowCreateDataSourceModel{ObjectType: types.StringValue("table"), Name: types.StringValue("orders")}, `SHOW CREATE TABLE "orders"`},
+		{ShowCreateDataSourceModel{ObjectType: types.StringValue("view"), Name: types.StringValue("audit.Events"), Database: types.StringValue("app")}, `SHOW CREATE VIEW "app"."audit"."Events"`},
+		{ShowCreateDataSourceModel{ObjectType: types.StringValue("database"), Name: types.StringValue("app"), Database: types.StringValue("other")}, `SHOW CREATE DATABASE "app"`},
+	} {
+		if statement := tc.model.showCreateStatement(); statement != tc.expected {
+			t.Errorf("expected %s, got %s", tc.expected, statement)
+		}
+	}
+}
+
+func TestShowCreateDataSourceRead(t *testing.T) {
+	data := readDataSource[ShowCreateDataSourceModel](t, &ShowCreateDataSource{},
+		map[string]any{"object_type": "sequence", "name": "order_ids", "database": "app"},
+		mockQuery{
+			contains: `SHOW CREATE SEQUENCE "app"."order_ids"`,
+			columns:  []string{"table_name", "create_statement"},
+			rows: [][]driver.Value{
+				{"order_ids", "CREATE SEQUENCE public.order_ids MINVALUE 1 MAXVALUE 9223372036854775807 INCREMENT 1 START 1"},
+			},
+		},
+	)
+	if data.CreateStatement.ValueString() != "CREATE SEQUENCE public.order_ids MINVALUE 1 MAXVALUE 9223372036854775807 INCREMENT 1 START 1" {
+		t.Errorf("unexpected create statement %q", data.CreateStatement.ValueString())
+	}
+}
@@ -0,0 +1,76 @@
+package provider
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// appliedStatementType is an element of last_applied_statements
+var appliedStatementType = types.ObjectType{AttrTypes: map[string]attr.Type{
+	"statement":   types.StringType,
+	"executed_at": types.StringType,
+}}
+
+// appliedStatement is a statement run during an apply, with secrets scrubbed
+type appliedStatement struct {
+	Statement  string `tfsdk:"statement"`
+	ExecutedAt string `tfsdk:"executed_at"`
+}
+
+// statementRecorder collects the statements a connection runs, it's only set when the provider has
+// record_statements enabled
+type statementRecorder struct {
+	mu         sync.Mutex
+	statements []appliedStatement
+}
+
+// record keeps a successfully executed statement, it does nothing on a nil recorder
+func (r *statementRecorder) record(query string) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statements = append(r.statements, appliedStatement{
+		Statement:  scrubSecrets(query),
+		ExecutedAt: time.Now().UTC().Format(time.RFC3339Nano),
+	})
+}
+
+// appliedStatements is the value of last_applied_statements, null unless the provider records statements
+func (c *CockroachConn) appliedStatements(ctx context.Context) (types.List, diag.Diagnostics) {
+	if c.recorder == nil {
+		return types.ListNull(appliedStatementType), nil
+	}
+
+	c.recorder.mu.Lock()
+	defer c.recorder.mu.Unlock()
+	return types.ListValueFrom(ctx, appliedStatementType, c.recorder.statements)
+}
+
+// lastAppliedStatementsAttribute is shared by the resources which run DDL
+func lastAppliedStatementsAttribute() schema.ListNestedAttribute {
+	return schema.ListNestedAttribute{
+		MarkdownDescription: "Statements run by the last create or update, with secrets scrubbed. Only recorded when the provider sets `record_statements`, statements run inside transactions such as `init_sql` are left out",
+		Computed:            true,
+		NestedObject: schema.NestedAttributeObject{
+			Attributes: map[string]schema.Attribute{
+				"statement": schema.StringAttribute{
+					MarkdownDescription: "The statement",
+					Computed:            true,
+				},
+				"executed_at": schema.StringAttribute{
+					MarkdownDescription: "RFC 3339 time the statement completed",
+					Computed:            true,
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,44 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestAppliedStatements(t *testing.T) {
+	ctx := context.Background()
+
+	client := newMockClient(t, mockQuery{contains: "ALTER USER"})
+	client.RecordStatements = true
+	conn, err := client.Connect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `ALTER USER "app" WITH PASSWORD 'hunter2'`); err != nil {
+		t.Fatal(err)
+	}
+
+	list, diags := conn.appliedStatements(ctx)
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	statements := []appliedStatement{}
+	diags = list.ElementsAs(ctx, &statements, false)
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	if len(statements) != 1 || statements[0].ExecutedAt == "" {
+		t.Fatalf("expected one recorded statement, got %v", statements)
+	}
+	if strings.Contains(statements[0].Statement, "hunter2") {
+		t.Errorf("password leaked into the recorded statement: %s", statements[0].Statement)
+	}
+
+	unrecorded := newMockConn(t)
+	if list, _ := unrecorded.appliedStatements(ctx); !list.IsNull() {
+		t.Errorf("expected null without record_statements, got %s", list)
+	}
+}
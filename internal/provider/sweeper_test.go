@@ -0,0 +1,154 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/lib/pq"
+)
+
+// testAccPrefix starts the name of every object created by acceptance tests, sweepers drop anything matching it
+const testAccPrefix = "tf-acc-"
+
+// testAccSweepURLEnv holds the connection string of the shared test cluster for sweepers
+const testAccSweepURLEnv = "COCKROACHGKE_SWEEP_URL"
+
+func TestMain(m *testing.M) {
+	resource.TestMain(m)
+}
+
+func init() {
+	resource.AddTestSweepers("cockroachgke_changefeed", &resource.Sweeper{
+		Name: "cockroachgke_changefeed",
+		F:    sweepChangefeeds,
+	})
+	resource.AddTestSweepers("cockroachgke_database", &resource.Sweeper{
+		Name:         "cockroachgke_database",
+		F:            sweepDatabases,
+		Dependencies: []string{"cockroachgke_changefeed"},
+	})
+	resource.AddTestSweepers("cockroachgke_user", &resource.Sweeper{
+		Name:         "cockroachgke_user",
+		F:            sweepUsers,
+		Dependencies: []string{"cockroachgke_database"},
+	})
+}
+
+// sweepClient connects to the test cluster, the region passed by the harness has no meaning for cockroach
+func sweepClient() (*sql.DB, error) {
+	url := os.Getenv(testAccSweepURLEnv)
+	if url == "" {
+		return nil, fmt.Errorf("%s must be set to run sweepers", testAccSweepURLEnv)
+	}
+	return sql.Open("postgres", url)
+}
+
+// sweepNames lists the first column of every row of the query
+func sweepNames(ctx context.Context, client *sql.DB, query string, args ...any) ([]string, error) {
+	rows, err := client.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// sweepChangefeeds cancels changefeeds on tables of test databases, so dropping them isn't held up
+func sweepChangefeeds(_ string) error {
+	ctx := context.Background()
+	client, err := sweepClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	jobs, err := sweepNames(ctx, client, `SELECT job_id::STRING FROM [SHOW CHANGEFEED JOBS]
+		WHERE status IN ('running', 'paused', 'pending') AND EXISTS (SELECT 1 FROM unnest(full_table_names) AS t WHERE t LIKE $1)`, testAccPrefix+"%")
+	if err != nil {
+		return fmt.Errorf("unable to list changefeeds: %w", err)
+	}
+
+	var errs []error
+	for _, job := range jobs {
+		if _, err := client.ExecContext(ctx, "CANCEL JOB "+job); err != nil {
+			errs = append(errs, fmt.Errorf("unable to cancel changefeed %s: %w", job, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sweepDatabases drops test databases with everything in them
+func sweepDatabases(_ string) error {
+	ctx := context.Background()
+	client, err := sweepClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	databases, err := sweepNames(ctx, client, "SELECT datname FROM pg_catalog.pg_database WHERE datname LIKE $1", testAccPrefix+"%")
+	if err != nil {
+		return fmt.Errorf("unable to list databases: %w", err)
+	}
+	// Labels are deleted with each object, the table only exists once something was labeled
+	if err := ensureLabelsTable(ctx, client); err != nil {
+		return fmt.Errorf("unable to create the labels table: %w", err)
+	}
+
+	var errs []error
+	for _, database := range databases {
+		if _, err := client.ExecContext(ctx, fmt.Sprintf("DROP DATABASE IF EXISTS %s CASCADE", pq.QuoteIdentifier(database))); err != nil {
+			errs = append(errs, fmt.Errorf("unable to drop database %s: %w", database, err))
+			continue
+		}
+		if diags := deleteLabels(ctx, client, labelObjectDatabase, database); diags.HasError() {
+			errs = append(errs, fmt.Errorf("unable to delete labels of database %s: %v", database, diags))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sweepUsers drops test users, after their databases are gone so no grants are left holding them
+func sweepUsers(_ string) error {
+	ctx := context.Background()
+	client, err := sweepClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	users, err := sweepNames(ctx, client, "SELECT username FROM [SHOW USERS] WHERE username LIKE $1", testAccPrefix+"%")
+	if err != nil {
+		return fmt.Errorf("unable to list users: %w", err)
+	}
+	// Labels are deleted with each object, the table only exists once something was labeled
+	if err := ensureLabelsTable(ctx, client); err != nil {
+		return fmt.Errorf("unable to create the labels table: %w", err)
+	}
+
+	var errs []error
+	for _, user := range users {
+		if _, err := client.ExecContext(ctx, fmt.Sprintf("DROP USER IF EXISTS %s", pq.QuoteIdentifier(user))); err != nil {
+			errs = append(errs, fmt.Errorf("unable to drop user %s: %w", user, err))
+			continue
+		}
+		if diags := deleteLabels(ctx, client, labelObjectUser, user); diags.HasError() {
+			errs = append(errs, fmt.Errorf("unable to delete labels of user %s: %v", user, diags))
+		}
+	}
+	return errors.Join(errs...)
+}
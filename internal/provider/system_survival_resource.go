@@ -0,0 +1,278 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/lib/pq"
+	"golang.org/x/exp/slices"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &SystemSurvivalResource{}
+var _ resource.ResourceWithValidateConfig = &SystemSurvivalResource{}
+
+func NewSystemSurvivalResource() resource.Resource {
+	return &SystemSurvivalResource{}
+}
+
+// SystemSurvivalResource manages the regions and survival goal of the system database during multi-region enablement.
+type SystemSurvivalResource struct {
+	db Database
+}
+
+// SystemSurvivalResourceModel describes the resource data model.
+type SystemSurvivalResourceModel struct {
+	PrimaryRegion         types.String `tfsdk:"primary_region"`
+	Regions               types.Set    `tfsdk:"regions"`
+	SurvivalGoal          types.String `tfsdk:"survival_goal"`
+	ConfirmDangerous      types.Bool   `tfsdk:"confirm_dangerous"`
+	LastAppliedStatements types.List   `tfsdk:"last_applied_statements"`
+}
+
+// Metadata appends the resource name to the provider name
+func (r *SystemSurvivalResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_system_database_survival"
+}
+
+// Schema is the shape of the resource - what you need to supply
+func (r *SystemSurvivalResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Regions and survival goal of the `system` database. Mistakes here can make the whole cluster unavailable, so every change has to be confirmed with `confirm_dangerous`. Destroying the resource leaves the system database as it is",
+		Attributes: map[string]schema.Attribute{
+			"primary_region": schema.StringAttribute{
+				MarkdownDescription: "Primary region of the system database",
+				Required:            true,
+			},
+			"regions": schema.SetAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: "All regions of the system database, including `primary_region`. Regions are added and dropped to match",
+				Optional:            true,
+			},
+			"survival_goal": schema.StringAttribute{
+				MarkdownDescription: "`zone` or `region`, defaults to `zone`. Surviving a region failure needs at least three regions",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString("zone"),
+				Validators:          []validator.String{stringvalidator.OneOf("zone", "region")},
+			},
+			"confirm_dangerous": schema.BoolAttribute{
+				MarkdownDescription: "Must be true, acknowledges that the change affects the availability of the whole cluster",
+				Required:            true,
+			},
+			"last_applied_statements": lastAppliedStatementsAttribute(),
+		},
+	}
+}
+
+// ValidateConfig refuses to plan anything without confirm_dangerous
+func (r *SystemSurvivalResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var data SystemSurvivalResourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() || data.ConfirmDangerous.IsUnknown() {
+		return
+	}
+
+	if !data.ConfirmDangerous.ValueBool() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("confirm_dangerous"),
+			"Dangerous change not confirmed",
+			"Changing the regions or survival goal of the system database affects the availability of the whole cluster. Set confirm_dangerous = true once the change has been reviewed.",
+		)
+	}
+}
+
+// Configure adds the provider configured client to the resource
+func (r *SystemSurvivalResource) Configure(_ context.Context, req resource.ConfigureRequest, _ *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	r.db = req.ProviderData.(Database)
+}
+
+// Create applies the configuration to the system database
+func (r *SystemSurvivalResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data *SystemSurvivalResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(r.apply(ctx, data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tflog.Trace(ctx, "configured the system database survival")
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Read compares the regions and survival goal of the system database
+func (r *SystemSurvivalResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data *SystemSurvivalResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, err := r.db.Connect(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to connect to cockroach",
+			err.Error(),
+		)
+		return
+	}
+	defer client.Close()
+
+	primary, regions, err := systemRegions(ctx, client)
+	if err != nil {
+		resp.Diagnostics.AddError("Read system database error", fmt.Sprintf("Unable to read regions, got error: %s", err))
+		return
+	}
+	data.PrimaryRegion = types.StringValue(primary)
+	if !data.Regions.IsNull() {
+		set, diags := types.SetValueFrom(ctx, types.StringType, regions)
+		resp.Diagnostics.Append(diags...)
+		data.Regions = set
+	}
+
+	var goal sql.NullString
+	err = client.QueryRowContext(ctx, "SELECT survival_goal FROM [SHOW SURVIVAL GOAL FROM DATABASE system]").Scan(&goal)
+	if err != nil {
+		resp.Diagnostics.AddError("Read system database error", fmt.Sprintf("Unable to read survival goal, got error: %s", err))
+		return
+	}
+	data.SurvivalGoal = types.StringValue(goal.String)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Update applies the changed configuration to the system database
+func (r *SystemSurvivalResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data *SystemSurvivalResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(r.apply(ctx, data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tflog.Trace(ctx, "configured the system database survival")
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Delete only forgets the resource, reverting the system database to a single region is a manual decision
+func (r *SystemSurvivalResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	resp.Diagnostics.AddWarning(
+		"System database left unchanged",
+		"The regions and survival goal of the system database were not reverted, they're no longer managed by terraform.",
+	)
+}
+
+// apply moves the primary region, adds regions, then sets the survival goal and drops regions. Zone survival is
+// set before dropping regions and region survival after, so the goal always has enough regions to hold.
+func (r *SystemSurvivalResource) apply(ctx context.Context, data *SystemSurvivalResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	client, err := r.db.Connect(ctx)
+	if err != nil {
+		diags.AddError("Failed to connect to cockroach", err.Error())
+		return diags
+	}
+	defer client.Close()
+
+	primary, current, err := systemRegions(ctx, client)
+	if err != nil {
+		diags.AddError("Configure system database error", fmt.Sprintf("Unable to read regions, got error: %s", err))
+		return diags
+	}
+
+	statements := []string{}
+	drops := []string{}
+	if primary != data.PrimaryRegion.ValueString() {
+		statements = append(statements, "ALTER DATABASE system SET PRIMARY REGION "+pq.QuoteIdentifier(data.PrimaryRegion.ValueString()))
+	}
+
+	if !data.Regions.IsNull() {
+		desired := []string{}
+		diags.Append(data.Regions.ElementsAs(ctx, &desired, false)...)
+		if diags.HasError() {
+			return diags
+		}
+		for _, region := range desired {
+			if !slices.Contains(current, region) && region != data.PrimaryRegion.ValueString() {
+				statements = append(statements, "ALTER DATABASE system ADD REGION "+pq.QuoteIdentifier(region))
+			}
+		}
+		for _, region := range current {
+			if !slices.Contains(desired, region) && region != data.PrimaryRegion.ValueString() {
+				drops = append(drops, "ALTER DATABASE system DROP REGION "+pq.QuoteIdentifier(region))
+			}
+		}
+	}
+
+	survive := fmt.Sprintf("ALTER DATABASE system SURVIVE %s FAILURE", strings.ToUpper(data.SurvivalGoal.ValueString()))
+	if data.SurvivalGoal.ValueString() == "zone" {
+		statements = append(append(statements, survive), drops...)
+	} else {
+		statements = append(append(statements, drops...), survive)
+	}
+
+	for _, statement := range statements {
+		if _, err := client.ExecContext(ctx, statement); err != nil {
+			diags.AddError("Configure system database error", fmt.Sprintf("Unable to run %s, got error: %s", statement, err))
+			return diags
+		}
+	}
+
+	applied, d := client.appliedStatements(ctx)
+	diags.Append(d...)
+	data.LastAppliedStatements = applied
+	return diags
+}
+
+// systemRegions returns the primary region and all regions of the system database
+func systemRegions(ctx context.Context, client Executor) (string, []string, error) {
+	rows, err := client.QueryContext(ctx, `SELECT region, "primary" FROM [SHOW REGIONS FROM DATABASE system] ORDER BY region`)
+	if err != nil {
+		return "", nil, err
+	}
+	defer rows.Close()
+
+	primary := ""
+	regions := []string{}
+	for rows.Next() {
+		var region string
+		var isPrimary bool
+		if err := rows.Scan(&region, &isPrimary); err != nil {
+			return "", nil, err
+		}
+		if isPrimary {
+			primary = region
+		}
+		regions = append(regions, region)
+	}
+	return primary, regions, rows.Err()
+}
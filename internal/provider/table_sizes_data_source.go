@@ -0,0 +1,184 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/lib/pq"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &TableSizesDataSource{}
+
+func NewTableSizesDataSource() datasource.DataSource {
+	return &TableSizesDataSource{}
+}
+
+// TableSizesDataSource reports approximate row counts and sizes of the tables in a database.
+type TableSizesDataSource struct {
+	db Database
+}
+
+// TableSizesDataSourceModel describes the data source data model.
+type TableSizesDataSourceModel struct {
+	Database types.String     `tfsdk:"database"`
+	Tables   []tableSizeModel `tfsdk:"tables"`
+}
+
+// tableSizeModel is the size of a single table
+type tableSizeModel struct {
+	Schema            types.String `tfsdk:"schema"`
+	Name              types.String `tfsdk:"name"`
+	EstimatedRowCount types.Int64  `tfsdk:"estimated_row_count"`
+	LiveBytes         types.Int64  `tfsdk:"live_bytes"`
+}
+
+// Metadata appends the data source name to the provider name
+func (d *TableSizesDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_table_sizes"
+}
+
+// Schema is the shape of the data source - what you need to supply and what you get back
+func (d *TableSizesDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Approximate row counts and sizes of the tables in a database, e.g. for cost dashboards",
+		Attributes: map[string]schema.Attribute{
+			"database": schema.StringAttribute{
+				MarkdownDescription: "Name of the database",
+				Required:            true,
+			},
+			"tables": schema.ListNestedAttribute{
+				MarkdownDescription: "Tables of the database",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"schema": schema.StringAttribute{
+							MarkdownDescription: "Schema of the table",
+							Computed:            true,
+						},
+						"name": schema.StringAttribute{
+							MarkdownDescription: "Name of the table",
+							Computed:            true,
+						},
+						"estimated_row_count": schema.Int64Attribute{
+							MarkdownDescription: "Row count estimated from table statistics",
+							Computed:            true,
+						},
+						"live_bytes": schema.Int64Attribute{
+							MarkdownDescription: "Approximate live data in bytes, null on clusters before v23.2",
+							Computed:            true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Configure adds the provider configured client to the data source
+func (d *TableSizesDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(Database)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *CockroachClient, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.db = client
+}
+
+// Read collects row estimates from SHOW TABLES and sizes from the span stats where available
+func (d *TableSizesDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data TableSizesDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, err := d.db.ConnectForRead(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to connect to cockroach",
+			err.Error(),
+		)
+		return
+	}
+	defer client.Close()
+
+	dialect, err := client.Dialect(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Read table sizes error", fmt.Sprintf("Unable to determine server version, got error: %s", err))
+		return
+	}
+
+	sizes := map[string]int64{}
+	sizeQuery, ok, diags := dialect.TableSizes()
+	resp.Diagnostics.Append(diags...)
+	if ok {
+		rows, err := client.QueryContext(ctx, sizeQuery, data.Database.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Read table sizes error", fmt.Sprintf("Unable to read span stats, got error: %s", err))
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var schemaName, tableName string
+			var liveBytes int64
+			if err := rows.Scan(&schemaName, &tableName, &liveBytes); err != nil {
+				resp.Diagnostics.AddError("Read table sizes error", fmt.Sprintf("Unable to scan span stats, got error: %s", err))
+				return
+			}
+			sizes[schemaName+"."+tableName] = liveBytes
+		}
+	}
+
+	q := fmt.Sprintf("SELECT schema_name, table_name, estimated_row_count FROM [SHOW TABLES FROM %s] WHERE type = 'table' ORDER BY schema_name, table_name", pq.QuoteIdentifier(data.Database.ValueString()))
+	rows, err := client.QueryContext(ctx, q)
+	if err != nil {
+		resp.Diagnostics.AddError("Read table sizes error", fmt.Sprintf("Unable to list tables, got error: %s", err))
+		return
+	}
+	defer rows.Close()
+
+	data.Tables = []tableSizeModel{}
+	for rows.Next() {
+		var schemaName, tableName string
+		var estimatedRowCount sql.NullInt64
+		if err := rows.Scan(&schemaName, &tableName, &estimatedRowCount); err != nil {
+			resp.Diagnostics.AddError("Read table sizes error", fmt.Sprintf("Unable to scan table, got error: %s", err))
+			return
+		}
+
+		table := tableSizeModel{
+			Schema:            types.StringValue(schemaName),
+			Name:              types.StringValue(tableName),
+			EstimatedRowCount: types.Int64Value(estimatedRowCount.Int64),
+			LiveBytes:         types.Int64Null(),
+		}
+		if liveBytes, ok := sizes[schemaName+"."+tableName]; ok {
+			table.LiveBytes = types.Int64Value(liveBytes)
+		}
+		data.Tables = append(data.Tables, table)
+	}
+	if err := rows.Err(); err != nil {
+		resp.Diagnostics.AddError("Read table sizes error", fmt.Sprintf("Unable to list tables, got error: %s", err))
+		return
+	}
+
+	tflog.Trace(ctx, "read table sizes")
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,360 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/float64validator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/lib/pq"
+	"golang.org/x/exp/slices"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &TableStatisticsResource{}
+var _ resource.ResourceWithImportState = &TableStatisticsResource{}
+
+// Storage parameters of a table controlling automatic statistics collection
+const (
+	statsCollectionEnabledParam = "sql_stats_automatic_collection_enabled"
+	statsFractionStaleRowsParam = "sql_stats_automatic_collection_fraction_stale_rows"
+)
+
+func NewTableStatisticsResource() resource.Resource {
+	return &TableStatisticsResource{}
+}
+
+// TableStatisticsResource manages the automatic statistics collection settings of an existing table.
+type TableStatisticsResource struct {
+	db Database
+}
+
+// TableStatisticsResourceModel describes the resource data model.
+type TableStatisticsResourceModel struct {
+	Database                   identifierValue `tfsdk:"database"`
+	Schema                     identifierValue `tfsdk:"schema"`
+	Table                      identifierValue `tfsdk:"table"`
+	AutomaticCollectionEnabled types.Bool      `tfsdk:"automatic_collection_enabled"`
+	FractionStaleRows          types.Float64   `tfsdk:"fraction_stale_rows"`
+	LastAppliedStatements      types.List      `tfsdk:"last_applied_statements"`
+	Timeouts                   timeouts.Value  `tfsdk:"timeouts"`
+}
+
+// Metadata appends the resource name to the provider name
+func (r *TableStatisticsResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_table_statistics"
+}
+
+// Schema is the shape of the resource - what you need to supply
+func (r *TableStatisticsResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Automatic statistics collection settings of an existing table, for tuning high-churn tables. Unset attributes follow the cluster settings and destroying the resource resets the table to them",
+		Attributes: map[string]schema.Attribute{
+			"database": schema.StringAttribute{
+				CustomType:          identifierType{},
+				MarkdownDescription: "Database of the table",
+				Required:            true,
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"schema": schema.StringAttribute{
+				CustomType:          identifierType{},
+				MarkdownDescription: "Schema of the table, defaults to `public`",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString("public"),
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"table": schema.StringAttribute{
+				CustomType:          identifierType{},
+				MarkdownDescription: "Name of the table",
+				Required:            true,
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"automatic_collection_enabled": schema.BoolAttribute{
+				MarkdownDescription: "Whether statistics are collected automatically, `sql_stats_automatic_collection_enabled`",
+				Optional:            true,
+			},
+			"fraction_stale_rows": schema.Float64Attribute{
+				MarkdownDescription: "Fraction of rows that have to change before statistics are refreshed, `sql_stats_automatic_collection_fraction_stale_rows`",
+				Optional:            true,
+				Validators:          []validator.Float64{float64validator.AtLeast(0)},
+			},
+			"last_applied_statements": lastAppliedStatementsAttribute(),
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{Create: true, Update: true}),
+		},
+	}
+}
+
+// Configure adds the provider configured client to the resource
+func (r *TableStatisticsResource) Configure(_ context.Context, req resource.ConfigureRequest, _ *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	r.db = req.ProviderData.(Database)
+}
+
+// qualifiedName is database.schema.table of the table
+func (data *TableStatisticsResourceModel) qualifiedName() string {
+	return fmt.Sprintf("%s.%s.%s", pq.QuoteIdentifier(data.Database.ValueString()), pq.QuoteIdentifier(data.Schema.ValueString()), pq.QuoteIdentifier(data.Table.ValueString()))
+}
+
+// storageParams renders the configured settings as storage parameters, and lists the ones left unset
+func (data *TableStatisticsResourceModel) storageParams() (set []string, unset []string) {
+	if data.AutomaticCollectionEnabled.IsNull() {
+		unset = append(unset, statsCollectionEnabledParam)
+	} else {
+		set = append(set, fmt.Sprintf("%s = %t", statsCollectionEnabledParam, data.AutomaticCollectionEnabled.ValueBool()))
+	}
+
+	if data.FractionStaleRows.IsNull() {
+		unset = append(unset, statsFractionStaleRowsParam)
+	} else {
+		set = append(set, fmt.Sprintf("%s = %s", statsFractionStaleRowsParam, strconv.FormatFloat(data.FractionStaleRows.ValueFloat64(), 'f', -1, 64)))
+	}
+	return set, unset
+}
+
+// apply sets the configured storage parameters and resets the ones removed since the previous state, then waits up to
+// the timeout for the resulting schema change
+func (r *TableStatisticsResource) apply(ctx context.Context, data *TableStatisticsResourceModel, state *TableStatisticsResourceModel, timeout time.Duration) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	client, err := r.db.Connect(ctx)
+	if err != nil {
+		diags.AddError("Failed to connect to cockroach", err.Error())
+		return diags
+	}
+	defer client.Close()
+
+	set, unset := data.storageParams()
+	statements := []string{}
+	if len(set) > 0 {
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %s SET (%s)", data.qualifiedName(), strings.Join(set, ", ")))
+	}
+	if state != nil {
+		_, previouslyUnset := state.storageParams()
+		reset := []string{}
+		for _, param := range unset {
+			if !slices.Contains(previouslyUnset, param) {
+				reset = append(reset, param)
+			}
+		}
+		if len(reset) > 0 {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s RESET (%s)", data.qualifiedName(), strings.Join(reset, ", ")))
+		}
+	}
+
+	mark, err := schemaChangeMark(ctx, client)
+	if err != nil {
+		diags.AddError("Table statistics error", fmt.Sprintf("Unable to read the cluster time, got error: %s", err))
+		return diags
+	}
+
+	for _, statement := range statements {
+		if _, err := client.ExecContext(ctx, statement); err != nil {
+			diags.AddError("Table statistics error", fmt.Sprintf("Unable to run %s, got error: %s", statement, err))
+			return diags
+		}
+	}
+
+	diags.Append(awaitSchemaChanges(ctx, client, data.Database.ValueString(), mark, timeout)...)
+	if diags.HasError() {
+		return diags
+	}
+
+	applied, d := client.appliedStatements(ctx)
+	diags.Append(d...)
+	data.LastAppliedStatements = applied
+	return diags
+}
+
+func (r *TableStatisticsResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data *TableStatisticsResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	timeout, diags := data.Timeouts.Create(ctx, defaultSchemaChangeTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(r.apply(ctx, data, nil, timeout)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tflog.Trace(ctx, "configured table statistics")
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *TableStatisticsResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data *TableStatisticsResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, err := r.db.ConnectForRead(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to connect to cockroach",
+			err.Error(),
+		)
+		return
+	}
+	defer client.Close()
+
+	params, err := tableStorageParams(ctx, client, data.Database.ValueString(), data.Schema.ValueString(), data.Table.ValueString())
+	if err == sql.ErrNoRows {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Read table statistics error", fmt.Sprintf("Unable to read storage parameters, got error: %s", err))
+		return
+	}
+
+	data.AutomaticCollectionEnabled = types.BoolNull()
+	if value, ok := params[statsCollectionEnabledParam]; ok {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			resp.Diagnostics.AddError("Read table statistics error", fmt.Sprintf("Unable to parse %s=%s, got error: %s", statsCollectionEnabledParam, value, err))
+			return
+		}
+		data.AutomaticCollectionEnabled = types.BoolValue(enabled)
+	}
+
+	data.FractionStaleRows = types.Float64Null()
+	if value, ok := params[statsFractionStaleRowsParam]; ok {
+		fraction, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			resp.Diagnostics.AddError("Read table statistics error", fmt.Sprintf("Unable to parse %s=%s, got error: %s", statsFractionStaleRowsParam, value, err))
+			return
+		}
+		data.FractionStaleRows = types.Float64Value(fraction)
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *TableStatisticsResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data *TableStatisticsResourceModel
+	var state *TableStatisticsResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	timeout, diags := data.Timeouts.Update(ctx, defaultSchemaChangeTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(r.apply(ctx, data, state, timeout)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tflog.Trace(ctx, "configured table statistics")
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Delete resets the managed storage parameters, the table itself is left alone
+func (r *TableStatisticsResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data *TableStatisticsResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	set, _ := data.storageParams()
+	if len(set) == 0 {
+		return
+	}
+
+	client, err := r.db.Connect(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to connect to cockroach",
+			err.Error(),
+		)
+		return
+	}
+	defer client.Close()
+
+	statement := fmt.Sprintf("ALTER TABLE IF EXISTS %s RESET (%s, %s)", data.qualifiedName(), statsCollectionEnabledParam, statsFractionStaleRowsParam)
+	if _, err := client.ExecContext(ctx, statement); err != nil {
+		resp.Diagnostics.AddError("Table statistics error", fmt.Sprintf("Unable to reset storage parameters, got error: %s", err))
+		return
+	}
+
+	tflog.Trace(ctx, "reset table statistics")
+}
+
+// ImportState takes an identifier of the form database.schema.table
+func (r *TableStatisticsResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	parts := strings.Split(req.ID, ".")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		resp.Diagnostics.AddError(
+			"Unexpected import identifier",
+			fmt.Sprintf("Expected import identifier with format: database.schema.table. Got: %q", req.ID),
+		)
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("database"), parts[0])...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("schema"), parts[1])...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("table"), parts[2])...)
+}
+
+// tableStorageParams returns the storage parameters set on a table, sql.ErrNoRows if there is no such table
+func tableStorageParams(ctx context.Context, client Executor, database string, schema string, table string) (map[string]string, error) {
+	catalog := pq.QuoteIdentifier(database) + ".pg_catalog"
+	query := fmt.Sprintf(`SELECT c.reloptions FROM %s.pg_class c JOIN %s.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relname = $2 AND c.relkind = 'r'`, catalog, catalog)
+
+	var options []string
+	if err := client.QueryRowContext(ctx, query, schema, table).Scan(pq.Array(&options)); err != nil {
+		return nil, err
+	}
+	return parseStorageParams(options), nil
+}
+
+// parseStorageParams splits reloptions entries of the form key=value
+func parseStorageParams(options []string) map[string]string {
+	params := map[string]string{}
+	for _, option := range options {
+		if key, value, ok := strings.Cut(option, "="); ok {
+			params[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return params
+}
@@ -0,0 +1,44 @@
+package provider
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestTableStatisticsStorageParams(t *testing.T) {
+	data := TableStatisticsResourceModel{
+		AutomaticCollectionEnabled: types.BoolValue(false),
+		FractionStaleRows:          types.Float64Null(),
+	}
+	set, unset := data.storageParams()
+	if len(set) != 1 || set[0] != "sql_stats_automatic_collection_enabled = false" {
+		t.Errorf("unexpected set %v", set)
+	}
+	if len(unset) != 1 || unset[0] != statsFractionStaleRowsParam {
+		t.Errorf("unexpected unset %v", unset)
+	}
+
+	data.FractionStaleRows = types.Float64Value(0.05)
+	if set, _ := data.storageParams(); len(set) != 2 || set[1] != "sql_stats_automatic_collection_fraction_stale_rows = 0.05" {
+		t.Errorf("unexpected set %v", set)
+	}
+}
+
+func TestTableStorageParams(t *testing.T) {
+	client := newMockConn(t, mockQuery{
+		contains: `"app".pg_catalog.pg_class`,
+		columns:  []string{"reloptions"},
+		rows:     [][]driver.Value{{"{sql_stats_automatic_collection_enabled=false,fillfactor=100}"}},
+	})
+
+	params, err := tableStorageParams(context.Background(), client, "app", "public", "events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(params) != 2 || params[statsCollectionEnabledParam] != "false" {
+		t.Errorf("unexpected params %v", params)
+	}
+}
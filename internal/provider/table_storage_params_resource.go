@@ -0,0 +1,470 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/mapvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/lib/pq"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &TableStorageParamsResource{}
+var _ resource.ResourceWithImportState = &TableStorageParamsResource{}
+
+// Kinds of storage parameter values, which decide how a value is validated, quoted and compared
+const (
+	storageParamBool     = "bool"
+	storageParamInt      = "int"
+	storageParamString   = "string"
+	storageParamInterval = "interval"
+)
+
+// storageParamKinds is the allow-list of table storage parameters the resource manages. The statistics parameters
+// belong to the table statistics resource.
+var storageParamKinds = map[string]string{
+	"autovacuum_enabled":       storageParamBool,
+	"exclude_data_from_backup": storageParamBool,
+	"fillfactor":               storageParamInt,
+	"schema_locked":            storageParamBool,
+	"sql_stats_automatic_collection_min_stale_rows": storageParamInt,
+	"sql_stats_forecasts_enabled":                   storageParamBool,
+	"sql_stats_histogram_buckets_count":             storageParamInt,
+	"sql_stats_histogram_samples_count":             storageParamInt,
+	"ttl":                                           storageParamBool,
+	"ttl_delete_batch_size":                         storageParamInt,
+	"ttl_delete_rate_limit":                         storageParamInt,
+	"ttl_disable_changefeed_replication":            storageParamBool,
+	"ttl_expiration_expression":                     storageParamString,
+	"ttl_expire_after":                              storageParamInterval,
+	"ttl_job_cron":                                  storageParamString,
+	"ttl_label_metrics":                             storageParamBool,
+	"ttl_pause":                                     storageParamBool,
+	"ttl_row_stats_poll_interval":                   storageParamInterval,
+	"ttl_select_batch_size":                         storageParamInt,
+	"ttl_select_rate_limit":                         storageParamInt,
+}
+
+func NewTableStorageParamsResource() resource.Resource {
+	return &TableStorageParamsResource{}
+}
+
+// TableStorageParamsResource manages storage parameters of an existing table.
+type TableStorageParamsResource struct {
+	db Database
+}
+
+// TableStorageParamsResourceModel describes the resource data model.
+type TableStorageParamsResourceModel struct {
+	Database              identifierValue `tfsdk:"database"`
+	Schema                identifierValue `tfsdk:"schema"`
+	Table                 identifierValue `tfsdk:"table"`
+	Params                types.Map       `tfsdk:"params"`
+	LastAppliedStatements types.List      `tfsdk:"last_applied_statements"`
+	Timeouts              timeouts.Value  `tfsdk:"timeouts"`
+}
+
+// Metadata appends the resource name to the provider name
+func (r *TableStorageParamsResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_table_storage_params"
+}
+
+// Schema is the shape of the resource - what you need to supply
+func (r *TableStorageParamsResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Storage parameters of an existing table, such as row-level TTL or `exclude_data_from_backup`. Parameters removed from `params` and destroying the resource reset the table to the defaults",
+		Attributes: map[string]schema.Attribute{
+			"database": schema.StringAttribute{
+				CustomType:          identifierType{},
+				MarkdownDescription: "Database of the table",
+				Required:            true,
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"schema": schema.StringAttribute{
+				CustomType:          identifierType{},
+				MarkdownDescription: "Schema of the table, defaults to `public`",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString("public"),
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"table": schema.StringAttribute{
+				CustomType:          identifierType{},
+				MarkdownDescription: "Name of the table",
+				Required:            true,
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"params": schema.MapAttribute{
+				MarkdownDescription: "Storage parameters by name, e.g. `ttl_expire_after = \"30 days\"`. Values are given unquoted, string and interval values are quoted by the provider",
+				ElementType:         types.StringType,
+				Required:            true,
+				Validators:          []validator.Map{mapvalidator.SizeAtLeast(1), storageParamsValidator{}},
+			},
+			"last_applied_statements": lastAppliedStatementsAttribute(),
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{Create: true, Update: true}),
+		},
+	}
+}
+
+// storageParamsValidator rejects parameters outside the allow-list and values which don't parse as their kind
+type storageParamsValidator struct{}
+
+func (v storageParamsValidator) Description(ctx context.Context) string {
+	return "params must be known table storage parameters with values of their type"
+}
+
+func (v storageParamsValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v storageParamsValidator) ValidateMap(ctx context.Context, req validator.MapRequest, resp *validator.MapResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	for name, element := range req.ConfigValue.Elements() {
+		value, ok := element.(types.String)
+		if !ok || value.IsUnknown() {
+			continue
+		}
+		if name == statsCollectionEnabledParam || name == statsFractionStaleRowsParam {
+			resp.Diagnostics.AddAttributeError(req.Path.AtMapKey(name), "Unsupported storage parameter", fmt.Sprintf("%s is managed by the %s_table_statistics resource.", name, typeName))
+			continue
+		}
+		kind, ok := storageParamKinds[name]
+		if !ok {
+			resp.Diagnostics.AddAttributeError(req.Path.AtMapKey(name), "Unsupported storage parameter", fmt.Sprintf("%s is not a known table storage parameter.", name))
+			continue
+		}
+		if err := checkStorageParam(kind, value.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(req.Path.AtMapKey(name), "Invalid storage parameter", fmt.Sprintf("%s expects a %s value, got error: %s", name, kind, err))
+		}
+	}
+}
+
+// checkStorageParam parses a value as its kind
+func checkStorageParam(kind string, value string) error {
+	var err error
+	switch kind {
+	case storageParamBool:
+		_, err = strconv.ParseBool(value)
+	case storageParamInt:
+		_, err = strconv.ParseInt(value, 10, 64)
+	}
+	return err
+}
+
+// storageParamLiteral renders a value for ALTER TABLE ... SET
+func storageParamLiteral(kind string, value string) string {
+	switch kind {
+	case storageParamString, storageParamInterval:
+		return pq.QuoteLiteral(value)
+	case storageParamBool:
+		parsed, _ := strconv.ParseBool(value)
+		return strconv.FormatBool(parsed)
+	}
+	return value
+}
+
+// storageParamEqual compares a configured value with the one read from the table, which the cluster may have formatted
+// differently
+func storageParamEqual(kind string, configured string, read string) bool {
+	read = strings.Trim(read, "'")
+	switch kind {
+	case storageParamBool:
+		a, errA := strconv.ParseBool(configured)
+		b, errB := strconv.ParseBool(read)
+		return errA == nil && errB == nil && a == b
+	case storageParamInt:
+		a, errA := strconv.ParseFloat(configured, 64)
+		b, errB := strconv.ParseFloat(read, 64)
+		return errA == nil && errB == nil && a == b
+	case storageParamInterval:
+		a, errA := time.ParseDuration(configured)
+		b, errB := time.ParseDuration(read)
+		if errA == nil && errB == nil {
+			return a == b
+		}
+	}
+	return configured == read
+}
+
+// Configure adds the provider configured client to the resource
+func (r *TableStorageParamsResource) Configure(_ context.Context, req resource.ConfigureRequest, _ *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	r.db = req.ProviderData.(Database)
+}
+
+// qualifiedName is database.schema.table of the table
+func (data *TableStorageParamsResourceModel) qualifiedName() string {
+	return fmt.Sprintf("%s.%s.%s", pq.QuoteIdentifier(data.Database.ValueString()), pq.QuoteIdentifier(data.Schema.ValueString()), pq.QuoteIdentifier(data.Table.ValueString()))
+}
+
+// params decodes the configured parameters
+func (data *TableStorageParamsResourceModel) params(ctx context.Context) (map[string]string, diag.Diagnostics) {
+	params := map[string]string{}
+	if data.Params.IsNull() || data.Params.IsUnknown() {
+		return params, nil
+	}
+	diags := data.Params.ElementsAs(ctx, &params, false)
+	return params, diags
+}
+
+// storageParamStatements sets the parameters which changed and resets the ones removed since the previous state
+func storageParamStatements(table string, params map[string]string, previous map[string]string) []string {
+	set := []string{}
+	for name, value := range params {
+		if old, ok := previous[name]; !ok || old != value {
+			set = append(set, fmt.Sprintf("%s = %s", name, storageParamLiteral(storageParamKinds[name], value)))
+		}
+	}
+	reset := []string{}
+	for name := range previous {
+		if _, ok := params[name]; !ok {
+			reset = append(reset, name)
+		}
+	}
+	sort.Strings(set)
+	sort.Strings(reset)
+
+	statements := []string{}
+	if len(set) > 0 {
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %s SET (%s)", table, strings.Join(set, ", ")))
+	}
+	if len(reset) > 0 {
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %s RESET (%s)", table, strings.Join(reset, ", ")))
+	}
+	return statements
+}
+
+// apply runs the statements moving the table from the previous parameters to the planned ones, then waits up to the
+// timeout for the resulting schema change
+func (r *TableStorageParamsResource) apply(ctx context.Context, data *TableStorageParamsResourceModel, state *TableStorageParamsResourceModel, timeout time.Duration) diag.Diagnostics {
+	params, diags := data.params(ctx)
+	previous := map[string]string{}
+	if state != nil {
+		var d diag.Diagnostics
+		previous, d = state.params(ctx)
+		diags.Append(d...)
+	}
+	if diags.HasError() {
+		return diags
+	}
+
+	client, err := r.db.Connect(ctx)
+	if err != nil {
+		diags.AddError("Failed to connect to cockroach", err.Error())
+		return diags
+	}
+	defer client.Close()
+
+	mark, err := schemaChangeMark(ctx, client)
+	if err != nil {
+		diags.AddError("Table storage params error", fmt.Sprintf("Unable to read the cluster time, got error: %s", err))
+		return diags
+	}
+
+	for _, statement := range storageParamStatements(data.qualifiedName(), params, previous) {
+		if _, err := client.ExecContext(ctx, statement); err != nil {
+			diags.AddError("Table storage params error", fmt.Sprintf("Unable to run %s, got error: %s", statement, err))
+			return diags
+		}
+	}
+
+	diags.Append(awaitSchemaChanges(ctx, client, data.Database.ValueString(), mark, timeout)...)
+	if diags.HasError() {
+		return diags
+	}
+
+	applied, d := client.appliedStatements(ctx)
+	diags.Append(d...)
+	data.LastAppliedStatements = applied
+	return diags
+}
+
+func (r *TableStorageParamsResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data *TableStorageParamsResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	timeout, diags := data.Timeouts.Create(ctx, defaultSchemaChangeTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(r.apply(ctx, data, nil, timeout)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tflog.Trace(ctx, "configured table storage params")
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Read refreshes the managed parameters, or every allow-listed one set on the table after an import
+func (r *TableStorageParamsResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data *TableStorageParamsResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, err := r.db.ConnectForRead(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to connect to cockroach",
+			err.Error(),
+		)
+		return
+	}
+	defer client.Close()
+
+	actual, err := tableStorageParams(ctx, client, data.Database.ValueString(), data.Schema.ValueString(), data.Table.ValueString())
+	if err == sql.ErrNoRows {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Read table storage params error", fmt.Sprintf("Unable to read storage parameters, got error: %s", err))
+		return
+	}
+
+	managed, diags := data.params(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(refreshStorageParams(ctx, data, managed, actual)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// refreshStorageParams keeps the managed values the table still has, in their configured spelling
+func refreshStorageParams(ctx context.Context, data *TableStorageParamsResourceModel, managed map[string]string, actual map[string]string) diag.Diagnostics {
+	imported := len(managed) == 0
+
+	params := map[string]string{}
+	for name, value := range actual {
+		kind, allowed := storageParamKinds[name]
+		configured, ok := managed[name]
+		switch {
+		case ok && storageParamEqual(kind, configured, value):
+			params[name] = configured
+		case ok || (imported && allowed):
+			params[name] = strings.Trim(value, "'")
+		}
+	}
+
+	refreshed, diags := types.MapValueFrom(ctx, types.StringType, params)
+	data.Params = refreshed
+	return diags
+}
+
+func (r *TableStorageParamsResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data *TableStorageParamsResourceModel
+	var state *TableStorageParamsResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	timeout, diags := data.Timeouts.Update(ctx, defaultSchemaChangeTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(r.apply(ctx, data, state, timeout)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tflog.Trace(ctx, "configured table storage params")
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Delete resets the managed storage parameters, the table itself is left alone
+func (r *TableStorageParamsResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data *TableStorageParamsResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	params, diags := data.params(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || len(params) == 0 {
+		return
+	}
+
+	client, err := r.db.Connect(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to connect to cockroach",
+			err.Error(),
+		)
+		return
+	}
+	defer client.Close()
+
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	statement := fmt.Sprintf("ALTER TABLE IF EXISTS %s RESET (%s)", data.qualifiedName(), strings.Join(names, ", "))
+	if _, err := client.ExecContext(ctx, statement); err != nil {
+		resp.Diagnostics.AddError("Table storage params error", fmt.Sprintf("Unable to reset storage parameters, got error: %s", err))
+		return
+	}
+
+	tflog.Trace(ctx, "reset table storage params")
+}
+
+// ImportState takes an identifier of the form database.schema.table
+func (r *TableStorageParamsResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	parts := strings.Split(req.ID, ".")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		resp.Diagnostics.AddError(
+			"Unexpected import identifier",
+			fmt.Sprintf("Expected import identifier with format: database.schema.table. Got: %q", req.ID),
+		)
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("database"), parts[0])...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("schema"), parts[1])...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("table"), parts[2])...)
+}
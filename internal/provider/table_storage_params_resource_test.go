@@ -0,0 +1,50 @@
+package provider
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestStorageParamStatements(t *testing.T) {
+	statements := storageParamStatements(`"app"."public"."events"`,
+		map[string]string{"ttl_expire_after": "30 days", "ttl_job_cron": "@daily", "exclude_data_from_backup": "true"},
+		map[string]string{"ttl_job_cron": "@daily", "fillfactor": "90"},
+	)
+	want := []string{
+		`ALTER TABLE "app"."public"."events" SET (exclude_data_from_backup = true, ttl_expire_after = '30 days')`,
+		`ALTER TABLE "app"."public"."events" RESET (fillfactor)`,
+	}
+	if !reflect.DeepEqual(statements, want) {
+		t.Errorf("storageParamStatements() = %q, want %q", statements, want)
+	}
+}
+
+func TestRefreshStorageParams(t *testing.T) {
+	ctx := context.Background()
+	actual := parseStorageParams([]string{"exclude_data_from_backup=t", "ttl_expire_after='30 days'", "ttl_job_cron='@hourly'", "sql_stats_automatic_collection_enabled=false"})
+
+	data := &TableStorageParamsResourceModel{}
+	managed := map[string]string{"exclude_data_from_backup": "true", "ttl_job_cron": "@daily", "fillfactor": "90"}
+	if diags := refreshStorageParams(ctx, data, managed, actual); diags.HasError() {
+		t.Fatal(diags)
+	}
+	want := types.MapValueMust(types.StringType, map[string]attr.Value{
+		"exclude_data_from_backup": types.StringValue("true"),
+		"ttl_job_cron":             types.StringValue("@hourly"),
+	})
+	if !data.Params.Equal(want) {
+		t.Errorf("expected the drift of ttl_job_cron and fillfactor, got %s", data.Params)
+	}
+
+	// After an import every allow-listed parameter is adopted
+	if diags := refreshStorageParams(ctx, data, map[string]string{}, actual); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if len(data.Params.Elements()) != 3 {
+		t.Errorf("expected the table's parameters, got %s", data.Params)
+	}
+}
@@ -0,0 +1,85 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	defaultTemporaryTTL = 24 * time.Hour
+
+	// labelKeyExpiresAt records when a temporary object may be dropped by cockroachgke_cleanup_run
+	labelKeyExpiresAt = reservedLabelPrefix + "expires_at"
+)
+
+// temporaryRunEnv are checked in order for an id of the CI run creating temporary objects
+var temporaryRunEnv = []string{"TFC_RUN_ID", "GITHUB_RUN_ID", "CI_PIPELINE_ID", "BUILD_ID"}
+
+var nonIdentifierChars = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// temporarySuffix identifies the current run, falling back to random hex outside of CI. Run ids stay the same when a
+// run is retried, so they get a random part too, or the retry would collide with the objects of the first attempt.
+func temporarySuffix() (string, error) {
+	random, err := randomHex(3)
+	if err != nil {
+		return "", err
+	}
+	for _, env := range temporaryRunEnv {
+		if id := strings.Trim(nonIdentifierChars.ReplaceAllString(strings.ToLower(os.Getenv(env)), "_"), "_"); id != "" {
+			return id + "_" + random, nil
+		}
+	}
+	return randomHex(4)
+}
+
+// temporaryExpiry is the time a temporary object created now expires, ttl defaults to 24h
+func temporaryExpiry(ttl types.String) (time.Time, error) {
+	d := defaultTemporaryTTL
+	if !ttl.IsNull() && !ttl.IsUnknown() {
+		var err error
+		d, err = time.ParseDuration(ttl.ValueString())
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid temporary_ttl %q: %w", ttl.ValueString(), err)
+		}
+	}
+	return time.Now().UTC().Add(d), nil
+}
+
+// recordExpiry stores the expiry of a temporary object next to its labels
+func recordExpiry(ctx context.Context, client Executor, objectType string, objectName string, expiresAt string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if err := ensureLabelsTable(ctx, client); err != nil {
+		diags.AddError("Labels error", fmt.Sprintf("Unable to create the labels table, got error: %s", err))
+		return diags
+	}
+
+	_, err := client.ExecContext(ctx, "UPSERT INTO "+labelsTable+" (object_type, object_name, key, value) VALUES ($1, $2, $3, $4)", objectType, objectName, labelKeyExpiresAt, expiresAt)
+	if err != nil {
+		diags.AddError("Labels error", fmt.Sprintf("Unable to record expiry, got error: %s", err))
+	}
+	return diags
+}
+
+// requiresReplaceIfTemporary forces a new object when the name of a temporary one changes, its suffix is only picked at creation
+func requiresReplaceIfTemporary() planmodifier.String {
+	return stringplanmodifier.RequiresReplaceIf(
+		func(ctx context.Context, req planmodifier.StringRequest, resp *stringplanmodifier.RequiresReplaceIfFuncResponse) {
+			var temporary types.Bool
+			resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("temporary"), &temporary)...)
+			resp.RequiresReplace = temporary.ValueBool()
+		},
+		"Temporary objects are replaced when renamed",
+		"Temporary objects are replaced when renamed",
+	)
+}
@@ -0,0 +1,46 @@
+package provider
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestTemporarySuffix(t *testing.T) {
+	for _, env := range temporaryRunEnv {
+		t.Setenv(env, "")
+	}
+
+	t.Setenv("TFC_RUN_ID", "run-CZcmD7eagjhyX0vN")
+	first, err := temporarySuffix()
+	if err != nil || !regexp.MustCompile(`^run_czcmd7eagjhyx0vn_[0-9a-f]{6}$`).MatchString(first) {
+		t.Errorf("expected run_czcmd7eagjhyx0vn with a random part, got %q (%v)", first, err)
+	}
+	// A retried run has the same id
+	if second, err := temporarySuffix(); err != nil || second == first {
+		t.Errorf("expected attempts of the same run to differ, got %q twice (%v)", second, err)
+	}
+
+	t.Setenv("TFC_RUN_ID", "")
+	if suffix, err := temporarySuffix(); err != nil || len(suffix) != 8 {
+		t.Errorf("expected 8 random hex characters, got %q (%v)", suffix, err)
+	}
+}
+
+func TestTemporaryExpiry(t *testing.T) {
+	expiresAt, err := temporaryExpiry(types.StringNull())
+	if err != nil || time.Until(expiresAt) < 23*time.Hour {
+		t.Errorf("expected the default ttl of 24h, got %s (%v)", expiresAt, err)
+	}
+
+	expiresAt, err = temporaryExpiry(types.StringValue("90m"))
+	if err != nil || time.Until(expiresAt) > 90*time.Minute {
+		t.Errorf("expected a ttl of 90m, got %s (%v)", expiresAt, err)
+	}
+
+	if _, err := temporaryExpiry(types.StringValue("tomorrow")); err == nil {
+		t.Error("expected an invalid ttl to fail")
+	}
+}
@@ -0,0 +1,191 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"golang.org/x/exp/slices"
+)
+
+// tlsMaterial tracks the certificate files of the connection. The driver reads them again for every new connection,
+// so a rotation on disk is picked up without reloading the provider; the checksum shows when that happened.
+type tlsMaterial struct {
+	paths []string
+}
+
+// newTLSMaterial tracks the given files, empty paths are skipped
+func newTLSMaterial(paths ...string) *tlsMaterial {
+	m := &tlsMaterial{}
+	for _, p := range paths {
+		if p != "" {
+			m.paths = append(m.paths, p)
+		}
+	}
+	return m
+}
+
+// current hashes the files as they are on disk now
+func (m *tlsMaterial) current() (string, error) {
+	if m == nil {
+		return "", nil
+	}
+
+	hash := sha256.New()
+	for _, p := range m.paths {
+		contents, err := os.ReadFile(p)
+		if err != nil {
+			return "", err
+		}
+		hash.Write(contents)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// rotatedSince reports whether the files changed after the given checksum was taken
+func (m *tlsMaterial) rotatedSince(checksum string) func() bool {
+	return func() bool {
+		current, err := m.current()
+		return err == nil && current != checksum
+	}
+}
+
+// writeCACert stores an inline PEM certificate authority in a temporary file for the driver, which only takes
+// sslrootcert as a path. The file lives as long as the provider process.
+func writeCACert(contents string) (string, error) {
+	if !x509.NewCertPool().AppendCertsFromPEM([]byte(contents)) {
+		return "", errors.New("no PEM encoded certificate found")
+	}
+
+	f, err := os.CreateTemp("", "cockroachgke-ca-*.crt")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := f.WriteString(contents); err != nil {
+		return "", err
+	}
+	return f.Name(), f.Close()
+}
+
+// isCertificateError reports whether err is a failed verification of the server certificate
+func isCertificateError(err error) bool {
+	var unknownAuthority x509.UnknownAuthorityError
+	var invalidCert x509.CertificateInvalidError
+	return errors.As(err, &unknownAuthority) || errors.As(err, &invalidCert)
+}
+
+// tlsVersions are the values of tls_min_version
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// tlsPolicy is the security baseline the negotiated TLS session has to meet, zero values allow anything
+type tlsPolicy struct {
+	MinVersion   uint16
+	CipherSuites []uint16
+}
+
+// parseCipherSuites looks up cipher suites by their IANA names, e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256. Suites
+// Go considers insecure are rejected.
+func parseCipherSuites(names []string) ([]uint16, error) {
+	ids := []uint16{}
+	for _, name := range names {
+		found := false
+		for _, suite := range tls.CipherSuites() {
+			if suite.Name == name {
+				ids = append(ids, suite.ID)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%q is not a supported secure cipher suite", name)
+		}
+	}
+	return ids, nil
+}
+
+// check reports how the negotiated session falls short of the policy
+func (p tlsPolicy) check(state tls.ConnectionState) error {
+	if state.Version < p.MinVersion {
+		return fmt.Errorf("the server negotiated %s, below the required %s", tls.VersionName(state.Version), tls.VersionName(p.MinVersion))
+	}
+	if len(p.CipherSuites) > 0 && !slices.Contains(p.CipherSuites, state.CipherSuite) {
+		return fmt.Errorf("the server negotiated the cipher suite %s, which is not allowed", tls.CipherSuiteName(state.CipherSuite))
+	}
+	return nil
+}
+
+// sslRequestCode asks a postgres server to switch the connection to TLS
+const sslRequestCode = 80877103
+
+// probeTLS negotiates a TLS session the way the driver does and returns what the server picked. The certificate is
+// not verified here, the driver does that on every real connection; the probe only looks at version and cipher.
+func probeTLS(ctx context.Context, dialer connDialer, host string, port int64) (tls.ConnectionState, error) {
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.FormatInt(port, 10)))
+	if err != nil {
+		return tls.ConnectionState{}, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return tls.ConnectionState{}, err
+		}
+	}
+
+	request := make([]byte, 8)
+	binary.BigEndian.PutUint32(request[0:4], 8)
+	binary.BigEndian.PutUint32(request[4:8], sslRequestCode)
+	if _, err := conn.Write(request); err != nil {
+		return tls.ConnectionState{}, err
+	}
+	response := make([]byte, 1)
+	if _, err := io.ReadFull(conn, response); err != nil {
+		return tls.ConnectionState{}, err
+	}
+	if response[0] != 'S' {
+		return tls.ConnectionState{}, errors.New("the server does not accept TLS connections")
+	}
+
+	session := tls.Client(conn, &tls.Config{ServerName: host, InsecureSkipVerify: true})
+	if err := session.HandshakeContext(ctx); err != nil {
+		return tls.ConnectionState{}, err
+	}
+	return session.ConnectionState(), nil
+}
+
+// checkTLSPolicy fails configuration when the server negotiates a session below the provider's TLS baseline
+func checkTLSPolicy(ctx context.Context, client *CockroachClient, policy tlsPolicy) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	state, err := probeTLS(ctx, newConnDialer(client.ProxyAddress, client.DialTimeout, client.KeepAlive), client.Host, client.Port)
+	if err != nil {
+		diags.AddAttributeError(
+			path.Root("tls_min_version"),
+			"Unable to negotiate TLS with Cockroach",
+			fmt.Sprintf("The provider could not check the TLS version and cipher suite of %s: %s", client.Host, err),
+		)
+		return diags
+	}
+
+	if err := policy.check(state); err != nil {
+		diags.AddAttributeError(
+			path.Root("tls_min_version"),
+			"Cockroach does not meet the TLS requirements",
+			fmt.Sprintf("The provider refuses to connect to %s because %s. Configure the cluster to support the required TLS version and cipher suites, or relax tls_min_version and tls_cipher_suites.", client.Host, err),
+		)
+	}
+	return diags
+}
@@ -0,0 +1,165 @@
+package provider
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRetryDuringCertificateRotation(t *testing.T) {
+	ca := filepath.Join(t.TempDir(), "ca.crt")
+	if err := os.WriteFile(ca, []byte("old"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	material := newTLSMaterial(ca, "")
+	checksum, err := material.current()
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := retryPolicy{MaxRetries: 2, rotated: material.rotatedSince(checksum)}
+
+	// Without a rotation a bad certificate fails right away
+	calls := 0
+	policy.do(context.Background(), func() error {
+		calls++
+		return x509.UnknownAuthorityError{}
+	})
+	if calls != 1 {
+		t.Errorf("expected no retries, got %d calls", calls)
+	}
+
+	// The server switched certificates before the new CA landed on disk
+	calls = 0
+	err = policy.do(context.Background(), func() error {
+		calls++
+		if calls == 1 {
+			if err := os.WriteFile(ca, []byte("new"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			return x509.UnknownAuthorityError{}
+		}
+		return nil
+	})
+	if err != nil || calls != 2 {
+		t.Errorf("expected a retry after the rotation, got %d calls and %v", calls, err)
+	}
+}
+
+// serveTLS answers the SSLRequest of one connection and completes a TLS handshake with at most maxVersion, TLS 1.2
+// sessions always use AES-GCM
+func serveTLS(t *testing.T, maxVersion uint16) (string, int64) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), NotBefore: time.Now(), NotAfter: time.Now().Add(time.Hour), DNSNames: []string{"localhost"}}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if _, err := io.ReadFull(conn, make([]byte, 8)); err != nil {
+			return
+		}
+		if _, err := conn.Write([]byte("S")); err != nil {
+			return
+		}
+		session := tls.Server(conn, &tls.Config{
+			Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+			MaxVersion:   maxVersion,
+			CipherSuites: []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+		})
+		_ = session.Handshake()
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	portNumber, _ := strconv.ParseInt(port, 10, 64)
+	return host, portNumber
+}
+
+func TestCheckTLSPolicy(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	host, port := serveTLS(t, tls.VersionTLS12)
+	client := &CockroachClient{Host: host, Port: port}
+	if diags := checkTLSPolicy(ctx, client, tlsPolicy{MinVersion: tls.VersionTLS12}); diags.HasError() {
+		t.Errorf("expected TLS 1.2 to satisfy the policy, got %v", diags)
+	}
+
+	host, port = serveTLS(t, tls.VersionTLS12)
+	client = &CockroachClient{Host: host, Port: port}
+	if diags := checkTLSPolicy(ctx, client, tlsPolicy{MinVersion: tls.VersionTLS13}); !diags.HasError() {
+		t.Error("expected a server limited to TLS 1.2 to fail a TLS 1.3 policy")
+	}
+
+	host, port = serveTLS(t, tls.VersionTLS12)
+	client = &CockroachClient{Host: host, Port: port}
+	suites, err := parseCipherSuites([]string{"TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diags := checkTLSPolicy(ctx, client, tlsPolicy{CipherSuites: suites}); !diags.HasError() {
+		t.Error("expected the AES-GCM cipher of the server to be rejected")
+	}
+}
+
+func TestParseCipherSuites(t *testing.T) {
+	if _, err := parseCipherSuites([]string{"TLS_AES_128_GCM_SHA256", "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"}); err != nil {
+		t.Error(err)
+	}
+	if _, err := parseCipherSuites([]string{"TLS_RSA_WITH_RC4_128_SHA"}); err == nil {
+		t.Error("expected insecure cipher suites to be rejected")
+	}
+}
+
+func TestWriteCACert(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), NotBefore: time.Now(), NotAfter: time.Now().Add(time.Hour), IsCA: true}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+
+	certPath, err := writeCACert(contents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Remove(certPath) })
+	if written, err := os.ReadFile(certPath); err != nil || string(written) != contents {
+		t.Errorf("expected the certificate authority in %s, got %q (%v)", certPath, written, err)
+	}
+
+	if _, err := writeCACert("not a certificate"); err == nil {
+		t.Error("expected contents without a certificate to be rejected")
+	}
+}
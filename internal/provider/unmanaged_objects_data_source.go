@@ -0,0 +1,180 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"golang.org/x/exp/slices"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &UnmanagedObjectsDataSource{}
+
+func NewUnmanagedObjectsDataSource() datasource.DataSource {
+	return &UnmanagedObjectsDataSource{}
+}
+
+// UnmanagedObjectsDataSource reports users and databases which exist in the cluster but aren't managed.
+type UnmanagedObjectsDataSource struct {
+	db Database
+}
+
+// UnmanagedObjectsDataSourceModel describes the data source data model.
+type UnmanagedObjectsDataSourceModel struct {
+	ManagedUsers       types.Set  `tfsdk:"managed_users"`
+	ManagedDatabases   types.Set  `tfsdk:"managed_databases"`
+	UnmanagedUsers     types.List `tfsdk:"unmanaged_users"`
+	UnmanagedDatabases types.List `tfsdk:"unmanaged_databases"`
+	InSync             types.Bool `tfsdk:"in_sync"`
+}
+
+// builtinUsers and builtinDatabases come with every cluster and are never reported
+var builtinUsers = []string{"root", "admin", "node", "public"}
+var builtinDatabases = []string{"system", "defaultdb", "postgres", metadataDatabase}
+
+// Metadata appends the data source name to the provider name
+func (d *UnmanagedObjectsDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_unmanaged_objects"
+}
+
+// Schema is the shape of the data source - what you need to supply and what you get back
+func (d *UnmanagedObjectsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Users and databases in the cluster which aren't in the given lists of managed names, e.g. to enforce that nothing exists outside of terraform. Built-in users and databases, and the provider's `cockroachgke_metadata` database, are never reported",
+		Attributes: map[string]schema.Attribute{
+			"managed_users": schema.SetAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: "Usernames managed by terraform, i.e. the `full_username` of temporary users and the `username` of all others",
+				Required:            true,
+			},
+			"managed_databases": schema.SetAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: "Database names managed by terraform",
+				Required:            true,
+			},
+			"unmanaged_users": schema.ListAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: "Users in the cluster missing from `managed_users`, sorted",
+				Computed:            true,
+			},
+			"unmanaged_databases": schema.ListAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: "Databases in the cluster missing from `managed_databases`, sorted",
+				Computed:            true,
+			},
+			"in_sync": schema.BoolAttribute{
+				MarkdownDescription: "Whether nothing unmanaged was found",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+// Configure adds the provider configured client to the data source
+func (d *UnmanagedObjectsDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(Database)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *CockroachClient, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.db = client
+}
+
+// unmanagedNames lists the names in the given column of the two column query which are neither managed nor built in
+func unmanagedNames(ctx context.Context, client Executor, query string, column int, managed []string, builtin []string) ([]string, error) {
+	rows, err := client.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var columns [2]string
+		if err := rows.Scan(&columns[0], &columns[1]); err != nil {
+			return nil, err
+		}
+		name := columns[column]
+		if !slices.Contains(managed, name) && !slices.Contains(builtin, name) {
+			names = append(names, name)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// Read compares the users and databases of the cluster with the managed names
+func (d *UnmanagedObjectsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data UnmanagedObjectsDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	managedUsers := []string{}
+	managedDatabases := []string{}
+	resp.Diagnostics.Append(data.ManagedUsers.ElementsAs(ctx, &managedUsers, false)...)
+	resp.Diagnostics.Append(data.ManagedDatabases.ElementsAs(ctx, &managedDatabases, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, err := d.db.ConnectForRead(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to connect to cockroach",
+			err.Error(),
+		)
+		return
+	}
+	defer client.Close()
+
+	dialect, err := client.Dialect(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Read unmanaged objects error", fmt.Sprintf("Unable to determine server version, got error: %s", err))
+		return
+	}
+	usersQuery, diags := dialect.Users()
+	resp.Diagnostics.Append(diags...)
+	databasesQuery, diags := dialect.Databases()
+	resp.Diagnostics.Append(diags...)
+
+	users, err := unmanagedNames(ctx, client, usersQuery, 0, managedUsers, builtinUsers)
+	if err != nil {
+		resp.Diagnostics.AddError("Read unmanaged objects error", fmt.Sprintf("Unable to list users, got error: %s", err))
+		return
+	}
+	databases, err := unmanagedNames(ctx, client, databasesQuery, 1, managedDatabases, builtinDatabases)
+	if err != nil {
+		resp.Diagnostics.AddError("Read unmanaged objects error", fmt.Sprintf("Unable to list databases, got error: %s", err))
+		return
+	}
+
+	list, diags := types.ListValueFrom(ctx, types.StringType, users)
+	resp.Diagnostics.Append(diags...)
+	data.UnmanagedUsers = list
+	list, diags = types.ListValueFrom(ctx, types.StringType, databases)
+	resp.Diagnostics.Append(diags...)
+	data.UnmanagedDatabases = list
+	data.InSync = types.BoolValue(len(users) == 0 && len(databases) == 0)
+
+	tflog.Trace(ctx, "read unmanaged objects")
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,40 @@
+package provider
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"golang.org/x/exp/slices"
+)
+
+func TestUnmanagedObjectsDataSourceRead(t *testing.T) {
+	ctx := context.Background()
+	data := readDataSource[UnmanagedObjectsDataSourceModel](t, &UnmanagedObjectsDataSource{},
+		map[string]any{"managed_users": []string{"app_rw"}, "managed_databases": []string{"app"}},
+		mockQuery{contains: "SELECT version()", columns: []string{"version"}, rows: [][]driver.Value{{"CockroachDB CCL v23.2.1 (x86_64-pc-linux-gnu)"}}},
+		mockQuery{
+			contains: "system.users",
+			columns:  []string{"username", "user_id"},
+			rows:     [][]driver.Value{{"root", int64(1)}, {"admin", int64(2)}, {"app_rw", int64(100)}, {"manual", int64(101)}, {"debug", int64(102)}},
+		},
+		mockQuery{
+			contains: "crdb_internal.databases",
+			columns:  []string{"id", "name"},
+			rows:     [][]driver.Value{{int64(1), "system"}, {int64(100), "defaultdb"}, {int64(104), "app"}, {int64(110), metadataDatabase}},
+		},
+	)
+	users := []string{}
+	databases := []string{}
+	data.UnmanagedUsers.ElementsAs(ctx, &users, false)
+	data.UnmanagedDatabases.ElementsAs(ctx, &databases, false)
+	if !slices.Equal(users, []string{"debug", "manual"}) {
+		t.Errorf("expected the unmanaged users sorted, got %v", users)
+	}
+	if len(databases) != 0 {
+		t.Errorf("expected no unmanaged databases, got %v", databases)
+	}
+	if data.InSync.ValueBool() {
+		t.Error("expected the cluster not to be in sync")
+	}
+}
@@ -0,0 +1,190 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/setvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/lib/pq"
+	"golang.org/x/exp/slices"
+)
+
+// userGrantType is an element of the grant blocks of a user
+var userGrantType = types.ObjectType{AttrTypes: map[string]attr.Type{
+	"database":   types.StringType,
+	"schema":     types.StringType,
+	"privileges": types.SetType{ElemType: types.StringType},
+}}
+
+// userGrantModel is a grant block, privileges on the current and future tables of one schema
+type userGrantModel struct {
+	Database   types.String `tfsdk:"database"`
+	Schema     types.String `tfsdk:"schema"`
+	Privileges types.Set    `tfsdk:"privileges"`
+}
+
+// userGrantBlock is keyed by database and schema, so a dynamic block over environments yields one block each
+func userGrantBlock() schema.SetNestedBlock {
+	return schema.SetNestedBlock{
+		MarkdownDescription: "Privileges on the tables of a schema in any database, in addition to `privileges` on `database`. Future tables are covered through default privileges. Works with dynamic blocks, e.g. one block per environment",
+		NestedObject: schema.NestedBlockObject{
+			Attributes: map[string]schema.Attribute{
+				"database": schema.StringAttribute{
+					MarkdownDescription: "Database of the tables",
+					Required:            true,
+				},
+				"schema": schema.StringAttribute{
+					MarkdownDescription: "Schema of the tables, defaults to `public`",
+					Optional:            true,
+					Computed:            true,
+					Default:             stringdefault.StaticString("public"),
+				},
+				"privileges": schema.SetAttribute{
+					ElementType:         types.StringType,
+					MarkdownDescription: "Any of `select`, `insert`, `update`, `delete` and `changefeed`",
+					Required:            true,
+					Validators: []validator.Set{
+						setvalidator.ValueStringsAre(stringvalidator.OneOf(privilegeSlice...)),
+					},
+				},
+			},
+		},
+	}
+}
+
+// privileges lists the privileges of the block, sorted
+func (g userGrantModel) privileges() []string {
+	privileges := []string{}
+	for _, element := range g.Privileges.Elements() {
+		if privilege, ok := element.(types.String); ok {
+			privileges = append(privileges, privilege.ValueString())
+		}
+	}
+	sort.Strings(privileges)
+	return privileges
+}
+
+// grantStatements grant the privileges on the existing and future tables of the schema. Default privileges apply to the
+// current database, use switches the session there unless it already is.
+func (g userGrantModel) grantStatements(d dialect, use string, username string) []string {
+	if len(g.privileges()) == 0 {
+		return nil
+	}
+
+	privileges := strings.ToUpper(strings.Join(g.privileges(), ", "))
+	return []string{
+		d.TablesGrant(privileges, g.Database.ValueString(), []string{g.Schema.ValueString()}, username),
+		use + fmt.Sprintf("ALTER DEFAULT PRIVILEGES %s GRANT %s ON TABLES TO %s", defaultPrivilegesScope("", []string{g.Schema.ValueString()}), privileges, pq.QuoteIdentifier(username)),
+	}
+}
+
+// revokeStatements revoke everything granted by grantStatements
+func (g userGrantModel) revokeStatements(d dialect, use string, username string) []string {
+	return []string{
+		d.TablesRevoke(g.Database.ValueString(), []string{g.Schema.ValueString()}, username),
+		use + fmt.Sprintf("ALTER DEFAULT PRIVILEGES %s REVOKE ALL ON TABLES FROM %s", defaultPrivilegesScope("", []string{g.Schema.ValueString()}), pq.QuoteIdentifier(username)),
+	}
+}
+
+// userGrants decodes the grant blocks
+func userGrants(ctx context.Context, grants types.Set) ([]userGrantModel, diag.Diagnostics) {
+	blocks := []userGrantModel{}
+	if grants.IsNull() || grants.IsUnknown() {
+		return blocks, nil
+	}
+	diags := grants.ElementsAs(ctx, &blocks, false)
+	return blocks, diags
+}
+
+// grantsOutside keeps the grant blocks on databases other than database
+func grantsOutside(ctx context.Context, grants types.Set, database string) (types.Set, diag.Diagnostics) {
+	blocks, diags := userGrants(ctx, grants)
+	if diags.HasError() || grants.IsNull() || grants.IsUnknown() {
+		return grants, diags
+	}
+
+	outside := []userGrantModel{}
+	for _, g := range blocks {
+		if g.Database.ValueString() != database {
+			outside = append(outside, g)
+		}
+	}
+	set, d := types.SetValueFrom(ctx, userGrantType, outside)
+	diags.Append(d...)
+	return set, diags
+}
+
+// applyGrantBlocks runs the grant or revoke statements of every block
+func applyGrantBlocks(ctx context.Context, client Executor, d dialect, username string, grants types.Set, revoke bool) diag.Diagnostics {
+	blocks, diags := userGrants(ctx, grants)
+	if diags.HasError() {
+		return diags
+	}
+
+	for _, g := range blocks {
+		use := useDatabase(client, g.Database.ValueString())
+		statements := g.grantStatements(d, use, username)
+		if revoke {
+			statements = g.revokeStatements(d, use, username)
+		}
+		for _, statement := range statements {
+			// Granting and revoking twice is harmless, so batches switching the database are retried too
+			if _, err := client.ExecContext(idempotent(ctx), statement); err != nil {
+				diags.AddError("Grant error", fmt.Sprintf("Unable to run %s, got error: %s", statement, err))
+				return diags
+			}
+		}
+	}
+	tflog.Trace(ctx, "applied grant blocks")
+	return diags
+}
+
+// readGrantBlocks refreshes the privileges of every block from the grants on the tables of its schema
+func readGrantBlocks(ctx context.Context, client Executor, username string, grants types.Set) (types.Set, diag.Diagnostics) {
+	blocks, diags := userGrants(ctx, grants)
+	if diags.HasError() || grants.IsNull() {
+		return grants, diags
+	}
+
+	found := map[string][]grantRow{}
+	for i, g := range blocks {
+		database := g.Database.ValueString()
+		if _, ok := found[database]; !ok {
+			rows, err := showGrants(ctx, client, database, username)
+			if err != nil {
+				diags.AddError("Read grants error", fmt.Sprintf("Unable to read grants in %s, got error: %s", database, err))
+				return grants, diags
+			}
+			found[database] = rows
+		}
+
+		privileges := []string{}
+		for _, row := range found[database] {
+			privilege := strings.ToLower(row.Privilege)
+			if row.Relation.Valid && row.Schema.String == g.Schema.ValueString() && slices.Contains(privilegeSlice, privilege) && !slices.Contains(privileges, privilege) {
+				privileges = append(privileges, privilege)
+			}
+		}
+		// Without any grants the schema may just have no tables yet, default privileges only apply to future tables
+		if len(privileges) == 0 {
+			continue
+		}
+		set, d := types.SetValueFrom(ctx, types.StringType, privileges)
+		diags.Append(d...)
+		blocks[i].Privileges = set
+	}
+
+	set, d := types.SetValueFrom(ctx, userGrantType, blocks)
+	diags.Append(d...)
+	return set, diags
+}
@@ -4,22 +4,44 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"regexp"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"golang.org/x/exp/slices"
 
-	// "github.com/hashicorp/terraform-plugin-log/tflog"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 // Ensure provider defined types fully satisfy framework interfaces.
 var _ resource.Resource = &UserResource{}
 var _ resource.ResourceWithImportState = &UserResource{}
+var _ resource.ResourceWithValidateConfig = &UserResource{}
+var _ resource.ResourceWithIdentity = &UserResource{}
+
+// Operations of the user which record their progress in private state, and the steps they record
+const (
+	operationCreateUser   = "create_user"
+	operationRecreateUser = "recreate_user"
+
+	progressDropped = 1
+	progressCreated = 2
+)
 
 func NewUserResource() resource.Resource {
 	return &UserResource{}
@@ -27,22 +49,133 @@ func NewUserResource() resource.Resource {
 
 // UserResource defines the resource implementation. Contains the cockroach client connection string.
 type UserResource struct {
-	db *CockroachClient
+	db Database
 }
 
 // UserResourceModel describes the resource data model.
 type UserResourceModel struct {
-	Username   types.String `tfsdk:"username"`
-	Password   types.String `tfsdk:"password"`
-	Database   types.String `tfsdk:"database"`
-	Privileges types.List   `tfsdk:"privileges"`
+	Username                   identifierValue `tfsdk:"username"`
+	Password                   types.String    `tfsdk:"password"`
+	Database                   identifierValue `tfsdk:"database"`
+	Privileges                 types.List      `tfsdk:"privileges"`
+	ObservabilityAccess        types.Bool      `tfsdk:"observability_access"`
+	Exclusive                  types.Bool      `tfsdk:"exclusive"`
+	DefaultPrivilegesForRole   types.String    `tfsdk:"default_privileges_for_role"`
+	DefaultPrivilegesInSchemas types.List      `tfsdk:"default_privileges_in_schemas"`
+	PrivilegesInSchemas        types.List      `tfsdk:"privileges_in_schemas"`
+	ManagePrivileges           types.Bool      `tfsdk:"manage_privileges"`
+	EffectiveGrants            types.Map       `tfsdk:"effective_grants"`
+	Labels                     types.Map       `tfsdk:"labels"`
+	AllowDestroy               types.Bool      `tfsdk:"allow_destroy"`
+	Grants                     types.Set       `tfsdk:"grant"`
+	ExternalConnections        types.Set       `tfsdk:"external_connections"`
+	ControlChangefeed          types.Bool      `tfsdk:"control_changefeed"`
+	SearchPath                 types.String    `tfsdk:"search_path"`
+	Fingerprint                types.String    `tfsdk:"fingerprint"`
+	DropOwned                  types.Bool      `tfsdk:"drop_owned"`
+	RecreateIfMissing          types.Bool      `tfsdk:"recreate_if_missing"`
+	IgnorePasswordChanges      types.Bool      `tfsdk:"ignore_password_changes"`
+	Temporary                  types.Bool      `tfsdk:"temporary"`
+	TemporaryTTL               types.String    `tfsdk:"temporary_ttl"`
+	FullUsername               types.String    `tfsdk:"full_username"`
+	ExpiresAt                  types.String    `tfsdk:"expires_at"`
+	LastAppliedStatements      types.List      `tfsdk:"last_applied_statements"`
+}
+
+// identity is the resource identity of the user
+func (m *UserResourceModel) identity() userIdentityModel {
+	return userIdentityModel{Database: m.Database.StringValue, Username: m.sqlName().StringValue}
+}
+
+// managesPrivileges reports whether grants are handled by this resource, which is the default
+func (m *UserResourceModel) managesPrivileges() bool {
+	return m.ManagePrivileges.IsNull() || m.ManagePrivileges.ValueBool()
+}
+
+// sqlName is the name of the user in cockroach, which has a suffix for temporary users
+func (m *UserResourceModel) sqlName() identifierValue {
+	if m.FullUsername.IsNull() || m.FullUsername.IsUnknown() {
+		return m.Username
+	}
+	return exactIdentifierValue(m.FullUsername.ValueString())
+}
+
+// defaultPrivilegesScope narrows the default privileges to the configured role and schemas
+func (m *UserResourceModel) defaultPrivilegesScope() string {
+	schemas := []string{}
+	for _, element := range m.DefaultPrivilegesInSchemas.Elements() {
+		if schema, ok := element.(types.String); ok {
+			schemas = append(schemas, schema.ValueString())
+		}
+	}
+	return defaultPrivilegesScope(m.DefaultPrivilegesForRole.ValueString(), schemas)
+}
+
+// privilegeSchemas are the schemas whose existing tables get privileges
+func (m *UserResourceModel) privilegeSchemas() []string {
+	schemas := []string{}
+	for _, element := range m.PrivilegesInSchemas.Elements() {
+		if schema, ok := element.(types.String); ok {
+			schemas = append(schemas, schema.ValueString())
+		}
+	}
+	if len(schemas) == 0 {
+		return []string{"public"}
+	}
+	return schemas
 }
 
-var privilegeSlice = []string{"select", "update", "insert", "delete"}
+var privilegeSlice = []string{"select", "update", "insert", "delete", "changefeed"}
+
+// usernamePattern is what cockroach accepts as a username once it's lowercased
+var usernamePattern = regexp.MustCompile(`^[\p{L}0-9_][-\p{L}0-9_.]*$`)
+
+// reservedUsernames are built in roles, reservedUsernamePrefixes are reserved for system roles
+var (
+	reservedUsernames        = []string{"root", "admin", "node", "public", "none"}
+	reservedUsernamePrefixes = []string{"pg_", "crdb_internal"}
+)
+
+// reservedUsernameValidator rejects usernames cockroach keeps for itself
+type reservedUsernameValidator struct{}
+
+func (v reservedUsernameValidator) Description(ctx context.Context) string {
+	return "username must not be a reserved name such as root or admin, or start with pg_ or crdb_internal"
+}
+
+func (v reservedUsernameValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v reservedUsernameValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	username := strings.ToLower(req.ConfigValue.ValueString())
+	reserved := slices.Contains(reservedUsernames, username)
+	for _, prefix := range reservedUsernamePrefixes {
+		reserved = reserved || strings.HasPrefix(username, prefix)
+	}
+	if reserved {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Reserved username",
+			fmt.Sprintf("%s is reserved by cockroach, %s.", req.ConfigValue.ValueString(), v.Description(ctx)),
+		)
+	}
+}
 
 // Metadata appends the resource name to the provider name
 func (r *UserResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_user"
+	// A changed username recreates the user in place, changing its identity
+	resp.ResourceBehavior.MutableIdentity = true
+}
+
+// IdentitySchema is the shape of the identity - what identifies the user across workspaces
+func (r *UserResource) IdentitySchema(ctx context.Context, req resource.IdentitySchemaRequest, resp *resource.IdentitySchemaResponse) {
+	resp.IdentitySchema = userIdentitySchema
 }
 
 // Schema is the shape of the resource - what you need to supply
@@ -51,33 +184,173 @@ func (r *UserResource) Schema(ctx context.Context, req resource.SchemaRequest, r
 		MarkdownDescription: "User resource",
 		Attributes: map[string]schema.Attribute{
 			"username": schema.StringAttribute{
-				MarkdownDescription: "Name of the user",
+				CustomType:          identifierType{},
+				MarkdownDescription: "Name of the user, folded to lowercase like an unquoted SQL identifier. Up to 63 letters, digits, underscores, hyphens or periods, must not be a reserved name such as `root` or `admin` or start with `pg_` or `crdb_internal`",
 				Required:            true,
+				PlanModifiers:       []planmodifier.String{requiresReplaceIfTemporary()},
+				Validators: []validator.String{
+					stringvalidator.LengthBetween(1, 63),
+					stringvalidator.RegexMatches(usernamePattern, "must start with a letter, digit or underscore and contain only letters, digits, underscores, hyphens or periods"),
+					reservedUsernameValidator{},
+				},
 			},
 			"password": schema.StringAttribute{
 				MarkdownDescription: "Password of the user",
 				Required:            true,
+				Sensitive:           true,
 			},
 			"database": schema.StringAttribute{
+				CustomType:          identifierType{},
 				MarkdownDescription: "Database to which the user belongs",
 				Required:            true,
 			},
 			"privileges": schema.ListAttribute{
 				ElementType:         types.StringType,
-				MarkdownDescription: "Privileges of the user",
+				MarkdownDescription: "Privileges of the user on the tables of `database`, any of `select`, `insert`, `update`, `delete` and `changefeed`",
+				Optional:            true,
+			},
+			"external_connections": schema.SetAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: "External connections the user may use, e.g. as changefeed sinks, through `USAGE ON EXTERNAL CONNECTION`",
+				Optional:            true,
+			},
+			"control_changefeed": schema.BoolAttribute{
+				MarkdownDescription: "Grant the CONTROLCHANGEFEED role option, which allows changefeeds on any table the user can select from. Deprecated since 23.1, prefer the `changefeed` privilege",
+				Optional:            true,
+			},
+			"search_path": schema.StringAttribute{
+				MarkdownDescription: "Default search path of the user's sessions as a comma separated list of schemas, e.g. `app,public`, set through `ALTER USER ... SET search_path`",
+				Optional:            true,
+			},
+			"observability_access": schema.BoolAttribute{
+				MarkdownDescription: "Grant VIEWACTIVITY and VIEWCLUSTERSETTING for monitoring users",
+				Optional:            true,
+			},
+			"manage_privileges": schema.BoolAttribute{
+				MarkdownDescription: "Set to false to only manage the account and leave all grants to other resources. Defaults to true",
+				Optional:            true,
+			},
+			"effective_grants": schema.MapAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: "Everything the user can access in `database` as object → privileges, including grants made outside of terraform. Shown in plans for access reviews",
+				Computed:            true,
+			},
+			"exclusive": schema.BoolAttribute{
+				MarkdownDescription: "Revoke any privileges in `database` that aren't listed in `privileges`, e.g. grants made by hand. By default grants are additive",
+				Optional:            true,
+			},
+			"default_privileges_for_role": schema.StringAttribute{
+				MarkdownDescription: "Only grant `privileges` on tables created by this role in the future, instead of tables created by any role",
+				Optional:            true,
+			},
+			"privileges_in_schemas": schema.ListAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: "Schemas of `database` whose existing tables get `privileges`, defaults to `public`",
+				Optional:            true,
+				Validators: []validator.List{
+					listvalidator.SizeAtLeast(1),
+				},
+			},
+			"default_privileges_in_schemas": schema.ListAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: "Only grant `privileges` on tables created in these schemas in the future, instead of in any schema",
 				Optional:            true,
 			},
+			"labels": schema.MapAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: "Key/value labels stored in the provider managed `cockroachgke_metadata.labels` table, e.g. for chargeback reports",
+				Optional:            true,
+			},
+			"allow_destroy": schema.BoolAttribute{
+				MarkdownDescription: "Allow destroying this user while the provider has `deletion_protection` enabled",
+				Optional:            true,
+			},
+			"drop_owned": schema.BoolAttribute{
+				MarkdownDescription: "Destroy the user with `DROP OWNED BY`, which revokes all of its privileges and drops all objects it owns in `database`, instead of revoking the managed privileges. Objects owned in other databases still block the drop",
+				Optional:            true,
+			},
+			"recreate_if_missing": schema.BoolAttribute{
+				MarkdownDescription: "Repair a user dropped outside of terraform. A missing user, or one dropped and recreated under the same name, is removed from state on refresh so the next apply creates it again, adopting a same-named user if there is one, and reapplies all of its grants",
+				Optional:            true,
+			},
+			"ignore_password_changes": schema.BoolAttribute{
+				MarkdownDescription: "Only set `password` when creating the user. Later changes, including the first apply after an import, which can't read the password back, are stored without resetting the credentials of the user",
+				Optional:            true,
+			},
+			"temporary": schema.BoolAttribute{
+				MarkdownDescription: "Suffix the username with the CI run id and a random part and record an expiry, so `cockroachgke_cleanup_run` can drop the user if the pipeline never destroys it",
+				Optional:            true,
+				PlanModifiers:       []planmodifier.Bool{boolplanmodifier.RequiresReplace()},
+			},
+			"temporary_ttl": schema.StringAttribute{
+				MarkdownDescription: "How long a temporary user lives, e.g. `8h`. Defaults to `24h`, only evaluated on creation",
+				Optional:            true,
+			},
+			"fingerprint": schema.StringAttribute{
+				MarkdownDescription: "Cluster id and role id of the user, e.g. `9b1c2d3e-.../100`. Compare it before applying a promoted state to make sure the state belongs to the intended cluster. Unknown during updates since most of them recreate the user",
+				Computed:            true,
+			},
+			"full_username": schema.StringAttribute{
+				MarkdownDescription: "Name of a temporary user including its suffix, null otherwise",
+				Computed:            true,
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"expires_at": schema.StringAttribute{
+				MarkdownDescription: "RFC 3339 time after which a temporary user may be cleaned up, null otherwise",
+				Computed:            true,
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"last_applied_statements": lastAppliedStatementsAttribute(),
+		},
+		Blocks: map[string]schema.Block{
+			"grant": userGrantBlock(),
 		},
 	}
 }
 
+// ValidateConfig rejects grant settings on a user which doesn't manage its privileges
+func (r *UserResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var data UserResourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() || data.managesPrivileges() || data.ManagePrivileges.IsUnknown() {
+		return
+	}
+
+	for attribute, value := range map[string]attr.Value{
+		"privileges":                    data.Privileges,
+		"exclusive":                     data.Exclusive,
+		"default_privileges_for_role":   data.DefaultPrivilegesForRole,
+		"default_privileges_in_schemas": data.DefaultPrivilegesInSchemas,
+		"privileges_in_schemas":         data.PrivilegesInSchemas,
+		"external_connections":          data.ExternalConnections,
+	} {
+		if !value.IsNull() {
+			resp.Diagnostics.AddAttributeError(
+				path.Root(attribute),
+				"Conflicting privilege configuration",
+				fmt.Sprintf("%s has no effect while manage_privileges is false, grant privileges with a separate resource instead.", attribute),
+			)
+		}
+	}
+
+	// Absent blocks are an empty set rather than null
+	if len(data.Grants.Elements()) > 0 {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("grant"),
+			"Conflicting privilege configuration",
+			"grant has no effect while manage_privileges is false, grant privileges with a separate resource instead.",
+		)
+	}
+}
+
 // Configure adds the provider configured client to the resource
 func (r *UserResource) Configure(_ context.Context, req resource.ConfigureRequest, _ *resource.ConfigureResponse) {
 	if req.ProviderData == nil {
 		return
 	}
 
-	r.db = req.ProviderData.(*CockroachClient)
+	r.db = req.ProviderData.(Database)
 }
 
 // Create is for creating the user resource
@@ -87,8 +360,9 @@ func (r *UserResource) Create(ctx context.Context, req resource.CreateRequest, r
 	if resp.Diagnostics.HasError() {
 		return
 	}
+	ctx = maskSecrets(ctx, data.Password.ValueString())
 
-	client, err := r.db.Connect()
+	client, err := r.db.Connect(ctx)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Failed to connect to cockroach",
@@ -98,7 +372,23 @@ func (r *UserResource) Create(ctx context.Context, req resource.CreateRequest, r
 	}
 	defer client.Close()
 
-	pw := strings.Replace(data.Password.String(), "\"", "", -1)
+	data.FullUsername = types.StringNull()
+	data.ExpiresAt = types.StringNull()
+	if data.Temporary.ValueBool() {
+		expiresAt, err := temporaryExpiry(data.TemporaryTTL)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("temporary_ttl"), "Create user error", err.Error())
+			return
+		}
+		suffix, err := temporarySuffix()
+		if err != nil {
+			resp.Diagnostics.AddError("Create user error", fmt.Sprintf("Unable to generate a temporary suffix, got error: %s", err))
+			return
+		}
+		data.FullUsername = types.StringValue(data.Username.ValueString() + "_" + suffix)
+		data.ExpiresAt = types.StringValue(expiresAt.Format(time.RFC3339))
+	}
+
 	privString := ""
 	privList := data.Privileges.Elements()
 	last := len(privList) - 1
@@ -115,25 +405,141 @@ func (r *UserResource) Create(ctx context.Context, req resource.CreateRequest, r
 	}
 	privileges := strings.Replace(privString, "\"", "", -1)
 
-	query := fmt.Sprintf("SET DATABASE=%s; CREATE USER %s WITH PASSWORD '%s';", data.Database, data.Username, pw)
-	_, err = client.Exec(query)
+	// A missing database would only fail the statements after CREATE USER, leaving a half configured user behind
+	resp.Diagnostics.Append(checkDatabasesExist(ctx, client, data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// A user recreated outside of terraform is adopted, its grants are applied below like for a new one
+	query := createUserStatement(useDatabase(client, data.Database.ValueString()), data.sqlName().ValueString(), data.Password.ValueString(), data.RecreateIfMissing.ValueBool())
+	_, err = client.ExecContext(ctx, query)
 	if err != nil {
-		resp.Diagnostics.AddError("Create user error", fmt.Sprintf("Unable to create user, got error: %s", err))
+		resp.Diagnostics.AddError("Create user error", fmt.Sprintf("Unable to create user, got error: %s", scrubError(err, data.Password.ValueString())))
 		return
 	}
 
-	var tables string
-	alter := fmt.Sprintf("ALTER DEFAULT PRIVILEGES FOR ALL ROLES GRANT %s ON TABLES TO %s;", privileges, data.Username)
-	grant := fmt.Sprintf("GRANT %s ON * TO %s;", privileges, data.Username)
-	err = client.QueryRow("SHOW TABLES;").Scan(&tables)
-	if err == sql.ErrNoRows {
-		client.Exec(alter)
-	} else {
-		client.Exec(grant)
-		client.Exec(alter)
+	// From here on the user exists. Should a later step fail it stays in state, which Terraform marks tainted, so the
+	// retry replaces it instead of failing with "already exists"
+	defer func() {
+		if resp.Diagnostics.HasError() {
+			data.nullUnknowns(ctx)
+			resp.Diagnostics.Append(setProgress(ctx, resp.Private, operationCreateUser, "", progressCreated)...)
+			resp.Diagnostics.Append(setIdentity(ctx, resp.Identity, data.identity())...)
+			resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+		}
+	}()
+
+	dialect, err := client.Dialect(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Create user error", fmt.Sprintf("Unable to determine server version, got error: %s", err))
+		return
+	}
+
+	if data.managesPrivileges() {
+		var tables string
+		use := useDatabase(client, data.Database.ValueString())
+		alter := use + fmt.Sprintf("ALTER DEFAULT PRIVILEGES %s GRANT %s ON TABLES TO %s;", data.defaultPrivilegesScope(), privileges, data.sqlName())
+		grant := dialect.TablesGrant(privileges, data.Database.ValueString(), data.privilegeSchemas(), data.sqlName().ValueString())
+		err = client.QueryRowContext(idempotent(ctx), use+"SHOW TABLES;").Scan(&tables)
+		if err == sql.ErrNoRows {
+			_, err = client.ExecContext(idempotent(ctx), alter)
+		} else {
+			_, err = client.ExecContext(ctx, grant)
+			if err == nil {
+				_, err = client.ExecContext(idempotent(ctx), alter)
+			}
+		}
+		if err != nil {
+			resp.Diagnostics.AddError("Create user error", fmt.Sprintf("Unable to grant privileges, got error: %s", scrubError(err, data.Password.ValueString())))
+			return
+		}
+	}
+
+	if data.ObservabilityAccess.ValueBool() {
+		resp.Diagnostics.Append(grantObservabilityAccess(ctx, client, data.sqlName().ValueString())...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if data.ControlChangefeed.ValueBool() {
+		resp.Diagnostics.Append(grantControlChangefeed(ctx, client, data.sqlName().ValueString())...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if !data.SearchPath.IsNull() {
+		resp.Diagnostics.Append(applySearchPath(ctx, client, data.sqlName().ValueString(), data.SearchPath)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if data.managesPrivileges() {
+		resp.Diagnostics.Append(applyGrantBlocks(ctx, client, dialect, data.sqlName().ValueString(), data.Grants, false)...)
+		resp.Diagnostics.Append(applyExternalConnectionGrants(ctx, client, data.sqlName().ValueString(), data.ExternalConnections, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if data.Exclusive.ValueBool() && data.managesPrivileges() {
+		declared, diags := data.declaredPrivileges(ctx)
+		resp.Diagnostics.Append(diags...)
+		resp.Diagnostics.Append(revokeUnmanaged(ctx, client, data.Database.ValueString(), data.sqlName().ValueString(), declared)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	// Statements can succeed without creating the user, e.g. when sent to the wrong database, so check before reporting success
+	resp.Diagnostics.Append(verifyUser(ctx, client, data.sqlName().ValueString(), data.expectedOptions())...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
 	tflog.Trace(ctx, "created a user")
+
+	if data.Temporary.ValueBool() {
+		resp.Diagnostics.Append(recordExpiry(ctx, client, labelObjectUser, data.FullUsername.ValueString(), data.ExpiresAt.ValueString())...)
+	}
+
+	query, diags := dialect.UserID()
+	resp.Diagnostics.Append(diags...)
+
+	var id int64
+	err = client.QueryRowContext(ctx, query, data.sqlName().ValueString()).Scan(&id)
+	if err != nil {
+		resp.Diagnostics.AddError("Read user error", fmt.Sprintf("Unable to read user id, got error: %s", err))
+		return
+	}
+	resp.Diagnostics.Append(setPrivateID(ctx, resp.Private, privateKeyRoleID, id)...)
+
+	fingerprint, err := client.fingerprint(ctx, id)
+	if err != nil {
+		resp.Diagnostics.AddError("Read user error", fmt.Sprintf("Unable to read cluster id, got error: %s", err))
+		return
+	}
+	data.Fingerprint = fingerprint
+
+	if !data.Labels.IsNull() {
+		resp.Diagnostics.Append(writeLabels(ctx, client, labelObjectUser, data.sqlName().ValueString(), data.Labels)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	grants, diags := effectiveGrants(ctx, client, data.Database.ValueString(), data.sqlName().ValueString())
+	resp.Diagnostics.Append(diags...)
+	data.EffectiveGrants = grants
+
+	statements, diags := client.appliedStatements(ctx)
+	resp.Diagnostics.Append(diags...)
+	data.LastAppliedStatements = statements
+	resp.Diagnostics.Append(setIdentity(ctx, resp.Identity, data.identity())...)
+
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
@@ -145,7 +551,7 @@ func (r *UserResource) Read(ctx context.Context, req resource.ReadRequest, resp
 		return
 	}
 
-	client, err := r.db.Connect()
+	client, err := r.db.ConnectForRead(ctx)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Failed to connect to cockroach",
@@ -153,36 +559,145 @@ func (r *UserResource) Read(ctx context.Context, req resource.ReadRequest, resp
 		)
 		return
 	}
+	defer client.Close()
 
-	queryName := strings.Replace(data.Username.String(), "\"", "", -1)
-	type rowData struct {
-		db        string
-		schema    string
-		relation  string
-		grantee   string
-		privilege string
-		grantable string
-	}
-	privilegeReadSlice := []string{}
+	queryName := data.sqlName().ValueString()
 
-	q := fmt.Sprintf("SET DATABASE=%s; SHOW GRANTS FOR %s", data.Database, queryName)
+	dialect, err := client.Dialect(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Read user error", fmt.Sprintf("Unable to determine server version, got error: %s", err))
+		return
+	}
+	query, diags := dialect.Users()
+	resp.Diagnostics.Append(diags...)
 
-	rows, err := client.Query(q)
+	id, err := client.cache().userID(ctx, client, query, queryName)
+	if err == sql.ErrNoRows {
+		if data.RecreateIfMissing.ValueBool() {
+			resp.Diagnostics.AddWarning(
+				"User is missing",
+				fmt.Sprintf("User %s was dropped outside of terraform, the next apply recreates it with its grants.", queryName),
+			)
+		}
+		resp.State.RemoveResource(ctx)
+		return
+	}
 	if err != nil {
+		resp.Diagnostics.AddError("Read user error", fmt.Sprintf("Unable to read user, got error: %s", err))
+		return
+	}
+
+	// A different id under the same username means the user was dropped and recreated outside of terraform
+	storedID, ok, diags := getPrivateID(ctx, req.Private, privateKeyRoleID)
+	resp.Diagnostics.Append(diags...)
+	if ok && storedID != id && data.RecreateIfMissing.ValueBool() {
+		resp.Diagnostics.AddWarning(
+			"User was recreated",
+			fmt.Sprintf("User %s now has id %d instead of %d, it was dropped and recreated outside of terraform. The next apply adopts it and reapplies its grants.", queryName, id, storedID),
+		)
 		resp.State.RemoveResource(ctx)
 		return
-	} else {
-		for rows.Next() {
-			rowDataStruct := rowData{}
-			rows.Scan(&rowDataStruct.db, &rowDataStruct.schema, &rowDataStruct.relation, &rowDataStruct.grantee, &rowDataStruct.privilege, &rowDataStruct.grantable)
-			if !slices.Contains(privilegeReadSlice, rowDataStruct.privilege) {
-				privilegeReadSlice = append(privilegeReadSlice, rowDataStruct.privilege)
+	}
+	if ok && storedID != id {
+		resp.Diagnostics.AddWarning(
+			"User was recreated",
+			fmt.Sprintf("User %s now has id %d instead of %d, it was dropped and recreated outside of terraform.", queryName, id, storedID),
+		)
+	}
+	resp.Diagnostics.Append(setPrivateID(ctx, resp.Private, privateKeyRoleID, id)...)
+
+	fingerprint, err := client.fingerprint(ctx, id)
+	if err != nil {
+		resp.Diagnostics.AddError("Read user error", fmt.Sprintf("Unable to read cluster id, got error: %s", err))
+		return
+	}
+	data.Fingerprint = fingerprint
+
+	labels, diags := readLabels(ctx, client, labelObjectUser, queryName)
+	resp.Diagnostics.Append(diags...)
+	data.Labels = labels
+
+	grants, diags := effectiveGrants(ctx, client, data.Database.ValueString(), data.sqlName().ValueString())
+	resp.Diagnostics.Append(diags...)
+	data.EffectiveGrants = grants
+
+	resp.Diagnostics.Append(setIdentity(ctx, resp.Identity, data.identity())...)
+
+	// Grants belong to other resources
+	if !data.managesPrivileges() {
+		resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+		return
+	}
+
+	privilegeReadSlice, err := grantedPrivileges(ctx, client, data.Database.ValueString(), queryName)
+	if err != nil {
+		resp.Diagnostics.AddError("Read user error", fmt.Sprintf("Unable to read grants, got error: %s", err))
+		return
+	}
+
+	grantBlocks, diags := readGrantBlocks(ctx, client, queryName, data.Grants)
+	resp.Diagnostics.Append(diags...)
+	data.Grants = grantBlocks
+
+	if !data.SearchPath.IsNull() {
+		searchPath, err := userSearchPath(ctx, client, queryName)
+		if err != nil {
+			resp.Diagnostics.AddError("Read user error", fmt.Sprintf("Unable to read search path, got error: %s", err))
+			return
+		}
+		// Keep the configured spelling unless the schemas differ
+		if normalizeSearchPath(searchPath) != normalizeSearchPath(data.SearchPath.ValueString()) {
+			data.SearchPath = types.StringValue(searchPath)
+		}
+	}
+
+	if !data.ExternalConnections.IsNull() {
+		connections, err := externalConnectionGrants(ctx, client, queryName)
+		if err != nil {
+			resp.Diagnostics.AddError("Read user error", fmt.Sprintf("Unable to read external connection grants, got error: %s", err))
+			return
+		}
+		set, diags := types.SetValueFrom(ctx, types.StringType, connections)
+		resp.Diagnostics.Append(diags...)
+		data.ExternalConnections = set
+	}
+
+	// In exclusive mode any extra grant is drift, show everything that was found so the next apply revokes it
+	if data.Exclusive.ValueBool() && !data.Privileges.IsNull() {
+		declaredList, diags := data.declaredPrivileges(ctx)
+		resp.Diagnostics.Append(diags...)
+		declared := []string{}
+		resp.Diagnostics.Append(declaredList.ElementsAs(ctx, &declared, false)...)
+
+		found := []string{}
+		extra := false
+		for _, p := range privilegeReadSlice {
+			found = append(found, strings.ToLower(p))
+			if !slices.Contains(declared, strings.ToLower(p)) {
+				extra = true
 			}
 		}
+		if extra {
+			list, diags := types.ListValueFrom(ctx, types.StringType, found)
+			resp.Diagnostics.Append(diags...)
+			data.Privileges = list
+		}
 	}
 
-	//resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
-	defer client.Close()
+	// Imported users have no privileges in state yet, fill them in from the grants so generated config is complete
+	if data.Privileges.IsNull() && len(privilegeReadSlice) > 0 {
+		privileges := []string{}
+		for _, p := range privilegeReadSlice {
+			if slices.Contains(privilegeSlice, strings.ToLower(p)) {
+				privileges = append(privileges, strings.ToLower(p))
+			}
+		}
+		list, diags := types.ListValueFrom(ctx, types.StringType, privileges)
+		resp.Diagnostics.Append(diags...)
+		data.Privileges = list
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
 func (r *UserResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
@@ -198,8 +713,26 @@ func (r *UserResource) Update(ctx context.Context, req resource.UpdateRequest, r
 	if resp.Diagnostics.HasError() {
 		return
 	}
+	ctx = maskSecrets(ctx, data.Password.ValueString(), state.Password.ValueString())
+
+	// Recreating the user for a new password would reset its credentials, record the password without applying it
+	if data.IgnorePasswordChanges.ValueBool() {
+		onlyPassword, err := onlyPasswordChanged(req.State.Raw, req.Plan.Raw)
+		if err != nil {
+			resp.Diagnostics.AddError("Update user error", fmt.Sprintf("Unable to compare plan and state, got error: %s", err))
+			return
+		}
+		if onlyPassword {
+			data.Fingerprint = state.Fingerprint
+			data.EffectiveGrants = state.EffectiveGrants
+			data.LastAppliedStatements = state.LastAppliedStatements
+			resp.Diagnostics.Append(setIdentity(ctx, resp.Identity, data.identity())...)
+			resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+			return
+		}
+	}
 
-	client, err := r.db.Connect()
+	client, err := r.db.Connect(ctx)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Failed to connect to cockroach",
@@ -209,42 +742,131 @@ func (r *UserResource) Update(ctx context.Context, req resource.UpdateRequest, r
 	}
 	defer client.Close()
 
+	// Recreating the user would lose grants made by other resources, so only the password is changed in place
+	if !data.managesPrivileges() && state.Username.Equal(data.Username) {
+		if !data.IgnorePasswordChanges.ValueBool() {
+			_, err = client.ExecContext(ctx, fmt.Sprintf("ALTER USER %s WITH PASSWORD %s", pq.QuoteIdentifier(data.sqlName().ValueString()), pq.QuoteLiteral(data.Password.ValueString())))
+			if err != nil {
+				resp.Diagnostics.AddError("Update user error", fmt.Sprintf("Unable to alter user, got error: %s", scrubError(err, data.Password.ValueString())))
+				return
+			}
+		}
+
+		if data.ObservabilityAccess.ValueBool() && !state.ObservabilityAccess.ValueBool() {
+			resp.Diagnostics.Append(grantObservabilityAccess(ctx, client, data.sqlName().ValueString())...)
+		}
+		if !data.ObservabilityAccess.ValueBool() && state.ObservabilityAccess.ValueBool() {
+			resp.Diagnostics.Append(revokeObservabilityAccess(ctx, client, data.sqlName().ValueString())...)
+		}
+		if data.ControlChangefeed.ValueBool() && !state.ControlChangefeed.ValueBool() {
+			resp.Diagnostics.Append(grantControlChangefeed(ctx, client, data.sqlName().ValueString())...)
+		}
+		if !data.ControlChangefeed.ValueBool() && state.ControlChangefeed.ValueBool() {
+			resp.Diagnostics.Append(revokeControlChangefeed(ctx, client, data.sqlName().ValueString())...)
+		}
+		// Connections are granted along with the other privileges, but ones dropped from the config are still revoked
+		// so switching manage_privileges off doesn't leave them behind
+		removed, diags := removedElements(ctx, state.ExternalConnections, data.ExternalConnections)
+		resp.Diagnostics.Append(diags...)
+		resp.Diagnostics.Append(applyExternalConnectionGrants(ctx, client, data.sqlName().ValueString(), removed, true)...)
+		if !state.SearchPath.Equal(data.SearchPath) {
+			resp.Diagnostics.Append(applySearchPath(ctx, client, data.sqlName().ValueString(), data.SearchPath)...)
+		}
+		if !state.Labels.Equal(data.Labels) {
+			resp.Diagnostics.Append(writeLabels(ctx, client, labelObjectUser, data.sqlName().ValueString(), data.Labels)...)
+		}
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		tflog.Trace(ctx, "altered a user")
+
+		// The user keeps its id when altered in place
+		data.Fingerprint = state.Fingerprint
+
+		grants, diags := effectiveGrants(ctx, client, data.Database.ValueString(), data.sqlName().ValueString())
+		resp.Diagnostics.Append(diags...)
+		data.EffectiveGrants = grants
+
+		statements, diags := client.appliedStatements(ctx)
+		resp.Diagnostics.Append(diags...)
+		data.LastAppliedStatements = statements
+		resp.Diagnostics.Append(setIdentity(ctx, resp.Identity, data.identity())...)
+
+		resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+		return
+	}
+
+	dialect, err := client.Dialect(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Update user error", fmt.Sprintf("Unable to determine server version, got error: %s", err))
+		return
+	}
+
+	// Check before the old user is dropped
+	resp.Diagnostics.Append(checkDatabasesExist(ctx, client, data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	alter := ""
 	revoke := ""
 	delete := ""
 
 	// Check for username change
 	if state.Username != data.Username {
-		alter = fmt.Sprintf("SET DATABASE=%s; ALTER DEFAULT PRIVILEGES FOR ALL ROLES REVOKE ALL ON TABLES FROM %s; ", data.Database, state.Username)
-		revoke = fmt.Sprintf("REVOKE ALL ON * FROM %s; ", state.Username)
-		delete = fmt.Sprintf("DROP USER %s;", state.Username)
+		alter = fmt.Sprintf("ALTER DEFAULT PRIVILEGES %s REVOKE ALL ON TABLES FROM %s; ", state.defaultPrivilegesScope(), state.sqlName())
+		revoke = dialect.TablesRevoke(state.Database.ValueString(), state.privilegeSchemas(), state.sqlName().ValueString()) + "; "
+		delete = fmt.Sprintf("DROP USER %s;", state.sqlName())
 	} else {
 		// DELETE THE USER - CAN WE JUST CALL DELETE INSTEAD OF REPEATING THE CODE?
-		alter = fmt.Sprintf("SET DATABASE=%s; ALTER DEFAULT PRIVILEGES FOR ALL ROLES REVOKE ALL ON TABLES FROM %s; ", data.Database, data.Username)
-		revoke = fmt.Sprintf("REVOKE ALL ON * FROM %s; ", data.Username)
-		delete = fmt.Sprintf("DROP USER %s;", data.Username)
+		alter = fmt.Sprintf("ALTER DEFAULT PRIVILEGES %s REVOKE ALL ON TABLES FROM %s; ", state.defaultPrivilegesScope(), data.sqlName())
+		revoke = dialect.TablesRevoke(state.Database.ValueString(), state.privilegeSchemas(), data.sqlName().ValueString()) + "; "
+		delete = fmt.Sprintf("DROP USER %s;", data.sqlName())
 	}
 
-	var tables string
-	err = client.QueryRow(fmt.Sprintf("SET DATABASE=%s; SHOW TABLES;", data.Database)).Scan(&tables)
-	if err == sql.ErrNoRows {
-		_, err = client.Exec(alter + delete)
-		if err != nil {
-			resp.Diagnostics.AddError("Delete user error (no tables)", fmt.Sprintf("Unable to delete user, got error: %s", err))
-			return
+	// Grants belong to other resources, only the account itself is dropped
+	if !data.managesPrivileges() {
+		alter = ""
+		revoke = ""
+	}
+
+	// A retry of a failed recreate continues after the steps that already succeeded, the old user may already be gone
+	digest := progressDigest(req.Plan.Raw)
+	done, diags := getProgress(ctx, req.Private, operationRecreateUser, digest)
+	resp.Diagnostics.Append(diags...)
+
+	if done < progressDropped {
+		if state.managesPrivileges() {
+			resp.Diagnostics.Append(applyGrantBlocks(ctx, client, dialect, state.sqlName().ValueString(), state.Grants, true)...)
+			resp.Diagnostics.Append(applyExternalConnectionGrants(ctx, client, state.sqlName().ValueString(), state.ExternalConnections, true)...)
+			if resp.Diagnostics.HasError() {
+				return
+			}
 		}
-	} else {
-		_, err = client.Exec(alter + revoke + delete)
-		if err != nil {
-			resp.Diagnostics.AddError("Delete user error (tables)", fmt.Sprintf("Unable to delete user, got error: %s", err))
-			return
+
+		var tables string
+		use := useDatabase(client, data.Database.ValueString())
+		err = client.QueryRowContext(idempotent(ctx), use+"SHOW TABLES;").Scan(&tables)
+		if err == sql.ErrNoRows {
+			_, err = client.ExecContext(ctx, use+alter+delete)
+			if err != nil {
+				resp.Diagnostics.AddError("Delete user error (no tables)", fmt.Sprintf("Unable to delete user, got error: %s", err))
+				return
+			}
+		} else {
+			_, err = client.ExecContext(ctx, use+alter+revoke+delete)
+			if err != nil {
+				resp.Diagnostics.AddError("Delete user error (tables)", fmt.Sprintf("Unable to delete user, got error: %s", err))
+				return
+			}
 		}
-	}
 
-	tflog.Trace(ctx, "deleted a user")
+		tflog.Trace(ctx, "deleted a user")
+		resp.Diagnostics.Append(setProgress(ctx, resp.Private, operationRecreateUser, digest, progressDropped)...)
+	}
 
 	// CREATE THE USER AGAIN - CAN WE CALL CREATE INSTEAD OF REPEATING THE CODE
-	pw := strings.Replace(data.Password.String(), "\"", "", -1)
 	privString := ""
 	privList := data.Privileges.Elements()
 	last := len(privList) - 1
@@ -261,25 +883,119 @@ func (r *UserResource) Update(ctx context.Context, req resource.UpdateRequest, r
 	}
 	privileges := strings.Replace(privString, "\"", "", -1)
 
-	query := fmt.Sprintf("SET DATABASE=%s; CREATE USER %s WITH PASSWORD '%s';", data.Database, data.Username, pw)
-	_, err = client.Exec(query)
+	if done < progressCreated {
+		query := createUserStatement(useDatabase(client, data.Database.ValueString()), data.sqlName().ValueString(), data.Password.ValueString(), false)
+		_, err = client.ExecContext(ctx, query)
+		if err != nil {
+			resp.Diagnostics.AddError("Create user error", fmt.Sprintf("Unable to create user, got error: %s", scrubError(err, data.Password.ValueString())))
+			return
+		}
+		resp.Diagnostics.Append(setProgress(ctx, resp.Private, operationRecreateUser, digest, progressCreated)...)
+	}
+
+	// Recreating the user assigns a new id
+	query, diags := dialect.UserID()
+	resp.Diagnostics.Append(diags...)
+
+	var id int64
+	err = client.QueryRowContext(ctx, query, data.sqlName().ValueString()).Scan(&id)
 	if err != nil {
-		resp.Diagnostics.AddError("Create user error", fmt.Sprintf("Unable to create user, got error: %s", err))
+		resp.Diagnostics.AddError("Read user error", fmt.Sprintf("Unable to read user id, got error: %s", err))
 		return
 	}
+	resp.Diagnostics.Append(setPrivateID(ctx, resp.Private, privateKeyRoleID, id)...)
 
-	var tables2 string
-	alter = fmt.Sprintf("ALTER DEFAULT PRIVILEGES FOR ALL ROLES GRANT %s ON TABLES TO %s;", privileges, data.Username)
-	grant := fmt.Sprintf("GRANT %s ON * TO %s;", privileges, data.Username)
-	err = client.QueryRow("SHOW TABLES;").Scan(&tables2)
-	if err == sql.ErrNoRows {
-		client.Exec(alter)
-	} else {
-		client.Exec(grant)
-		client.Exec(alter)
+	fingerprint, err := client.fingerprint(ctx, id)
+	if err != nil {
+		resp.Diagnostics.AddError("Read user error", fmt.Sprintf("Unable to read cluster id, got error: %s", err))
+		return
+	}
+	data.Fingerprint = fingerprint
+
+	if data.managesPrivileges() {
+		var tables2 string
+		use := useDatabase(client, data.Database.ValueString())
+		alter = use + fmt.Sprintf("ALTER DEFAULT PRIVILEGES %s GRANT %s ON TABLES TO %s;", data.defaultPrivilegesScope(), privileges, data.sqlName())
+		grant := dialect.TablesGrant(privileges, data.Database.ValueString(), data.privilegeSchemas(), data.sqlName().ValueString())
+		err = client.QueryRowContext(idempotent(ctx), use+"SHOW TABLES;").Scan(&tables2)
+		if err == sql.ErrNoRows {
+			_, err = client.ExecContext(idempotent(ctx), alter)
+		} else {
+			_, err = client.ExecContext(ctx, grant)
+			if err == nil {
+				_, err = client.ExecContext(idempotent(ctx), alter)
+			}
+		}
+		if err != nil {
+			resp.Diagnostics.AddError("Update user error", fmt.Sprintf("Unable to grant privileges, got error: %s", scrubError(err, data.Password.ValueString())))
+			return
+		}
+	}
+
+	if data.ObservabilityAccess.ValueBool() {
+		resp.Diagnostics.Append(grantObservabilityAccess(ctx, client, data.sqlName().ValueString())...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if data.ControlChangefeed.ValueBool() {
+		resp.Diagnostics.Append(grantControlChangefeed(ctx, client, data.sqlName().ValueString())...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if !data.SearchPath.IsNull() || !state.SearchPath.IsNull() {
+		resp.Diagnostics.Append(applySearchPath(ctx, client, data.sqlName().ValueString(), data.SearchPath)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if data.managesPrivileges() {
+		resp.Diagnostics.Append(applyGrantBlocks(ctx, client, dialect, data.sqlName().ValueString(), data.Grants, false)...)
+		resp.Diagnostics.Append(applyExternalConnectionGrants(ctx, client, data.sqlName().ValueString(), data.ExternalConnections, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if data.Exclusive.ValueBool() && data.managesPrivileges() {
+		declared, diags := data.declaredPrivileges(ctx)
+		resp.Diagnostics.Append(diags...)
+		resp.Diagnostics.Append(revokeUnmanaged(ctx, client, data.Database.ValueString(), data.sqlName().ValueString(), declared)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	// Statements can succeed without creating the user, e.g. when sent to the wrong database, so check before reporting success
+	resp.Diagnostics.Append(verifyUser(ctx, client, data.sqlName().ValueString(), data.expectedOptions())...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
 	tflog.Trace(ctx, "created a user")
+
+	if !state.Username.Equal(data.Username) {
+		resp.Diagnostics.Append(deleteLabels(ctx, client, labelObjectUser, state.sqlName().ValueString())...)
+	}
+	resp.Diagnostics.Append(writeLabels(ctx, client, labelObjectUser, data.sqlName().ValueString(), data.Labels)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	grants, diags := effectiveGrants(ctx, client, data.Database.ValueString(), data.sqlName().ValueString())
+	resp.Diagnostics.Append(diags...)
+	data.EffectiveGrants = grants
+
+	statements, diags := client.appliedStatements(ctx)
+	resp.Diagnostics.Append(diags...)
+	data.LastAppliedStatements = statements
+	resp.Diagnostics.Append(setIdentity(ctx, resp.Identity, data.identity())...)
+	resp.Diagnostics.Append(clearProgress(ctx, resp.Private)...)
+
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
@@ -290,7 +1006,17 @@ func (r *UserResource) Delete(ctx context.Context, req resource.DeleteRequest, r
 		return
 	}
 
-	client, err := r.db.Connect()
+	// A user whose create failed halfway was never fully applied, deletion protection doesn't keep it around
+	incomplete, diags := getProgress(ctx, req.Private, operationCreateUser, "")
+	resp.Diagnostics.Append(diags...)
+	if incomplete == 0 {
+		resp.Diagnostics.Append(r.db.checkDeletionProtection("user", data.sqlName().ValueString(), data.AllowDestroy)...)
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, err := r.db.Connect(ctx)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Failed to connect to cockroach",
@@ -300,29 +1026,434 @@ func (r *UserResource) Delete(ctx context.Context, req resource.DeleteRequest, r
 	}
 	defer client.Close()
 
-	alter := fmt.Sprintf("SET DATABASE=%s; ALTER DEFAULT PRIVILEGES FOR ALL ROLES REVOKE ALL ON TABLES FROM %s; ", data.Database, data.Username)
-	revoke := fmt.Sprintf("REVOKE ALL ON * FROM %s; ", data.Username)
-	delete := fmt.Sprintf("DROP USER %s;", data.Username)
+	dialect, err := client.Dialect(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Delete user error", fmt.Sprintf("Unable to determine server version, got error: %s", err))
+		return
+	}
+
+	alter := fmt.Sprintf("ALTER DEFAULT PRIVILEGES %s REVOKE ALL ON TABLES FROM %s; ", data.defaultPrivilegesScope(), data.sqlName())
+	revoke := dialect.TablesRevoke(data.Database.ValueString(), data.privilegeSchemas(), data.sqlName().ValueString()) + "; "
+	delete := fmt.Sprintf("DROP USER %s;", data.sqlName())
+
+	// Grants belong to other resources, only the account itself is dropped
+	if !data.managesPrivileges() {
+		alter = ""
+		revoke = ""
+	}
+
+	// DROP OWNED BY clears privileges, default privileges and owned objects in one pass
+	if data.DropOwned.ValueBool() {
+		alter = fmt.Sprintf("DROP OWNED BY %s; ", data.sqlName())
+		revoke = ""
+	}
+
+	if data.managesPrivileges() {
+		// DROP OWNED BY only clears the privileges in the database it runs in, grants elsewhere still block DROP USER
+		grants := data.Grants
+		if data.DropOwned.ValueBool() {
+			grants, diags = grantsOutside(ctx, data.Grants, data.Database.ValueString())
+			resp.Diagnostics.Append(diags...)
+		}
+		resp.Diagnostics.Append(applyGrantBlocks(ctx, client, dialect, data.sqlName().ValueString(), grants, true)...)
+		resp.Diagnostics.Append(applyExternalConnectionGrants(ctx, client, data.sqlName().ValueString(), data.ExternalConnections, true)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
 
 	var delTables string
-	err = client.QueryRow(fmt.Sprintf("SET DATABASE=%s; SHOW TABLES;", data.Database)).Scan(&delTables)
+	use := useDatabase(client, data.Database.ValueString())
+	err = client.QueryRowContext(idempotent(ctx), use+"SHOW TABLES;").Scan(&delTables)
 	if err == sql.ErrNoRows {
-		_, err = client.Exec(alter + delete)
+		_, err = client.ExecContext(ctx, use+alter+delete)
 		if err != nil {
 			resp.Diagnostics.AddError("Delete user error (no tables)", fmt.Sprintf("Unable to delete user, got error: %s", err))
 			return
 		}
 	} else {
-		_, err = client.Exec(alter + revoke + delete)
+		_, err = client.ExecContext(ctx, use+alter+revoke+delete)
 		if err != nil {
 			resp.Diagnostics.AddError("Delete user error (tables)", fmt.Sprintf("Unable to delete user, got error: %s", err))
 			return
 		}
 	}
 	tflog.Trace(ctx, "deleted a user")
+
+	resp.Diagnostics.Append(deleteLabels(ctx, client, labelObjectUser, data.sqlName().ValueString())...)
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
+// grantObservabilityAccess lets a user see cluster activity and settings, in whichever way the server version supports
+func grantObservabilityAccess(ctx context.Context, client Session, username string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	dialect, err := client.Dialect(ctx)
+	if err != nil {
+		diags.AddError("Grant observability error", fmt.Sprintf("Unable to determine server version, got error: %s", err))
+		return diags
+	}
+
+	query, d := dialect.ObservabilityGrant(username)
+	diags.Append(d...)
+
+	_, err = client.ExecContext(ctx, query)
+	if err != nil {
+		diags.AddError("Grant observability error", fmt.Sprintf("Unable to grant observability access, got error: %s", err))
+	}
+	return diags
+}
+
+// revokeObservabilityAccess takes back VIEWACTIVITY and VIEWCLUSTERSETTING
+func revokeObservabilityAccess(ctx context.Context, client Session, username string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	dialect, err := client.Dialect(ctx)
+	if err != nil {
+		diags.AddError("Revoke observability error", fmt.Sprintf("Unable to determine server version, got error: %s", err))
+		return diags
+	}
+
+	query, d := dialect.ObservabilityRevoke(username)
+	diags.Append(d...)
+
+	_, err = client.ExecContext(ctx, query)
+	if err != nil {
+		diags.AddError("Revoke observability error", fmt.Sprintf("Unable to revoke observability access, got error: %s", err))
+	}
+	return diags
+}
+
+// checkDatabasesExist reports every database the user and its grant blocks refer to which doesn't exist yet
+func checkDatabasesExist(ctx context.Context, client Session, data *UserResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	databases := map[string]path.Path{data.Database.ValueString(): path.Root("database")}
+	if data.managesPrivileges() {
+		blocks, blockDiags := userGrants(ctx, data.Grants)
+		diags.Append(blockDiags...)
+		for _, g := range blocks {
+			if _, ok := databases[g.Database.ValueString()]; !ok {
+				databases[g.Database.ValueString()] = path.Root("grant")
+			}
+		}
+	}
+
+	dialect, err := client.Dialect(ctx)
+	if err != nil {
+		diags.AddError("Check database error", fmt.Sprintf("Unable to determine server version, got error: %s", err))
+		return diags
+	}
+	query, queryDiags := dialect.DatabaseByName()
+	diags.Append(queryDiags...)
+
+	names := make([]string, 0, len(databases))
+	for name := range databases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		var id int64
+		var found string
+		err := client.QueryRowContext(ctx, query, name).Scan(&id, &found)
+		if err == sql.ErrNoRows {
+			diags.AddAttributeError(
+				databases[name],
+				"Missing database",
+				fmt.Sprintf("Database %s does not exist. If it is created in the same apply, reference the name attribute of its %s_database resource or add that resource to depends_on, so it is created before the user.", name, typeName),
+			)
+			continue
+		}
+		if err != nil {
+			diags.AddError("Check database error", fmt.Sprintf("Unable to check whether database %s exists, got error: %s", name, err))
+		}
+	}
+	return diags
+}
+
+// createUserStatement creates the user with its password, or adopts an existing user of the same name. use switches
+// to the user's database, which makes the statement fail early when the database is missing.
+func createUserStatement(use string, username string, password string, adopt bool) string {
+	if adopt {
+		return fmt.Sprintf("%sCREATE USER IF NOT EXISTS %s; ALTER USER %s WITH PASSWORD %s;", use, pq.QuoteIdentifier(username), pq.QuoteIdentifier(username), pq.QuoteLiteral(password))
+	}
+	return fmt.Sprintf("%sCREATE USER %s WITH PASSWORD %s;", use, pq.QuoteIdentifier(username), pq.QuoteLiteral(password))
+}
+
+// onlyPasswordChanged reports whether the plan differs from the state in nothing but the password, ignoring computed
+// values which are unknown until applied
+func onlyPasswordChanged(state tftypes.Value, plan tftypes.Value) (bool, error) {
+	diffs, err := state.Diff(plan)
+	if err != nil {
+		return false, err
+	}
+
+	for _, d := range diffs {
+		if d.Value2 != nil && !d.Value2.IsKnown() {
+			continue
+		}
+		steps := d.Path.Steps()
+		// The object holding the changes is reported as well
+		if len(steps) == 0 {
+			continue
+		}
+		if name, ok := steps[0].(tftypes.AttributeName); ok && (name == "password" || name == "ignore_password_changes") {
+			continue
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+// nullUnknowns replaces the computed values which are still unknown, so a half created user can be kept in state
+func (m *UserResourceModel) nullUnknowns(ctx context.Context) {
+	if m.EffectiveGrants.IsUnknown() {
+		m.EffectiveGrants = types.MapNull(m.EffectiveGrants.ElementType(ctx))
+	}
+	if m.LastAppliedStatements.IsUnknown() {
+		m.LastAppliedStatements = types.ListNull(m.LastAppliedStatements.ElementType(ctx))
+	}
+	if m.Fingerprint.IsUnknown() {
+		m.Fingerprint = types.StringNull()
+	}
+}
+
+// expectedOptions are the role options a freshly created user should show
+func (m *UserResourceModel) expectedOptions() []string {
+	options := []string{}
+	if m.ControlChangefeed.ValueBool() {
+		options = append(options, "CONTROLCHANGEFEED")
+	}
+	return options
+}
+
+// verifyUser checks through SHOW USERS that the user exists, can log in and has the expected role options
+func verifyUser(ctx context.Context, client Executor, username string, expected []string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	// Options are a string before 23.1 and an array after, the cast covers both
+	var options string
+	err := client.QueryRowContext(ctx, "SELECT options::STRING FROM [SHOW USERS] WHERE username = $1", username).Scan(&options)
+	if err == sql.ErrNoRows {
+		diags.AddError("Create user error", fmt.Sprintf("User %s doesn't exist after CREATE USER succeeded, check that the provider connects to the intended cluster and may create users", username))
+		return diags
+	}
+	if err != nil {
+		diags.AddError("Create user error", fmt.Sprintf("Unable to verify user, got error: %s", err))
+		return diags
+	}
+
+	found := parseRoleOptions(options)
+	if slices.Contains(found, "NOLOGIN") {
+		diags.AddError("Create user error", fmt.Sprintf("User %s was created without the LOGIN option", username))
+	}
+	for _, option := range expected {
+		if !slices.Contains(found, option) {
+			diags.AddError("Create user error", fmt.Sprintf("User %s is missing the %s option after it was granted, got options: %s", username, option, options))
+		}
+	}
+	return diags
+}
+
+// parseRoleOptions splits the options column of SHOW USERS, e.g. {CONTROLCHANGEFEED,VIEWACTIVITY} or "NOLOGIN, VALID UNTIL=..."
+func parseRoleOptions(options string) []string {
+	parsed := []string{}
+	for _, option := range strings.Split(strings.Trim(options, "{}"), ",") {
+		option = strings.Trim(strings.TrimSpace(option), `"`)
+		if option == "" {
+			continue
+		}
+		// Options with a value like VALID UNTIL=... are compared by name
+		name, _, _ := strings.Cut(option, "=")
+		parsed = append(parsed, strings.ToUpper(name))
+	}
+	return parsed
+}
+
+// grantControlChangefeed sets the CONTROLCHANGEFEED role option
+func grantControlChangefeed(ctx context.Context, client Session, username string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	dialect, err := client.Dialect(ctx)
+	if err != nil {
+		diags.AddError("Grant changefeed control error", fmt.Sprintf("Unable to determine server version, got error: %s", err))
+		return diags
+	}
+
+	query, d := dialect.ControlChangefeedGrant(username)
+	diags.Append(d...)
+
+	_, err = client.ExecContext(ctx, query)
+	if err != nil {
+		diags.AddError("Grant changefeed control error", fmt.Sprintf("Unable to grant CONTROLCHANGEFEED, got error: %s", err))
+	}
+	return diags
+}
+
+// revokeControlChangefeed drops the CONTROLCHANGEFEED role option
+func revokeControlChangefeed(ctx context.Context, client Session, username string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	dialect, err := client.Dialect(ctx)
+	if err != nil {
+		diags.AddError("Revoke changefeed control error", fmt.Sprintf("Unable to determine server version, got error: %s", err))
+		return diags
+	}
+
+	query, d := dialect.ControlChangefeedRevoke(username)
+	diags.Append(d...)
+
+	_, err = client.ExecContext(ctx, query)
+	if err != nil {
+		diags.AddError("Revoke changefeed control error", fmt.Sprintf("Unable to revoke CONTROLCHANGEFEED, got error: %s", err))
+	}
+	return diags
+}
+
+// searchPathStatement sets the default search path of a user, an empty one resets it
+func searchPathStatement(username string, searchPath string) string {
+	schemas := []string{}
+	for _, schema := range strings.Split(normalizeSearchPath(searchPath), ",") {
+		if schema != "" {
+			schemas = append(schemas, pq.QuoteLiteral(schema))
+		}
+	}
+	if len(schemas) == 0 {
+		return fmt.Sprintf("ALTER USER %s RESET search_path", pq.QuoteIdentifier(username))
+	}
+	return fmt.Sprintf("ALTER USER %s SET search_path = %s", pq.QuoteIdentifier(username), strings.Join(schemas, ", "))
+}
+
+// normalizeSearchPath drops spaces and quotes around the schemas, e.g. `app, "public"` becomes `app,public`
+func normalizeSearchPath(searchPath string) string {
+	schemas := []string{}
+	for _, schema := range strings.Split(searchPath, ",") {
+		schemas = append(schemas, strings.Trim(strings.TrimSpace(schema), `"'`))
+	}
+	return strings.Join(schemas, ",")
+}
+
+// applySearchPath sets or resets the default search path of a user
+func applySearchPath(ctx context.Context, client Executor, username string, searchPath types.String) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	_, err := client.ExecContext(ctx, searchPathStatement(username, searchPath.ValueString()))
+	if err != nil {
+		diags.AddError("Set search path error", fmt.Sprintf("Unable to set the search path of %s, got error: %s", username, err))
+	}
+	return diags
+}
+
+// grantedPrivileges lists the distinct privileges a user holds in a database, in the order SHOW GRANTS returns them
+func grantedPrivileges(ctx context.Context, client Executor, database string, username string) ([]string, error) {
+	grants, err := showGrants(ctx, client, database, username)
+	if err != nil {
+		return nil, err
+	}
+
+	privileges := []string{}
+	for _, g := range grants {
+		if !slices.Contains(privileges, g.Privilege) {
+			privileges = append(privileges, g.Privilege)
+		}
+	}
+	return privileges, nil
+}
+
+// userSearchPath reads the default search path of a user in all databases, empty when it isn't set
+func userSearchPath(ctx context.Context, client Executor, username string) (string, error) {
+	var settings pq.StringArray
+	err := client.QueryRowContext(ctx, "SELECT s.setconfig FROM pg_catalog.pg_db_role_setting s JOIN pg_catalog.pg_roles r ON r.oid = s.setrole WHERE r.rolname = $1 AND s.setdatabase = 0", username).Scan(&settings)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	for _, setting := range settings {
+		if value, ok := strings.CutPrefix(setting, "search_path="); ok {
+			return value, nil
+		}
+	}
+	return "", nil
+}
+
+// declaredPrivileges lists the privileges the user should hold in its database, including those of grant blocks on it
+func (m *UserResourceModel) declaredPrivileges(ctx context.Context) (types.List, diag.Diagnostics) {
+	declared := []string{}
+	diags := m.Privileges.ElementsAs(ctx, &declared, false)
+
+	blocks, d := userGrants(ctx, m.Grants)
+	diags.Append(d...)
+	for _, g := range blocks {
+		if g.Database.ValueString() != m.Database.ValueString() {
+			continue
+		}
+		for _, privilege := range g.privileges() {
+			if !slices.Contains(declared, privilege) {
+				declared = append(declared, privilege)
+			}
+		}
+	}
+
+	list, d := types.ListValueFrom(ctx, types.StringType, declared)
+	diags.Append(d...)
+	return list, diags
+}
+
+// revokeUnmanaged revokes every privilege the user holds in the database that isn't in the declared list
+func revokeUnmanaged(ctx context.Context, client Executor, database string, username string, privileges types.List) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	declared := []string{}
+	diags.Append(privileges.ElementsAs(ctx, &declared, false)...)
+	if diags.HasError() {
+		return diags
+	}
+
+	grants, err := showGrants(ctx, client, database, username)
+	if err != nil {
+		diags.AddError("Revoke unmanaged error", fmt.Sprintf("Unable to read grants, got error: %s", err))
+		return diags
+	}
+
+	revokes := []string{}
+	for _, g := range grants {
+		if !slices.Contains(declared, strings.ToLower(g.Privilege)) {
+			revokes = append(revokes, fmt.Sprintf("REVOKE %s ON %s FROM %s", g.Privilege, g.target(), pq.QuoteIdentifier(username)))
+		}
+	}
+
+	for _, revoke := range revokes {
+		if _, err := client.ExecContext(ctx, revoke); err != nil {
+			diags.AddError("Revoke unmanaged error", fmt.Sprintf("Unable to run %s, got error: %s", revoke, err))
+			return diags
+		}
+		tflog.Trace(ctx, "revoked an unmanaged privilege")
+	}
+	return diags
+}
+
+// ImportState takes an identifier of the form database:username or an identity, the password can't be read back and has to be added to the config
 func (r *UserResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+	if req.ID == "" {
+		var identity userIdentityModel
+		resp.Diagnostics.Append(req.Identity.Get(ctx, &identity)...)
+		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("database"), identity.Database.ValueString())...)
+		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("username"), identity.Username.ValueString())...)
+		return
+	}
+
+	database, username, ok := strings.Cut(req.ID, ":")
+	if !ok || database == "" || username == "" {
+		resp.Diagnostics.AddError(
+			"Unexpected import identifier",
+			fmt.Sprintf("Expected import identifier with format: database:username. Got: %q", req.ID),
+		)
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("database"), database)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("username"), username)...)
 }
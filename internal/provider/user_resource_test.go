@@ -0,0 +1,324 @@
+package provider
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"golang.org/x/exp/slices"
+)
+
+func TestReservedUsernameValidator(t *testing.T) {
+	for username, reserved := range map[string]bool{
+		"app_reader":       false,
+		"administrator":    false,
+		"root":             true,
+		"Admin":            true,
+		"public":           true,
+		"pg_monitor":       true,
+		"crdb_internal_me": true,
+	} {
+		req := validator.StringRequest{Path: path.Root("username"), ConfigValue: types.StringValue(username)}
+		var resp validator.StringResponse
+		reservedUsernameValidator{}.ValidateString(context.Background(), req, &resp)
+		if resp.Diagnostics.HasError() != reserved {
+			t.Errorf("expected %s reserved %t, got %t", username, reserved, resp.Diagnostics.HasError())
+		}
+	}
+}
+
+func TestUsernamePattern(t *testing.T) {
+	for username, valid := range map[string]bool{
+		"app_reader":   true,
+		"ci-runner.01": true,
+		"_svc":         true,
+		"-svc":         false,
+		"app reader":   false,
+		"app;drop":     false,
+	} {
+		if usernamePattern.MatchString(username) != valid {
+			t.Errorf("expected %q valid %t", username, valid)
+		}
+	}
+}
+
+func TestUserReadDroppedUser(t *testing.T) {
+	ctx := context.Background()
+
+	for name, recreate := range map[string]bool{"default": false, "recreate_if_missing": true} {
+		t.Run(name, func(t *testing.T) {
+			r := &UserResource{db: newMockClient(t,
+				mockQuery{contains: "SELECT version()", columns: []string{"version"}, rows: [][]driver.Value{{"CockroachDB CCL v23.1.4 (x86_64-pc-linux-gnu)"}}},
+				mockQuery{contains: "FROM system.users", columns: []string{"username", "user_id"}},
+			)}
+
+			var schemaResp resource.SchemaResponse
+			r.Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+			objectType := schemaResp.Schema.Type().TerraformType(ctx)
+
+			state := tfsdk.State{Schema: schemaResp.Schema, Raw: tftypes.NewValue(objectType, nil)}
+			diags := state.SetAttribute(ctx, path.Root("database"), "app")
+			diags.Append(state.SetAttribute(ctx, path.Root("username"), "dropped")...)
+			diags.Append(state.SetAttribute(ctx, path.Root("recreate_if_missing"), recreate)...)
+			if diags.HasError() {
+				t.Fatal(diags)
+			}
+
+			resp := resource.ReadResponse{State: state}
+			r.Read(ctx, resource.ReadRequest{State: state}, &resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatal(resp.Diagnostics)
+			}
+			if !resp.State.Raw.IsNull() {
+				t.Error("expected the dropped user to be removed from state")
+			}
+			if warned := resp.Diagnostics.WarningsCount() > 0; warned != recreate {
+				t.Errorf("expected a warning %t, got %t", recreate, warned)
+			}
+		})
+	}
+}
+
+func TestGrantedPrivileges(t *testing.T) {
+	ctx := context.Background()
+	client := newMockConn(t,
+		mockQuery{
+			contains: `SET DATABASE="app"; SHOW GRANTS FOR "app-user"`,
+			columns:  []string{"database_name", "schema_name", "relation_name", "grantee", "privilege_type", "is_grantable"},
+			rows: [][]driver.Value{
+				{"app", "public", "orders", "app-user", "SELECT", false},
+				{"app", "public", "users", "app-user", "SELECT", false},
+				{"app", "public", "users", "app-user", "UPDATE", false},
+			},
+		},
+		mockQuery{contains: `SHOW GRANTS FOR "app.user"`, err: errors.New("connection reset")},
+	)
+
+	privileges, err := grantedPrivileges(ctx, client, "app", "app-user")
+	if err != nil || !slices.Equal(privileges, []string{"SELECT", "UPDATE"}) {
+		t.Errorf("expected SELECT and UPDATE, got %v, %v", privileges, err)
+	}
+	if _, err := grantedPrivileges(ctx, client, "app", "app.user"); err == nil {
+		t.Error("expected the query error")
+	}
+}
+
+func TestVerifyUser(t *testing.T) {
+	ctx := context.Background()
+	client := newMockConn(t,
+		mockQuery{contains: "FROM [SHOW USERS]", columns: []string{"options"}, rows: [][]driver.Value{{"{CONTROLCHANGEFEED,VIEWACTIVITY}"}}},
+		mockQuery{contains: "FROM [SHOW USERS]", columns: []string{"options"}, rows: [][]driver.Value{{"NOLOGIN"}}},
+		mockQuery{contains: "FROM [SHOW USERS]", columns: []string{"options"}},
+	)
+
+	if diags := verifyUser(ctx, client, "feeds", []string{"CONTROLCHANGEFEED"}); diags.HasError() {
+		t.Errorf("expected the user to verify, got %v", diags)
+	}
+	if diags := verifyUser(ctx, client, "feeds", []string{"CONTROLCHANGEFEED"}); diags.ErrorsCount() != 2 {
+		t.Errorf("expected errors for NOLOGIN and the missing option, got %v", diags)
+	}
+	if diags := verifyUser(ctx, client, "feeds", nil); !diags.HasError() {
+		t.Error("expected an error for a missing user")
+	}
+}
+
+func TestParseRoleOptions(t *testing.T) {
+	options := parseRoleOptions(`NOLOGIN, VALID UNTIL=2026-01-01 00:00:00+00`)
+	if len(options) != 2 || options[0] != "NOLOGIN" || options[1] != "VALID UNTIL" {
+		t.Errorf("unexpected options %v", options)
+	}
+	if options := parseRoleOptions("{}"); len(options) != 0 {
+		t.Errorf("expected no options, got %v", options)
+	}
+}
+
+func TestUserPasswordSensitive(t *testing.T) {
+	var schemaResp resource.SchemaResponse
+	(&UserResource{}).Schema(context.Background(), resource.SchemaRequest{}, &schemaResp)
+	if !schemaResp.Schema.Attributes["password"].IsSensitive() {
+		t.Error("expected password to be sensitive")
+	}
+}
+
+func TestUserUpdateIgnoresPassword(t *testing.T) {
+	ctx := context.Background()
+	r := &UserResource{db: newMockClient(t)}
+
+	var schemaResp resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+	objectType := schemaResp.Schema.Type().TerraformType(ctx)
+
+	// Imported users have no password in state
+	state := tfsdk.State{Schema: schemaResp.Schema, Raw: tftypes.NewValue(objectType, nil)}
+	diags := state.SetAttribute(ctx, path.Root("database"), "app")
+	diags.Append(state.SetAttribute(ctx, path.Root("username"), "imported")...)
+	diags.Append(state.SetAttribute(ctx, path.Root("ignore_password_changes"), true)...)
+
+	plan := tfsdk.Plan{Schema: schemaResp.Schema, Raw: state.Raw.Copy()}
+	diags.Append(plan.SetAttribute(ctx, path.Root("password"), "from-config")...)
+	diags.Append(plan.SetAttribute(ctx, path.Root("effective_grants"), types.MapUnknown(types.StringType))...)
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	resp := resource.UpdateResponse{State: state}
+	r.Update(ctx, resource.UpdateRequest{State: state, Plan: plan}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatal(resp.Diagnostics)
+	}
+
+	var data UserResourceModel
+	resp.Diagnostics.Append(resp.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		t.Fatal(resp.Diagnostics)
+	}
+	if data.Password.ValueString() != "from-config" || !data.EffectiveGrants.IsNull() {
+		t.Errorf("expected the password to be recorded without touching the user, got %+v", data)
+	}
+
+	// Any other change still goes to the cluster
+	diags = plan.SetAttribute(ctx, path.Root("observability_access"), true)
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	if onlyPassword, err := onlyPasswordChanged(state.Raw, plan.Raw); err != nil || onlyPassword {
+		t.Errorf("expected observability_access to count as a change, got %t (%v)", onlyPassword, err)
+	}
+}
+
+func TestUserUpdateRevokesWithoutManagedPrivileges(t *testing.T) {
+	ctx := context.Background()
+	version := mockQuery{contains: "SELECT version()", columns: []string{"version"}, rows: [][]driver.Value{{"CockroachDB CCL v23.1.4 (x86_64-pc-linux-gnu)"}}}
+
+	for attribute, tc := range map[string]struct {
+		value   any
+		queries []mockQuery
+	}{
+		"observability_access": {true, []mockQuery{version, {contains: `REVOKE SYSTEM VIEWACTIVITY, VIEWCLUSTERSETTING FROM "feeds"`}}},
+		"control_changefeed":   {true, []mockQuery{version, {contains: `ALTER USER "feeds" WITH NOCONTROLCHANGEFEED`}}},
+		"external_connections": {[]string{"kafka"}, []mockQuery{{contains: `REVOKE USAGE ON EXTERNAL CONNECTION "kafka" FROM "feeds"`}}},
+	} {
+		t.Run(attribute, func(t *testing.T) {
+			queries := append(tc.queries, mockQuery{contains: `SHOW GRANTS FOR "feeds"`, columns: []string{"database_name", "schema_name", "relation_name", "grantee", "privilege_type", "is_grantable"}})
+			r := &UserResource{db: newMockClient(t, queries...)}
+
+			var schemaResp resource.SchemaResponse
+			r.Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+			objectType := schemaResp.Schema.Type().TerraformType(ctx)
+
+			// Only the attribute is dropped from the config, the password is left alone
+			plan := tfsdk.Plan{Schema: schemaResp.Schema, Raw: tftypes.NewValue(objectType, nil)}
+			diags := plan.SetAttribute(ctx, path.Root("database"), "app")
+			diags.Append(plan.SetAttribute(ctx, path.Root("username"), "feeds")...)
+			diags.Append(plan.SetAttribute(ctx, path.Root("manage_privileges"), false)...)
+			diags.Append(plan.SetAttribute(ctx, path.Root("ignore_password_changes"), true)...)
+			state := tfsdk.State{Schema: schemaResp.Schema, Raw: plan.Raw.Copy()}
+			diags.Append(state.SetAttribute(ctx, path.Root(attribute), tc.value)...)
+			if diags.HasError() {
+				t.Fatal(diags)
+			}
+
+			resp := resource.UpdateResponse{State: state}
+			r.Update(ctx, resource.UpdateRequest{State: state, Plan: plan}, &resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatal(resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestUserDeleteDropOwnedRevokesOtherDatabases(t *testing.T) {
+	ctx := context.Background()
+	r := &UserResource{db: newMockClient(t,
+		mockQuery{contains: "SELECT version()", columns: []string{"version"}, rows: [][]driver.Value{{"CockroachDB CCL v23.1.4 (x86_64-pc-linux-gnu)"}}},
+		mockQuery{contains: `REVOKE ALL ON ALL TABLES IN SCHEMA "reporting"."public" FROM "etl"`},
+		mockQuery{contains: `SET DATABASE="reporting"; ALTER DEFAULT PRIVILEGES FOR ALL ROLES IN SCHEMA "public" REVOKE ALL ON TABLES FROM "etl"`},
+		mockQuery{contains: `REVOKE USAGE ON EXTERNAL CONNECTION "kafka" FROM "etl"`},
+		mockQuery{contains: "SHOW TABLES", columns: []string{"schema_name"}},
+		mockQuery{contains: `DROP OWNED BY "etl"; DROP USER "etl";`},
+		mockQuery{contains: "DELETE FROM " + labelsTable},
+	)}
+
+	var schemaResp resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+	objectType := schemaResp.Schema.Type().TerraformType(ctx)
+
+	grants, diags := types.SetValueFrom(ctx, userGrantType, []userGrantModel{
+		{Database: types.StringValue("app"), Schema: types.StringValue("public"), Privileges: types.SetValueMust(types.StringType, []attr.Value{types.StringValue("select")})},
+		{Database: types.StringValue("reporting"), Schema: types.StringValue("public"), Privileges: types.SetValueMust(types.StringType, []attr.Value{types.StringValue("select")})},
+	})
+	state := tfsdk.State{Schema: schemaResp.Schema, Raw: tftypes.NewValue(objectType, nil)}
+	diags.Append(state.SetAttribute(ctx, path.Root("database"), "app")...)
+	diags.Append(state.SetAttribute(ctx, path.Root("username"), "etl")...)
+	diags.Append(state.SetAttribute(ctx, path.Root("drop_owned"), true)...)
+	diags.Append(state.SetAttribute(ctx, path.Root("external_connections"), []string{"kafka"})...)
+	diags.Append(state.SetAttribute(ctx, path.Root("grant"), grants)...)
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	resp := resource.DeleteResponse{State: state}
+	r.Delete(ctx, resource.DeleteRequest{State: state}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatal(resp.Diagnostics)
+	}
+}
+
+func TestSearchPathStatement(t *testing.T) {
+	if statement := searchPathStatement("app", ` app, "public" `); statement != `ALTER USER "app" SET search_path = 'app', 'public'` {
+		t.Errorf("unexpected statement %s", statement)
+	}
+	if statement := searchPathStatement("app", ""); statement != `ALTER USER "app" RESET search_path` {
+		t.Errorf("unexpected statement %s", statement)
+	}
+}
+
+func TestUserSearchPath(t *testing.T) {
+	ctx := context.Background()
+	client := newMockConn(t,
+		mockQuery{contains: "pg_db_role_setting", columns: []string{"setconfig"}, rows: [][]driver.Value{{`{timezone=UTC,"search_path=app, public"}`}}},
+		mockQuery{contains: "pg_db_role_setting", columns: []string{"setconfig"}},
+	)
+
+	searchPath, err := userSearchPath(ctx, client, "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if normalizeSearchPath(searchPath) != "app,public" {
+		t.Errorf("unexpected search path %q", searchPath)
+	}
+	if searchPath, err := userSearchPath(ctx, client, "app"); err != nil || searchPath != "" {
+		t.Errorf("expected no search path, got %q (%v)", searchPath, err)
+	}
+}
+
+func TestCheckDatabasesExist(t *testing.T) {
+	ctx := context.Background()
+	client := newMockConn(t,
+		mockQuery{contains: "SELECT version()", columns: []string{"version"}, rows: [][]driver.Value{{"CockroachDB CCL v23.2.1 (x86_64-pc-linux-gnu)"}}},
+		mockQuery{contains: "FROM crdb_internal.databases", columns: []string{"id", "name"}, rows: [][]driver.Value{{int64(104), "app"}}},
+		mockQuery{contains: "FROM crdb_internal.databases", columns: []string{"id", "name"}},
+	)
+
+	grants, diags := types.SetValueFrom(ctx, userGrantType, []userGrantModel{
+		{Database: types.StringValue("reporting"), Schema: types.StringValue("public"), Privileges: types.SetValueMust(types.StringType, []attr.Value{types.StringValue("select")})},
+	})
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	data := &UserResourceModel{Database: newIdentifierValue("app"), Grants: grants}
+
+	diags = checkDatabasesExist(ctx, client, data)
+	if diags.ErrorsCount() != 1 || !strings.Contains(diags.Errors()[0].Detail(), "Database reporting does not exist") {
+		t.Errorf("expected only reporting to be missing, got %v", diags)
+	}
+}
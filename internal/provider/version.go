@@ -0,0 +1,76 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+// serverVersionPattern pulls the release out of e.g. "CockroachDB CCL v22.2.6 (x86_64-pc-linux-gnu, ...)"
+var serverVersionPattern = regexp.MustCompile(`v(\d+)\.(\d+)\.(\d+)`)
+
+// serverVersion is the release of the cockroach cluster we're connected to
+type serverVersion struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// parseServerVersion parses the output of SELECT version()
+func parseServerVersion(s string) (serverVersion, error) {
+	m := serverVersionPattern.FindStringSubmatch(s)
+	if m == nil {
+		return serverVersion{}, fmt.Errorf("unable to find a cockroach release in %q", s)
+	}
+
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[2])
+	patch, _ := strconv.Atoi(m[3])
+	return serverVersion{Major: major, Minor: minor, Patch: patch}, nil
+}
+
+// AtLeast reports whether the server is on the given major.minor release or newer
+func (v serverVersion) AtLeast(major, minor int) bool {
+	if v.Major != major {
+		return v.Major > major
+	}
+	return v.Minor >= minor
+}
+
+func (v serverVersion) String() string {
+	return fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// versionCache remembers the server version and cluster id across connections of the same provider instance
+type versionCache struct {
+	mu        sync.Mutex
+	version   *serverVersion
+	clusterID string
+}
+
+// ServerVersion asks the cluster which release it runs, once per provider instance
+func (c *CockroachConn) ServerVersion(ctx context.Context) (serverVersion, error) {
+	if c.versions != nil {
+		c.versions.mu.Lock()
+		defer c.versions.mu.Unlock()
+		if c.versions.version != nil {
+			return *c.versions.version, nil
+		}
+	}
+
+	var raw string
+	if err := c.QueryRowContext(ctx, "SELECT version()").Scan(&raw); err != nil {
+		return serverVersion{}, err
+	}
+	version, err := parseServerVersion(raw)
+	if err != nil {
+		return serverVersion{}, err
+	}
+
+	if c.versions != nil {
+		c.versions.version = &version
+	}
+	return version, nil
+}
@@ -0,0 +1,21 @@
+package provider
+
+import "testing"
+
+func TestParseServerVersion(t *testing.T) {
+	v, err := parseServerVersion("CockroachDB CCL v22.2.6 (x86_64-pc-linux-gnu, built 2023/03/03 17:37:42, go1.19.6)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != (serverVersion{Major: 22, Minor: 2, Patch: 6}) {
+		t.Errorf("unexpected version %s", v)
+	}
+
+	if !v.AtLeast(22, 2) || !v.AtLeast(21, 2) || v.AtLeast(23, 1) {
+		t.Errorf("unexpected AtLeast results for %s", v)
+	}
+
+	if _, err := parseServerVersion("PostgreSQL 15"); err == nil {
+		t.Error("expected an error for a non cockroach version string")
+	}
+}
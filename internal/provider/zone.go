@@ -0,0 +1,53 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// databaseZoneConfig returns the zone configuration of a database as SQL, e.g.
+//
+//	ALTER DATABASE app CONFIGURE ZONE USING
+//		range_min_bytes = 134217728,
+//		gc.ttlseconds = 600,
+//		...
+func databaseZoneConfig(ctx context.Context, client Executor, database string) (string, error) {
+	var raw string
+	err := client.QueryRowContext(ctx, fmt.Sprintf("SELECT raw_config_sql FROM [SHOW ZONE CONFIGURATION FROM DATABASE %s]", pq.QuoteIdentifier(database))).Scan(&raw)
+	return raw, err
+}
+
+// zoneConfigInt pulls an integer setting such as gc.ttlseconds out of a zone configuration
+func zoneConfigInt(raw string, key string) (int64, bool) {
+	m := regexp.MustCompile(regexp.QuoteMeta(key) + `\s*=\s*(\d+)`).FindStringSubmatch(raw)
+	if m == nil {
+		return 0, false
+	}
+	value, err := strconv.ParseInt(m[1], 10, 64)
+	return value, err == nil
+}
+
+// zoneConfigString pulls a quoted setting such as lease_preferences out of a zone configuration, without matching
+// voter_constraints when asked for constraints
+func zoneConfigString(raw string, key string) (string, bool) {
+	m := regexp.MustCompile(`(?:^|[\s,])` + regexp.QuoteMeta(key) + `\s*=\s*'((?:[^']|'')*)'`).FindStringSubmatch(raw)
+	if m == nil {
+		return "", false
+	}
+	return strings.ReplaceAll(m[1], "''", "'"), true
+}
+
+// setDatabaseGCTTL configures how long old row versions are kept, a negative ttl goes back to inheriting the cluster default
+func setDatabaseGCTTL(ctx context.Context, client Executor, database string, ttl int64) error {
+	value := "COPY FROM PARENT"
+	if ttl >= 0 {
+		value = strconv.FormatInt(ttl, 10)
+	}
+	_, err := client.ExecContext(ctx, fmt.Sprintf("ALTER DATABASE %s CONFIGURE ZONE USING gc.ttlseconds = %s", pq.QuoteIdentifier(database), value))
+	return err
+}
@@ -0,0 +1,37 @@
+package provider
+
+import "testing"
+
+func TestZoneConfigInt(t *testing.T) {
+	raw := `ALTER DATABASE app CONFIGURE ZONE USING
+	range_min_bytes = 134217728,
+	range_max_bytes = 536870912,
+	gc.ttlseconds = 600,
+	num_replicas = 3`
+
+	if ttl, ok := zoneConfigInt(raw, "gc.ttlseconds"); !ok || ttl != 600 {
+		t.Errorf("expected gc.ttlseconds 600, got %d (found %v)", ttl, ok)
+	}
+	if _, ok := zoneConfigInt(raw, "num_voters"); ok {
+		t.Error("expected num_voters to be missing")
+	}
+}
+
+func TestZoneConfigString(t *testing.T) {
+	raw := `ALTER DATABASE app CONFIGURE ZONE USING
+	num_replicas = 5,
+	num_voters = 3,
+	constraints = '{+region=us-east1: 1, +region=us-west1: 1}',
+	voter_constraints = '[+region=us-east1]',
+	lease_preferences = '[[+region=us-east1]]'`
+
+	if constraints, ok := zoneConfigString(raw, "constraints"); !ok || constraints != "{+region=us-east1: 1, +region=us-west1: 1}" {
+		t.Errorf("unexpected constraints %q (found %v)", constraints, ok)
+	}
+	if preferences, ok := zoneConfigString(raw, "lease_preferences"); !ok || preferences != "[[+region=us-east1]]" {
+		t.Errorf("unexpected lease preferences %q (found %v)", preferences, ok)
+	}
+	if _, ok := zoneConfigString("ALTER DATABASE app CONFIGURE ZONE USING voter_constraints = '[+region=us-east1]'", "constraints"); ok {
+		t.Error("expected constraints not to match voter_constraints")
+	}
+}
@@ -24,6 +24,9 @@ var (
 	// to appropriate values for the compiled binary.
 	version string = "dev"
 
+	// address is where terraform finds the provider, builds registering a different type name override it too
+	address string = "registry.terraform.io/pgdevelopers/terraform-provider-cockroachgke"
+
 	// goreleaser can pass other information to the main package, such as the specific commit
 	// https://goreleaser.com/cookbooks/using-main.version/
 )
@@ -35,8 +38,7 @@ func main() {
 	flag.Parse()
 
 	opts := providerserver.ServeOpts{
-		// TODO: Update this string with the published name of your provider.
-		Address: "registry.terraform.io/pgdevelopers/terraform-provider-cockroachgke",
+		Address: address,
 		Debug:   debug,
 	}
 